@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const (
+	allPagesFlag string = "cli.all-pages"
+	maxItemsFlag string = "cli.max-items"
+)
+
+func addAllPagesFlags(cmd *cobra.Command) {
+	cmd.Root().PersistentFlags().Bool(
+		allPagesFlag,
+		false,
+		`Follow all the pages of list actions and merge their items in a single result. Note that not all
+actions implement ExecuteAllPages(), if that is not available, then regular Execute() is used`,
+	)
+	cmd.Root().PersistentFlags().Lookup(allPagesFlag).NoOptDefVal = "true"
+
+	cmd.Root().PersistentFlags().Int(
+		maxItemsFlag,
+		0,
+		`If > 0, follow the pages of list actions until this number of items is collected. Implies --`+allPagesFlag,
+	)
+}
+
+func getAllPagesFlag(cmd *cobra.Command) bool {
+	v, err := cmd.Root().PersistentFlags().GetBool(allPagesFlag)
+	if err != nil {
+		return false
+	}
+	return v
+}
+
+func getMaxItemsFlag(cmd *cobra.Command) int {
+	v, err := cmd.Root().PersistentFlags().GetInt(maxItemsFlag)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v
+}
@@ -12,6 +12,12 @@ import (
 )
 
 func formatResult(sdk *mgcSdk.Sdk, cmd *cobra.Command, result core.Result) error {
+	// Object streams (such as cat) are written as is, unless the user explicitly asked for a format.
+	// The configured default output doesn't apply to them
+	if stream, ok := core.ResultAs[*core.SimpleResultWithReader](result); ok {
+		return handleResultWithReader(stream.Reader(), getOutputFlag(cmd), cmd)
+	}
+
 	output := getOutputFor(sdk, cmd, result)
 
 	if resultWithReader, ok := core.ResultAs[core.ResultWithReader](result); ok {
@@ -117,11 +117,17 @@ func handleExecutorPre(
 	}
 
 	waitTermination := getWaitTerminationFlag(cmd)
+	maxItems := getMaxItemsFlag(cmd)
+	allPages := getAllPagesFlag(cmd) || maxItems > 0
 	var cb core.RetryUntilCb
 	if tExec, ok := core.ExecutorAs[core.TerminatorExecutor](exec); ok && waitTermination {
 		cb = func() (result core.Result, err error) {
 			return tExec.ExecuteUntilTermination(ctx, parameters, configs)
 		}
+	} else if allPages {
+		cb = func() (result core.Result, err error) {
+			return core.ExecuteAllPages(ctx, exec, parameters, configs, maxItems)
+		}
 	} else {
 		cb = func() (result core.Result, err error) {
 			return exec.Execute(ctx, parameters, configs)
@@ -7,6 +7,7 @@ import (
 	"maps"
 	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"unicode"
@@ -14,6 +15,7 @@ import (
 	"github.com/MagaluCloud/magalu/mgc/core"
 	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
 	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/invopop/yaml"
 )
 
 const (
@@ -25,8 +27,8 @@ const (
 
 // handles special cases, in order:
 //  1. "": empty value is returned. No error.
-//  2. "@filename": load JSON from file.
-//     Returns error if file was not found or it's not a valid JSON for type "T".
+//  2. "@filename": load JSON from file. Files with ".yaml" or ".yml" extension are loaded as YAML.
+//     Returns error if file was not found or it's not a valid JSON (or YAML) for type "T".
 //  3. try to JSON parse as value type "T"
 func parseJSONFlagValue[T any](rawValue string) (value T, err error) {
 	switch {
@@ -577,6 +579,14 @@ func loadJSONFromFile[T any](filename string) (value T, err error) {
 		return
 	}
 
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		data, err = yaml.YAMLToJSON(data)
+		if err != nil {
+			return
+		}
+	}
+
 	err = json.Unmarshal(data, &value)
 	return
 }
@@ -3,6 +3,8 @@ package schema_flags
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -636,6 +638,33 @@ func Test_parseObjectFlagValue(t *testing.T) {
 	checkObject(t, "", expected, got)
 }
 
+func Test_parseJSONFlagValueFromFile(t *testing.T) {
+	dir := t.TempDir()
+	expected := map[string]any{
+		"str":   "word",
+		"array": []any{1.0, true},
+	}
+
+	files := map[string]string{
+		"value.json": `{"str": "word", "array": [1, true]}`,
+		"value.yaml": "str: word\narray:\n  - 1\n  - true\n",
+		"value.YML":  "str: word\narray: [1, true]\n",
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(dir, name)
+			if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := parseJSONFlagValue[map[string]any](ValueLoadJSONFromFilePrefix + filename)
+			checkError(t, nil, err)
+			checkObject(t, "", expected, got)
+		})
+	}
+}
+
 func Test_help(t *testing.T) {
 	types := []string{"array", "boolean", "integer", "string", "object", ""}
 	for _, typ := range types {
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -32,7 +33,24 @@ func NewClientRetryerWithAttempts(transport http.RoundTripper, attempts int) *Cl
 	}
 }
 
-func (r *ClientRetryer) cloneRequestBody(req *http.Request) (io.Reader, error) {
+var requestBodyWrapperKey contextKey = "github.com/MagaluCloud/magalu/mgc/core/RequestBodyWrapper"
+
+// Wraps the body sent by each attempt of a request, such as to limit its bandwidth
+type RequestBodyWrapper func(ctx context.Context, body io.Reader) io.Reader
+
+// Requests created with the returned context have their body wrapped by ClientRetryer.
+// The body is buffered to be resent, so wrapping it when creating the request
+// would only affect the buffering, not what's sent
+func NewRequestBodyWrapperContext(parent context.Context, wrapper RequestBodyWrapper) context.Context {
+	return context.WithValue(parent, requestBodyWrapperKey, wrapper)
+}
+
+func requestBodyWrapperFromContext(ctx context.Context) RequestBodyWrapper {
+	wrapper, _ := ctx.Value(requestBodyWrapperKey).(RequestBodyWrapper)
+	return wrapper
+}
+
+func (r *ClientRetryer) cloneRequestBody(req *http.Request) (*bytes.Reader, error) {
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
 		logger().Error(err)
@@ -45,19 +63,26 @@ func (r *ClientRetryer) cloneRequestBody(req *http.Request) (io.Reader, error) {
 
 func (r *ClientRetryer) cloneRequest(req *http.Request) *http.Request {
 	var body io.Reader
-	var err error
+	var size int64
 
 	if req.Body != nil {
-		body, err = r.cloneRequestBody(req)
+		cloned, err := r.cloneRequestBody(req)
 		if err != nil {
 			logger().Error(err)
 			return req
 		}
+		if cloned != nil {
+			body, size = cloned, cloned.Size()
+			if wrap := requestBodyWrapperFromContext(req.Context()); wrap != nil && size > 0 {
+				body = wrap(req.Context(), body)
+			}
+		}
 	}
 	clonedRequest, err := http.NewRequestWithContext(req.Context(), req.Method, req.URL.String(), body)
 	if err != nil {
 		return req
 	}
+	clonedRequest.ContentLength = size
 	clonedRequest.Header = req.Header
 	return clonedRequest
 }
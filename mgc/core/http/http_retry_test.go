@@ -0,0 +1,70 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// Fails the first request with 500, then succeeds, recording what was sent
+type flakyTransport struct {
+	bodies         []string
+	contentLengths []int64
+}
+
+func (o *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	o.bodies = append(o.bodies, string(body))
+	o.contentLengths = append(o.contentLengths, req.ContentLength)
+
+	status := http.StatusOK
+	if len(o.bodies) == 1 {
+		status = http.StatusInternalServerError
+	}
+	return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+}
+
+type countingReader struct {
+	io.Reader
+	read *int
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	*r.read += n
+	return n, err
+}
+
+func TestRetryerWrapsEachAttempt(t *testing.T) {
+	read := 0
+	ctx := NewRequestBodyWrapperContext(context.Background(), func(ctx context.Context, body io.Reader) io.Reader {
+		return countingReader{body, &read}
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, "http://localhost/object", bytes.NewReader([]byte("content")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &flakyTransport{}
+	if _, err = NewClientRetryerWithAttempts(transport, 2).RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() failed: %s", err)
+	}
+
+	if len(transport.bodies) != 2 || transport.bodies[0] != "content" || transport.bodies[1] != "content" {
+		t.Errorf("expected the body to be sent twice, got %q", transport.bodies)
+	}
+	for i, length := range transport.contentLengths {
+		if length != int64(len("content")) {
+			t.Errorf("attempt %d: expected the content length to be kept, got %d", i, length)
+		}
+	}
+	if read != 2*len("content") {
+		t.Errorf("expected both attempts to go through the wrapper, %d bytes read", read)
+	}
+}
@@ -15,6 +15,10 @@ func isHeaderSensitive(canonicalKey string) bool {
 		return true
 	case "X-Api-Key":
 		return true
+	case "X-Amz-Server-Side-Encryption-Customer-Key":
+		return true
+	case "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key":
+		return true
 	default:
 		return false
 	}
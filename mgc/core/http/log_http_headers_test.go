@@ -0,0 +1,33 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogHttpHeadersRedactsSensitive(t *testing.T) {
+	logSensitive := false
+	shouldLogSensitiveStatus = &logSensitive
+	defer func() { shouldLogSensitiveStatus = nil }()
+
+	secret := "c2VjcmV0LWN1c3RvbWVyLWtleS1ub3QtdG8tYmUtbG9nZ2Vk"
+	h := http.Header{}
+	h.Set("Authorization", secret)
+	h.Set("X-Amz-Server-Side-Encryption-Customer-Key", secret)
+	h.Set("X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key", secret)
+	h.Set("X-Amz-Server-Side-Encryption-Customer-Key-Md5", "md5-value")
+
+	data, err := json.Marshal(LogHttpHeaders(h))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if strings.Contains(string(data), secret) {
+		t.Errorf("sensitive value was logged: %s", data)
+	}
+	if !strings.Contains(string(data), "md5-value") {
+		t.Errorf("non-sensitive value was not logged: %s", data)
+	}
+}
@@ -0,0 +1,408 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+type PaginationStrategy string
+
+// Returned when the server keeps returning pages after PaginationConfig.MaxPages, instead of
+// silently truncating the items
+var ErrPaginationMaxPages = errors.New("pagination exceeded the maximum number of pages")
+
+const (
+	// Uses an offset (number of items to skip) and a limit (items per page)
+	PaginationStrategyOffsetLimit PaginationStrategy = "offset-limit"
+	// Uses a page number and the page size (items per page)
+	PaginationStrategyPageSize PaginationStrategy = "page-size"
+	// Uses an opaque cursor returned by the previous page
+	PaginationStrategyNextCursor PaginationStrategy = "next-cursor"
+)
+
+type PaginatedExecutor interface {
+	Executor
+	// Execute the operation following all the pages and merge the items into a single result.
+	//
+	// If maxItems > 0, then no more pages are requested once that many items were collected
+	// and the merged items are truncated to maxItems
+	ExecuteAllPages(context context.Context, parameters Parameters, configs Configs, maxItems int) (result Result, err error)
+}
+
+type PaginationConfig struct {
+	Strategy PaginationStrategy `json:"strategy"`
+	// Dot-separated path to the items array inside the result value, ex: "instances" or "data.items".
+	// If empty, the result value itself must be the array
+	ItemsField string `json:"itemsField,omitempty"`
+	// Used with "offset-limit", defaults to "_offset"
+	OffsetParameter string `json:"offsetParameter,omitempty"`
+	// Used with "offset-limit", defaults to "_limit"
+	LimitParameter string `json:"limitParameter,omitempty"`
+	// Used with "page-size", defaults to "_page"
+	PageParameter string `json:"pageParameter,omitempty"`
+	// Used with "page-size", defaults to "_size"
+	SizeParameter string `json:"sizeParameter,omitempty"`
+	// Used with "page-size", the number of the first page. Defaults to 1
+	FirstPage int `json:"firstPage,omitempty"`
+	// Used with "next-cursor", defaults to "cursor"
+	CursorParameter string `json:"cursorParameter,omitempty"`
+	// Used with "next-cursor", the JSON Path to the next cursor inside the result value.
+	// Pagination finishes once it's missing or empty
+	NextCursorJSONPath string `json:"nextCursorJsonPath,omitempty"`
+	// Items per page to request if the user didn't specify one.
+	// If zero, the default of the limit/size parameter schema is used, if any
+	PageSize int `json:"pageSize,omitempty"`
+	// Safety net against servers that never stop returning pages, exceeding it fails with
+	// ErrPaginationMaxPages. Defaults to 10000
+	MaxPages int `json:"maxPages,omitempty"`
+}
+
+var defaultPagination = PaginationConfig{
+	OffsetParameter: "_offset",
+	LimitParameter:  "_limit",
+	PageParameter:   "_page",
+	SizeParameter:   "_size",
+	FirstPage:       1,
+	CursorParameter: "cursor",
+	MaxPages:        10000,
+}
+
+func (c *PaginationConfig) withDefaults() PaginationConfig {
+	r := *c
+	if r.OffsetParameter == "" {
+		r.OffsetParameter = defaultPagination.OffsetParameter
+	}
+	if r.LimitParameter == "" {
+		r.LimitParameter = defaultPagination.LimitParameter
+	}
+	if r.PageParameter == "" {
+		r.PageParameter = defaultPagination.PageParameter
+	}
+	if r.SizeParameter == "" {
+		r.SizeParameter = defaultPagination.SizeParameter
+	}
+	if r.FirstPage <= 0 {
+		r.FirstPage = defaultPagination.FirstPage
+	}
+	if r.CursorParameter == "" {
+		r.CursorParameter = defaultPagination.CursorParameter
+	}
+	if r.MaxPages <= 0 {
+		r.MaxPages = defaultPagination.MaxPages
+	}
+	return r
+}
+
+func (c *PaginationConfig) Build(exec Executor) (pExec PaginatedExecutor, err error) {
+	cfg := c.withDefaults()
+
+	var getNextCursor func(value Value) (string, error)
+	switch cfg.Strategy {
+	case PaginationStrategyOffsetLimit, PaginationStrategyPageSize:
+	case PaginationStrategyNextCursor:
+		if cfg.NextCursorJSONPath == "" {
+			return nil, errors.New("next-cursor pagination needs nextCursorJsonPath")
+		}
+		jp, err := utils.NewJsonPath(cfg.NextCursorJSONPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid nextCursorJsonPath: %w", err)
+		}
+		getNextCursor = func(value Value) (string, error) {
+			v, err := jp(context.Background(), value)
+			if err != nil || v == nil {
+				return "", err
+			}
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return fmt.Sprint(v), nil
+		}
+	case "":
+		return nil, errors.New("missing pagination strategy")
+	default:
+		return nil, fmt.Errorf("unknown pagination strategy %q, supported: %s|%s|%s",
+			cfg.Strategy,
+			PaginationStrategyOffsetLimit,
+			PaginationStrategyPageSize,
+			PaginationStrategyNextCursor,
+		)
+	}
+
+	return &paginatedExecutor{exec, cfg, getNextCursor}, nil
+}
+
+func (c *PaginationConfig) UnmarshalJSON(data []byte) (err error) {
+	m := map[string]any{}
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return
+	}
+	return utils.DecodeValue(m, c)
+}
+
+var _ json.Unmarshaler = (*PaginationConfig)(nil)
+
+type paginatedExecutor struct {
+	Executor
+	cfg           PaginationConfig
+	getNextCursor func(value Value) (string, error)
+}
+
+func (o *paginatedExecutor) Unwrap() Executor {
+	return o.Executor
+}
+
+func (o *paginatedExecutor) Execute(ctx context.Context, parameters Parameters, configs Configs) (result Result, err error) {
+	result, err = o.Executor.Execute(ctx, parameters, configs)
+	return ExecutorWrapResult(o, result, err)
+}
+
+func (o *paginatedExecutor) ExecuteAllPages(ctx context.Context, parameters Parameters, configs Configs, maxItems int) (result Result, err error) {
+	result, err = o.executeAllPages(ctx, parameters, configs, maxItems)
+	return ExecutorWrapResult(o, result, err)
+}
+
+// Returns the page size parameter name and its value: given by the user, configured or the schema default.
+// Zero means unknown, then only an empty page finishes the pagination
+func (o *paginatedExecutor) pageSize(parameters Parameters) (name string, size int) {
+	switch o.cfg.Strategy {
+	case PaginationStrategyOffsetLimit:
+		name = o.cfg.LimitParameter
+	case PaginationStrategyPageSize:
+		name = o.cfg.SizeParameter
+	default:
+		return
+	}
+
+	if v, ok := toPaginationInt(parameters[name]); ok {
+		return name, v
+	}
+	if o.cfg.PageSize > 0 {
+		return name, o.cfg.PageSize
+	}
+	if propRef, ok := o.ParametersSchema().Properties[name]; ok && propRef != nil && propRef.Value != nil {
+		if v, ok := toPaginationInt(propRef.Value.Default); ok {
+			return name, v
+		}
+	}
+	return name, 0
+}
+
+func (o *paginatedExecutor) executeAllPages(ctx context.Context, parameters Parameters, configs Configs, maxItems int) (result Result, err error) {
+	pageParameters := make(Parameters, len(parameters)+2)
+	for k, v := range parameters {
+		pageParameters[k] = v
+	}
+
+	sizeName, size := o.pageSize(parameters)
+	if size > 0 && sizeName != "" {
+		pageParameters[sizeName] = size
+	}
+
+	offset, _ := toPaginationInt(parameters[o.cfg.OffsetParameter])
+	page, ok := toPaginationInt(parameters[o.cfg.PageParameter])
+	if !ok {
+		page = o.cfg.FirstPage
+	}
+
+	var first ResultWithValue
+	var items []any
+	complete := false
+
+	for i := 0; i < o.cfg.MaxPages && !complete; i++ {
+		switch o.cfg.Strategy {
+		case PaginationStrategyOffsetLimit:
+			pageParameters[o.cfg.OffsetParameter] = offset
+		case PaginationStrategyPageSize:
+			pageParameters[o.cfg.PageParameter] = page
+		}
+
+		pageResult, err := o.Executor.Execute(ctx, pageParameters, configs)
+		if err != nil {
+			return pageResult, err
+		}
+		resultWithValue, ok := ResultAs[ResultWithValue](pageResult)
+		if !ok {
+			return pageResult, ErrorResultHasNoValue
+		}
+		if first == nil {
+			first = resultWithValue
+		}
+
+		pageItems, err := getPaginationItems(resultWithValue.Value(), o.cfg.ItemsField)
+		if err != nil {
+			return pageResult, err
+		}
+		items = append(items, pageItems...)
+
+		if maxItems > 0 && len(items) >= maxItems {
+			items = items[:maxItems]
+			complete = true
+			break
+		}
+		if len(pageItems) == 0 {
+			complete = true
+			break
+		}
+
+		switch o.cfg.Strategy {
+		case PaginationStrategyOffsetLimit:
+			offset += len(pageItems)
+			complete = size > 0 && len(pageItems) < size
+		case PaginationStrategyPageSize:
+			page++
+			complete = size > 0 && len(pageItems) < size
+		case PaginationStrategyNextCursor:
+			cursor, err := o.getNextCursor(resultWithValue.Value())
+			if err != nil {
+				return pageResult, err
+			}
+			pageParameters[o.cfg.CursorParameter] = cursor
+			complete = cursor == ""
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+
+	if !complete {
+		return first, fmt.Errorf("%w: stopped after %d pages with %d items", ErrPaginationMaxPages, o.cfg.MaxPages, len(items))
+	}
+	if items == nil {
+		items = []any{}
+	}
+	value, err := setPaginationItems(first.Value(), o.cfg.ItemsField, items)
+	if err != nil {
+		return first, err
+	}
+
+	source := first.Source()
+	source.Parameters = parameters
+	return &resultWithMergedPages{first, source, value}, nil
+}
+
+var _ PaginatedExecutor = (*paginatedExecutor)(nil)
+var _ ExecutorWrapper = (*paginatedExecutor)(nil)
+
+// Follow all pages if the executor supports pagination, otherwise execute it once
+func ExecuteAllPages(ctx context.Context, exec Executor, parameters Parameters, configs Configs, maxItems int) (result Result, err error) {
+	if pExec, ok := ExecutorAs[PaginatedExecutor](exec); ok {
+		return pExec.ExecuteAllPages(ctx, parameters, configs, maxItems)
+	}
+	return exec.Execute(ctx, parameters, configs)
+}
+
+// The result of the first page, with all the items merged into it
+type resultWithMergedPages struct {
+	ResultWithValue
+	source ResultSource
+	value  Value
+}
+
+func (o *resultWithMergedPages) Source() ResultSource {
+	return o.source
+}
+
+func (o *resultWithMergedPages) Value() Value {
+	return o.value
+}
+
+func (o *resultWithMergedPages) ValidateSchema() error {
+	return o.Schema().VisitJSON(o.value, openapi3.MultiErrors())
+}
+
+func (o *resultWithMergedPages) Encode() ([]byte, error) {
+	return json.Marshal(o.value)
+}
+
+func (o *resultWithMergedPages) Decode(data []byte) error {
+	return json.Unmarshal(data, &o.value)
+}
+
+func (o *resultWithMergedPages) Unwrap() Result {
+	return o.ResultWithValue
+}
+
+var _ ResultWithValue = (*resultWithMergedPages)(nil)
+var _ ResultWrapper = (*resultWithMergedPages)(nil)
+
+func splitPaginationItemsField(field string) []string {
+	if field == "" {
+		return nil
+	}
+	return strings.Split(field, ".")
+}
+
+func getPaginationItems(value Value, field string) ([]any, error) {
+	current := value
+	for _, key := range splitPaginationItemsField(field) {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("pagination: expected object to get %q, got %T", key, current)
+		}
+		current = m[key]
+	}
+
+	switch v := current.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("pagination: expected array of items at %q, got %T", field, current)
+	}
+}
+
+// Returns a copy of value with the items replaced, the original value is not modified
+func setPaginationItems(value Value, field string, items []any) (Value, error) {
+	keys := splitPaginationItemsField(field)
+	if len(keys) == 0 {
+		return items, nil
+	}
+
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("pagination: expected object to set %q, got %T", keys[0], value)
+	}
+
+	child, err := setPaginationItems(m[keys[0]], strings.Join(keys[1:], "."), items)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	result[keys[0]] = child
+	return result, nil
+}
+
+func toPaginationInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case float32:
+		return int(n), true
+	case float64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+)
+
+func newPagedTestExecutor(total int, execute func(parameters Parameters, all []any) Value) *SimpleExecutor {
+	all := make([]any, total)
+	for i := range all {
+		all[i] = fmt.Sprintf("item-%d", i)
+	}
+
+	return NewSimpleExecutor(ExecutorSpec{
+		DescriptorSpec:   DescriptorSpec{Name: "list", Description: "list"},
+		ParametersSchema: mgcSchemaPkg.NewObjectSchema(map[string]*Schema{}, nil),
+		ConfigsSchema:    mgcSchemaPkg.NewObjectSchema(map[string]*Schema{}, nil),
+		ResultSchema:     mgcSchemaPkg.NewAnySchema(),
+		Execute: func(exec Executor, ctx context.Context, parameters Parameters, configs Configs) (Result, error) {
+			value := execute(parameters, all)
+			return NewSimpleResult(ResultSource{Executor: exec, Context: ctx, Parameters: parameters, Configs: configs}, exec.ResultSchema(), value), nil
+		},
+	})
+}
+
+func slicePage(all []any, start, count int) []any {
+	if start > len(all) {
+		start = len(all)
+	}
+	end := start + count
+	if end > len(all) {
+		end = len(all)
+	}
+	return all[start:end]
+}
+
+func expectedPagedItems(n int) []any {
+	items := make([]any, n)
+	for i := range items {
+		items[i] = fmt.Sprintf("item-%d", i)
+	}
+	return items
+}
+
+func TestPaginatedExecutor(t *testing.T) {
+	offsetLimit := func(parameters Parameters, all []any) Value {
+		offset, _ := toPaginationInt(parameters["_offset"])
+		limit, _ := toPaginationInt(parameters["_limit"])
+		return map[string]any{
+			"meta":      map[string]any{"offset": offset},
+			"instances": slicePage(all, offset, limit),
+		}
+	}
+	pageSize := func(parameters Parameters, all []any) Value {
+		page, _ := toPaginationInt(parameters["_page"])
+		size, _ := toPaginationInt(parameters["_size"])
+		return map[string]any{"data": map[string]any{"items": slicePage(all, (page-1)*size, size)}}
+	}
+	nextCursor := func(parameters Parameters, all []any) Value {
+		start := 0
+		if c, ok := parameters["cursor"].(string); ok && c != "" {
+			_, _ = fmt.Sscanf(c, "c%d", &start)
+		}
+		items := slicePage(all, start, 4)
+		next := ""
+		if start+len(items) < len(all) {
+			next = fmt.Sprintf("c%d", start+len(items))
+		}
+		return map[string]any{"items": items, "next": next}
+	}
+
+	tests := []struct {
+		name       string
+		cfg        PaginationConfig
+		total      int
+		parameters Parameters
+		maxItems   int
+		execute    func(parameters Parameters, all []any) Value
+		getItems   func(value Value) any
+		expected   int
+	}{
+		{
+			name:       "offset-limit/exact",
+			cfg:        PaginationConfig{Strategy: PaginationStrategyOffsetLimit, ItemsField: "instances"},
+			total:      30,
+			parameters: Parameters{"_limit": 10},
+			execute:    offsetLimit,
+			getItems:   func(v Value) any { return v.(map[string]any)["instances"] },
+			expected:   30,
+		},
+		{
+			name:     "offset-limit/page-size-from-config",
+			cfg:      PaginationConfig{Strategy: PaginationStrategyOffsetLimit, ItemsField: "instances", PageSize: 7},
+			total:    23,
+			execute:  offsetLimit,
+			getItems: func(v Value) any { return v.(map[string]any)["instances"] },
+			expected: 23,
+		},
+		{
+			name:       "offset-limit/max-items",
+			cfg:        PaginationConfig{Strategy: PaginationStrategyOffsetLimit, ItemsField: "instances"},
+			total:      30,
+			parameters: Parameters{"_limit": 10},
+			maxItems:   15,
+			execute:    offsetLimit,
+			getItems:   func(v Value) any { return v.(map[string]any)["instances"] },
+			expected:   15,
+		},
+		{
+			name:       "page-size/nested-items",
+			cfg:        PaginationConfig{Strategy: PaginationStrategyPageSize, ItemsField: "data.items"},
+			total:      12,
+			parameters: Parameters{"_size": 5},
+			execute:    pageSize,
+			getItems:   func(v Value) any { return v.(map[string]any)["data"].(map[string]any)["items"] },
+			expected:   12,
+		},
+		{
+			name: "next-cursor",
+			cfg: PaginationConfig{
+				Strategy:           PaginationStrategyNextCursor,
+				ItemsField:         "items",
+				NextCursorJSONPath: "$.next",
+			},
+			total:    10,
+			execute:  nextCursor,
+			getItems: func(v Value) any { return v.(map[string]any)["items"] },
+			expected: 10,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pExec, err := tc.cfg.Build(newPagedTestExecutor(tc.total, tc.execute))
+			if err != nil {
+				t.Fatalf("unexpected build error: %s", err)
+			}
+
+			parameters := tc.parameters
+			if parameters == nil {
+				parameters = Parameters{}
+			}
+			result, err := pExec.ExecuteAllPages(context.Background(), parameters, Configs{}, tc.maxItems)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			resultWithValue, ok := ResultAs[ResultWithValue](result)
+			if !ok {
+				t.Fatalf("expected result with value, got %T", result)
+			}
+			got := tc.getItems(resultWithValue.Value())
+			if expected := expectedPagedItems(tc.expected); !reflect.DeepEqual(got, expected) {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+			if result.Source().Executor != pExec {
+				t.Errorf("expected result source to be the paginated executor")
+			}
+			if _, ok := parameters["_offset"]; ok {
+				t.Errorf("original parameters must not be modified: %v", parameters)
+			}
+		})
+	}
+}
+
+func TestPaginationConfigBuildErrors(t *testing.T) {
+	exec := newPagedTestExecutor(0, func(parameters Parameters, all []any) Value { return all })
+
+	for _, cfg := range []PaginationConfig{
+		{},
+		{Strategy: "unknown"},
+		{Strategy: PaginationStrategyNextCursor},
+	} {
+		if _, err := cfg.Build(exec); err == nil {
+			t.Errorf("expected error building %#v", cfg)
+		}
+	}
+}
+
+func TestPaginatedExecutorMaxPages(t *testing.T) {
+	// Never returns an empty page nor a cursor to stop
+	exec := newPagedTestExecutor(3, func(parameters Parameters, all []any) Value {
+		return map[string]any{"items": all, "next": "again"}
+	})
+	cfg := PaginationConfig{Strategy: PaginationStrategyNextCursor, ItemsField: "items", NextCursorJSONPath: "$.next", MaxPages: 4}
+	pExec, err := cfg.Build(exec)
+	if err != nil {
+		t.Fatalf("unexpected build error: %s", err)
+	}
+
+	_, err = pExec.ExecuteAllPages(context.Background(), Parameters{}, Configs{}, 0)
+	if !errors.Is(err, ErrPaginationMaxPages) {
+		t.Errorf("expected ErrPaginationMaxPages, got %v", err)
+	}
+
+	// Reaching maxItems on the last allowed page isn't truncation
+	if _, err = pExec.ExecuteAllPages(context.Background(), Parameters{}, Configs{}, 12); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestExecuteAllPagesNotPaginated(t *testing.T) {
+	calls := 0
+	exec := newPagedTestExecutor(3, func(parameters Parameters, all []any) Value {
+		calls++
+		return all
+	})
+
+	result, err := ExecuteAllPages(context.Background(), exec, Parameters{}, Configs{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected a single call, got %d", calls)
+	}
+	if v := result.(ResultWithValue).Value(); !reflect.DeepEqual(v, expectedPagedItems(3)) {
+		t.Errorf("unexpected value %v", v)
+	}
+}
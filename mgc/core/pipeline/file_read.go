@@ -1,8 +1,10 @@
 package pipeline
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 )
@@ -18,6 +20,10 @@ type ReadableChunk struct {
 	Reader      ChunkReader
 	StartOffset int64
 	TotalSize   int64
+	// Hash sum of the chunk contents, only set by ReadChunksWithHash()
+	Checksum []byte
+	// Error reading the chunk to compute the Checksum, only set by ReadChunksWithHash()
+	Err error
 }
 
 // Reads a file into Chunks (Sections), each being its own ChunkReader (io.SectionReader)
@@ -43,6 +49,21 @@ func ReadChunks(
 	r io.ReaderAt,
 	size int64,
 	chunkSize int64,
+) (outputChan <-chan ReadableChunk) {
+	return ReadChunksWithHash(ctx, r, size, chunkSize, nil)
+}
+
+// Same as ReadChunks, but each chunk is read once in the generator to compute its Checksum
+// with a new hash created by newHash(). The chunk is kept in memory while hashed, so its
+// Reader doesn't read r again. If newHash is nil, no checksum is computed.
+//
+// If reading a chunk fails, it's sent with Err set and the generation stops.
+func ReadChunksWithHash(
+	ctx context.Context,
+	r io.ReaderAt,
+	size int64,
+	chunkSize int64,
+	newHash func() hash.Hash,
 ) (outputChan <-chan ReadableChunk) {
 	ch := make(chan ReadableChunk)
 	outputChan = ch
@@ -63,14 +84,24 @@ func ReadChunks(
 
 		var i int64
 		for i = 0; i < size; i += chunkSize {
+			chunk := ReadableChunk{Reader: io.NewSectionReader(r, i, chunkSize), StartOffset: i, TotalSize: size}
+			if newHash != nil {
+				chunk.Reader, chunk.Checksum, chunk.Err = hashChunk(newHash(), io.NewSectionReader(r, i, chunkSize))
+			}
+
 			select {
 			case <-ctx.Done():
 				logger.Debugw("context.Done()", "err", ctx.Err())
 				return
 
-			case ch <- ReadableChunk{io.NewSectionReader(r, i, chunkSize), i, size}:
+			case ch <- chunk:
 				logger.Debugw("read section", "offset", i)
 			}
+
+			if chunk.Err != nil {
+				logger.Debugw("failed to read section", "offset", i, "err", chunk.Err)
+				return
+			}
 		}
 		logger.Debug("finished reading sections")
 	}
@@ -79,3 +110,11 @@ func ReadChunks(
 	go generator()
 	return
 }
+
+func hashChunk(h hash.Hash, r io.Reader) (ChunkReader, []byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.TeeReader(r, h)); err != nil {
+		return nil, nil, err
+	}
+	return bytes.NewReader(buf.Bytes()), h.Sum(nil), nil
+}
@@ -0,0 +1,91 @@
+package pipeline_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
+)
+
+func TestReadChunksWithHash(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("0123456789abcdefghij")
+
+	chunks, err := pipeline.SliceItemConsumer[[]pipeline.ReadableChunk](ctx, pipeline.ReadChunksWithHash(ctx, bytes.NewReader(content), int64(len(content)), 8, sha256.New))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"01234567", "89abcdef", "ghij"}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+
+	for i, chunk := range chunks {
+		if chunk.Err != nil {
+			t.Errorf("chunk %d: unexpected error: %s", i, chunk.Err)
+		}
+		sum := sha256.Sum256([]byte(expected[i]))
+		if !reflect.DeepEqual(chunk.Checksum, sum[:]) {
+			t.Errorf("chunk %d: expected checksum %x, got %x", i, sum, chunk.Checksum)
+		}
+		// the checksum must not consume the chunk reader
+		data, err := io.ReadAll(chunk.Reader)
+		if err != nil || string(data) != expected[i] {
+			t.Errorf("chunk %d: expected content %q, got %q (%v)", i, expected[i], data, err)
+		}
+	}
+}
+
+type countingReaderAt struct {
+	io.ReaderAt
+	read int
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.ReaderAt.ReadAt(p, off)
+	r.read += n
+	return n, err
+}
+
+func TestReadChunksWithHashReadsOnce(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("0123456789abcdefghij")
+	r := &countingReaderAt{ReaderAt: bytes.NewReader(content)}
+
+	chunks, err := pipeline.SliceItemConsumer[[]pipeline.ReadableChunk](ctx, pipeline.ReadChunksWithHash(ctx, r, int64(len(content)), 8, sha256.New))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, chunk := range chunks {
+		if _, err := io.ReadAll(chunk.Reader); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if r.read != len(content) {
+		t.Errorf("expected the content to be read once, read %d of %d bytes", r.read, len(content))
+	}
+}
+
+func TestReadChunksWithoutHash(t *testing.T) {
+	ctx := context.Background()
+	content := strings.NewReader("0123456789")
+
+	chunks, err := pipeline.SliceItemConsumer[[]pipeline.ReadableChunk](ctx, pipeline.ReadChunks(ctx, content, 10, 4))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if chunk.Checksum != nil || chunk.Err != nil {
+			t.Errorf("chunk %d: expected no checksum, got %x (%v)", i, chunk.Checksum, chunk.Err)
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"path/filepath"
 	"regexp"
+	"time"
 )
 
 type FilterStatus int
@@ -364,6 +366,77 @@ func (r FilterWalkDirEntryIncludeGlobMatch) Filter(ctx context.Context, entry Wa
 
 var _ FilterRule[WalkDirEntry] = (*FilterWalkDirEntryIncludeGlobMatch)(nil)
 
+// Include files whose size is within [Min, Max]
+//
+// Directories and non-matching files are NOT excluded, they are just left as unknown.
+// To keep only the matching files, use &FilterRuleIncludeOnly[WalkDirEntry]{FilterWalkDirEntryIncludeSizeRange{...}}
+type FilterWalkDirEntryIncludeSizeRange struct {
+	Min           int64
+	Max           int64
+	CancelOnError func(error)
+}
+
+func (r FilterWalkDirEntryIncludeSizeRange) Filter(ctx context.Context, entry WalkDirEntry) FilterStatus {
+	info, ok := walkDirEntryFileInfo(entry, r.CancelOnError)
+	if !ok {
+		return FilterExclude
+	}
+	if info == nil {
+		return FilterUnknown
+	}
+	if size := info.Size(); size >= r.Min && size <= r.Max {
+		return FilterInclude
+	}
+	return FilterUnknown
+}
+
+var _ FilterRule[WalkDirEntry] = (*FilterWalkDirEntryIncludeSizeRange)(nil)
+
+// Include files modified within [After, Before]. Zero times mean no limit on that end
+//
+// Directories and non-matching files are NOT excluded, they are just left as unknown.
+// To keep only the matching files, use &FilterRuleIncludeOnly[WalkDirEntry]{FilterWalkDirEntryIncludeModTimeRange{...}}
+type FilterWalkDirEntryIncludeModTimeRange struct {
+	After         time.Time
+	Before        time.Time
+	CancelOnError func(error)
+}
+
+func (r FilterWalkDirEntryIncludeModTimeRange) Filter(ctx context.Context, entry WalkDirEntry) FilterStatus {
+	info, ok := walkDirEntryFileInfo(entry, r.CancelOnError)
+	if !ok {
+		return FilterExclude
+	}
+	if info == nil {
+		return FilterUnknown
+	}
+	modTime := info.ModTime()
+	if (r.After.IsZero() || !modTime.Before(r.After)) && (r.Before.IsZero() || !modTime.After(r.Before)) {
+		return FilterInclude
+	}
+	return FilterUnknown
+}
+
+var _ FilterRule[WalkDirEntry] = (*FilterWalkDirEntryIncludeModTimeRange)(nil)
+
+// Returns the file info of non-directory entries, nil for directories.
+// If the entry or its info failed, cancelOnError is called and ok is false
+func walkDirEntryFileInfo(entry WalkDirEntry, cancelOnError func(error)) (info fs.FileInfo, ok bool) {
+	err := entry.Err()
+	if err == nil {
+		if entry.DirEntry().IsDir() {
+			return nil, true
+		}
+		if info, err = entry.DirEntry().Info(); err == nil {
+			return info, true
+		}
+	}
+	if cancelOnError != nil {
+		cancelOnError(err)
+	}
+	return nil, false
+}
+
 // Only pass forward the non-nil elements
 type FilterNonNil[T any] struct{}
 
@@ -2,7 +2,11 @@ package pipeline_test
 
 import (
 	"context"
+	"io/fs"
+	"math"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
 	"golang.org/x/exp/constraints"
@@ -77,3 +81,49 @@ func TestAllFilter(t *testing.T) {
 		}
 	}
 }
+
+func TestSizeAndModTimeRangeFilters(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"small.txt": {Data: []byte("1"), ModTime: now.Add(-48 * time.Hour)},
+		"large.txt": {Data: []byte("1234567890"), ModTime: now.Add(-48 * time.Hour)},
+		"new.txt":   {Data: []byte("12345"), ModTime: now},
+		"dir":       {Mode: fs.ModeDir},
+	}
+	entries := map[string]pipeline.WalkDirEntry{}
+	for name := range fsys {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[name] = pipeline.NewSimpleWalkDirEntry(name, fs.FileInfoToDirEntry(info), nil)
+	}
+
+	rule := pipeline.FilterRuleAnd[pipeline.WalkDirEntry]{And: []pipeline.FilterRule[pipeline.WalkDirEntry]{
+		pipeline.FilterRuleIncludeOnly[pipeline.WalkDirEntry]{Pattern: pipeline.FilterWalkDirEntryIncludeSizeRange{Min: 2, Max: math.MaxInt64}},
+		pipeline.FilterRuleIncludeOnly[pipeline.WalkDirEntry]{Pattern: pipeline.FilterWalkDirEntryIncludeModTimeRange{Before: now.Add(-24 * time.Hour)}},
+	}}
+	expected := map[string]pipeline.FilterStatus{
+		"small.txt": pipeline.FilterExclude,
+		"large.txt": pipeline.FilterInclude,
+		"new.txt":   pipeline.FilterExclude,
+		"dir":       pipeline.FilterExclude,
+	}
+	for name, status := range expected {
+		if got := rule.Filter(ctx, entries[name]); got != status {
+			t.Errorf("%s: expected %v, got %v", name, status, got)
+		}
+	}
+
+	maxRule := pipeline.FilterWalkDirEntryIncludeSizeRange{Max: 5}
+	if got := maxRule.Filter(ctx, entries["large.txt"]); got != pipeline.FilterUnknown {
+		t.Errorf("large.txt must be left as unknown, got %v", got)
+	}
+	if got := maxRule.Filter(ctx, entries["new.txt"]); got != pipeline.FilterInclude {
+		t.Errorf("new.txt must be included, got %v", got)
+	}
+	if got := maxRule.Filter(ctx, entries["dir"]); got != pipeline.FilterUnknown {
+		t.Errorf("directories must be left as unknown, got %v", got)
+	}
+}
@@ -29,7 +29,7 @@ func NewContext(parentCtx context.Context, profile *ProfileManager) context.Cont
 }
 
 func FromContext(ctx context.Context) *ProfileManager {
-	a := ctx.Value(profileKey).(*ProfileManager)
+	a, _ := ctx.Value(profileKey).(*ProfileManager)
 	return a
 }
 
@@ -0,0 +1,98 @@
+package progress_report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Smallest amount of bytes that may be transferred at once, even with very low limits
+const minBandwidthBurst = 32 * 1024
+
+// Token bucket limiting the amount of bytes per second that go through the readers and
+// writers that share it. A nil limiter doesn't limit anything
+type BandwidthLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// Returns nil (unlimited) if bytesPerSecond is zero
+func NewBandwidthLimiter(bytesPerSecond uint64) *BandwidthLimiter {
+	if bytesPerSecond == 0 {
+		return nil
+	}
+	// Allow bursts of 100ms worth of bytes, so readers and writers don't stall on every call
+	burst := int(bytesPerSecond / 10)
+	if burst < minBandwidthBurst {
+		burst = minBandwidthBurst
+	}
+	return &BandwidthLimiter{
+		rate:   float64(bytesPerSecond),
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Parses rates such as "50MiB/s", "10 MB/s" or "1048576" into bytes per second.
+// An empty string means unlimited and returns zero
+func ParseBandwidth(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	bytesPerSecond, err := humanize.ParseBytes(strings.TrimSuffix(s, "/s"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q, expected something like 50MiB/s: %w", s, err)
+	}
+	return bytesPerSecond, nil
+}
+
+// The largest amount of bytes that should be transferred at once, up to n.
+// Nil-pointer safe
+func (l *BandwidthLimiter) chunkSize(n int) int {
+	if l == nil || n <= l.burst {
+		return n
+	}
+	return l.burst
+}
+
+// Blocks until n bytes may be transferred or the context is done. The bytes are reserved
+// before waiting, so concurrent callers are served in order.
+// Nil-pointer safe
+func (l *BandwidthLimiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, float64(l.burst))
+	l.last = now
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+package progress_report_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core/progress_report"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	cases := map[string]uint64{
+		"":         0,
+		"50MiB/s":  50 * 1024 * 1024,
+		"10 MB/s":  10 * 1000 * 1000,
+		"1048576":  1048576,
+		"512KiB":   512 * 1024,
+		" 1GiB/s ": 1024 * 1024 * 1024,
+	}
+	for input, expected := range cases {
+		actual, err := progress_report.ParseBandwidth(input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", input, err)
+		} else if actual != expected {
+			t.Errorf("%q: expected %d, got %d", input, expected, actual)
+		}
+	}
+
+	if _, err := progress_report.ParseBandwidth("fast"); err == nil {
+		t.Errorf("expected error for invalid bandwidth")
+	}
+}
+
+func TestLimitedReporterReader(t *testing.T) {
+	const rate = 256 * 1024
+	limiter := progress_report.NewBandwidthLimiter(rate)
+	content := make([]byte, rate/2)
+
+	var reported uint64
+	reader := progress_report.NewLimitedReporterReader(context.Background(), bytes.NewReader(content), func(n uint64, err error) {
+		reported += n
+	}, limiter)
+
+	start := time.Now()
+	data, err := io.ReadAll(reader)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(data) != len(content) || reported != uint64(len(content)) {
+		t.Errorf("expected %d bytes, got %d (reported %d)", len(content), len(data), reported)
+	}
+	// The initial burst is 1/10 of the rate, the remaining 0.4s worth of bytes must wait
+	if elapsed < 300*time.Millisecond {
+		t.Errorf("expected reading to be limited, took %s", elapsed)
+	}
+}
+
+func TestLimitedReporterWriterSharedLimiter(t *testing.T) {
+	const rate = 256 * 1024
+	limiter := progress_report.NewBandwidthLimiter(rate)
+
+	start := time.Now()
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			writer := progress_report.NewLimitedReporterWriter(context.Background(), io.Discard, nil, limiter)
+			_, err := writer.Write(make([]byte, rate/4))
+			done <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	// Both writers share the limit: 0.5s worth of bytes minus the initial burst
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("expected writers to share the limit, took %s", elapsed)
+	}
+}
+
+func TestBandwidthLimiterCancel(t *testing.T) {
+	limiter := progress_report.NewBandwidthLimiter(1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// exhaust the initial burst, so the next call has to wait
+	_ = limiter.WaitN(context.Background(), 32*1024)
+	if err := limiter.WaitN(ctx, 1024); err == nil {
+		t.Errorf("expected context error")
+	}
+}
+
+func TestNilBandwidthLimiter(t *testing.T) {
+	if limiter := progress_report.NewBandwidthLimiter(0); limiter != nil {
+		t.Fatalf("expected nil limiter for zero rate")
+	}
+	var limiter *progress_report.BandwidthLimiter
+	if err := limiter.WaitN(context.Background(), 1<<30); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
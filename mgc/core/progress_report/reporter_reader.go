@@ -1,6 +1,7 @@
 package progress_report
 
 import (
+	"context"
 	"io"
 )
 
@@ -9,6 +10,8 @@ type ReportRead func(n uint64, err error)
 type reporterReader struct {
 	parent         io.Reader
 	reportProgress ReportRead
+	ctx            context.Context
+	limiter        *BandwidthLimiter
 }
 
 // Wraps an io.Reader in another Reader which reports the amount of bytes read anytime
@@ -20,6 +23,17 @@ func NewReporterReader(parent io.Reader, reportProgress ReportRead) *reporterRea
 	}
 }
 
+// Same as NewReporterReader, but the bytes read are also limited by the given limiter,
+// which may be shared with other readers and writers. Both reportProgress and limiter may be nil
+func NewLimitedReporterReader(ctx context.Context, parent io.Reader, reportProgress ReportRead, limiter *BandwidthLimiter) *reporterReader {
+	return &reporterReader{
+		parent:         parent,
+		reportProgress: reportProgress,
+		ctx:            ctx,
+		limiter:        limiter,
+	}
+}
+
 func (pr *reporterReader) Unwrap() io.Reader {
 	return pr.parent
 }
@@ -27,8 +41,13 @@ func (pr *reporterReader) Unwrap() io.Reader {
 // BEGIN io.ReadCloser implementation
 
 func (pr *reporterReader) Read(p []byte) (n int, err error) {
-	n, err = pr.parent.Read(p)
-	pr.reportProgress(uint64(n), err)
+	n, err = pr.parent.Read(p[:pr.limiter.chunkSize(len(p))])
+	if waitErr := pr.limiter.WaitN(pr.ctx, n); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	if pr.reportProgress != nil {
+		pr.reportProgress(uint64(n), err)
+	}
 	return
 }
 
@@ -1,6 +1,7 @@
 package progress_report
 
 import (
+	"context"
 	"io"
 )
 
@@ -9,6 +10,8 @@ type ReportWrite func(n uint64, err error)
 type reporterWriter struct {
 	parent         io.Writer
 	reportProgress ReportWrite
+	ctx            context.Context
+	limiter        *BandwidthLimiter
 }
 
 func NewReporterWriter(parent io.Writer, reportProgress ReportWrite) *reporterWriter {
@@ -18,13 +21,39 @@ func NewReporterWriter(parent io.Writer, reportProgress ReportWrite) *reporterWr
 	}
 }
 
+// Same as NewReporterWriter, but the bytes written are also limited by the given limiter,
+// which may be shared with other readers and writers. Both reportProgress and limiter may be nil
+func NewLimitedReporterWriter(ctx context.Context, parent io.Writer, reportProgress ReportWrite, limiter *BandwidthLimiter) *reporterWriter {
+	return &reporterWriter{
+		parent:         parent,
+		reportProgress: reportProgress,
+		ctx:            ctx,
+		limiter:        limiter,
+	}
+}
+
 func (rw *reporterWriter) Unwrap() io.Writer {
 	return rw.parent
 }
 
 func (rw *reporterWriter) Write(p []byte) (n int, err error) {
-	n, err = rw.parent.Write(p)
-	rw.reportProgress(uint64(n), err)
+	for len(p) > 0 {
+		size := rw.limiter.chunkSize(len(p))
+		if err = rw.limiter.WaitN(rw.ctx, size); err != nil {
+			return
+		}
+
+		var written int
+		written, err = rw.parent.Write(p[:size])
+		n += written
+		if rw.reportProgress != nil {
+			rw.reportProgress(uint64(written), err)
+		}
+		if err != nil {
+			return
+		}
+		p = p[size:]
+	}
 	return
 }
 
@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"mime/multipart"
 
@@ -104,6 +105,34 @@ func (s *SimpleResult) Decode(data []byte) error {
 
 var _ ResultWithValue = (*SimpleResult)(nil)
 
+type SimpleResultWithReader struct {
+	SourceData  ResultSource
+	ReaderValue io.Reader
+}
+
+func NewSimpleResultWithReader(source ResultSource, reader io.Reader) *SimpleResultWithReader {
+	return &SimpleResultWithReader{source, reader}
+}
+
+func (s SimpleResultWithReader) Source() ResultSource {
+	return s.SourceData
+}
+
+func (s SimpleResultWithReader) Reader() io.Reader {
+	return s.ReaderValue
+}
+
+// Readers are consumed as streams, they can't be encoded
+func (s SimpleResultWithReader) Encode() ([]byte, error) {
+	return nil, errors.New("cannot encode result with reader")
+}
+
+func (s *SimpleResultWithReader) Decode(data []byte) error {
+	return errors.New("cannot decode result with reader")
+}
+
+var _ ResultWithReader = (*SimpleResultWithReader)(nil)
+
 type resultWithOriginalSource struct {
 	Result
 	originalSource ResultSource
@@ -79,6 +79,14 @@ func isJsonSchemaExclusiveMax(input *json.Number) bool {
 	return max < 0
 }
 
+// Schemas that are just a $ref have no type, keep it nil so the resolved ref is used
+func convertJsonSchemaType(input string) *openapi3.Types {
+	if input == "" {
+		return nil
+	}
+	return &openapi3.Types{input}
+}
+
 func convertJsonNumberToFloat64(input *json.Number) *float64 {
 	if input == nil {
 		return nil
@@ -148,7 +156,7 @@ func convertJsonSchemaToOpenAPISchema(input *jsonschema.Schema, refResolver *ref
 		AnyOf:        convertJsonSchemaToOpenAPISchemaSlice(input.AnyOf, refResolver),
 		AllOf:        convertJsonSchemaToOpenAPISchemaSlice(input.AllOf, refResolver),
 		Not:          convertJsonSchemaToOpenAPISchemaRef(input.Not, refResolver),
-		Type:         &openapi3.Types{input.Type},
+		Type:         convertJsonSchemaType(input.Type),
 		Title:        input.Title,
 		Format:       input.Format,
 		Description:  input.Description,
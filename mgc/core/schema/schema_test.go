@@ -264,3 +264,38 @@ func Test_CompareJsonSchemas(t *testing.T) {
 		})
 	}
 }
+
+func Test_SchemaFromTypeNestedStruct(t *testing.T) {
+	type inner struct {
+		Value int `json:"value"`
+	}
+	type outer struct {
+		Inner inner   `json:"inner"`
+		Items []inner `json:"items" jsonschema:"description=List of inner"`
+	}
+
+	s, err := SchemaFromType[outer]()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	innerSchema := NewObjectSchema(map[string]*Schema{"value": NewIntegerSchema()}, []string{"value"})
+	for _, name := range []string{"inner", "items"} {
+		prop, ok := s.Properties[name]
+		if !ok || prop.Value == nil {
+			t.Fatalf("missing property %q", name)
+		}
+
+		got := (*Schema)(prop.Value)
+		if name == "items" {
+			if !got.Type.Is(openapi3.TypeArray) || got.Items == nil {
+				t.Fatalf("expected array schema for %q, got %#v", name, got)
+			}
+			got = (*Schema)(got.Items.Value)
+		}
+
+		if err = CompareJsonSchemas(innerSchema, got); err != nil {
+			t.Errorf("property %q: %s", name, err)
+		}
+	}
+}
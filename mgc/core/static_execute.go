@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 
@@ -123,17 +124,23 @@ func ReflectExecutorSpecFn[ParamsT any, ConfigsT any, ResultT any](
 			return nil, err
 		}
 
-		value, err := utils.SimplifyAny(typedResult)
-		if err != nil {
-			return nil, &ChainedError{Name: "result", Err: fmt.Errorf("error simplifying %T: %w", typedResult, err)}
-		}
-
 		source := ResultSource{
 			Executor:   executor,
 			Context:    ctx,
 			Parameters: parameters,
 			Configs:    configs,
 		}
+
+		// Streams are not simplified, they are consumed by the caller, see ResultWithReader
+		if reader, ok := any(typedResult).(io.Reader); ok {
+			return NewSimpleResultWithReader(source, reader), nil
+		}
+
+		value, err := utils.SimplifyAny(typedResult)
+		if err != nil {
+			return nil, &ChainedError{Name: "result", Err: fmt.Errorf("error simplifying %T: %w", typedResult, err)}
+		}
+
 		return NewSimpleResult(source, executor.ResultSchema(), value), nil
 	}
 }
@@ -0,0 +1,34 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+)
+
+type Encoder struct {
+	impl *xml.Encoder
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{impl: xml.NewEncoder(w)}
+}
+
+// Encodes the value as a complete XML document, prefixed by the standard XML header
+func (e *Encoder) Encode(v any) error {
+	if err := e.impl.EncodeToken(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)}); err != nil {
+		return err
+	}
+	if err := e.impl.Encode(v); err != nil {
+		return err
+	}
+	return e.impl.Close()
+}
+
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
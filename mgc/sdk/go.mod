@@ -4,6 +4,7 @@ go 1.24.0
 
 require (
 	github.com/MagaluCloud/magalu/mgc/core v0.33.3
+	github.com/dustin/go-humanize v1.0.1
 	github.com/geffersonFerraz/brazilian-words-sorter v1.1.0
 	github.com/getkin/kin-openapi v0.131.0
 	github.com/go-openapi/jsonpointer v0.21.1
@@ -44,7 +45,6 @@ require (
 	github.com/Masterminds/semver/v3 v3.3.1 // indirect
 	github.com/PaesslerAG/jsonpath v0.1.1 // indirect
 	github.com/containerd/console v1.0.4 // indirect
-	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/fsnotify/fsnotify v1.8.0 // indirect
 	github.com/go-openapi/swag v0.23.1 // indirect
 	github.com/golang-jwt/jwt/v5 v5.2.2 // indirect
@@ -0,0 +1,16 @@
+package openapi
+
+import (
+	"fmt"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+)
+
+func wrapInPaginatedExecutor(exec core.Executor, pExt map[string]any) (core.PaginatedExecutor, error) {
+	cfg := core.PaginationConfig{}
+	if err := utils.DecodeValue(pExt, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid pagination: %w", err)
+	}
+	return cfg.Build(exec)
+}
@@ -128,6 +128,14 @@ func collectResourceChildren(
 			op = cpExt
 		}
 
+		if pExt, ok := getExtensionObject(extensionPrefix, "pagination", desc.op.Extensions, nil); ok && pExt != nil {
+			if pExec, err := wrapInPaginatedExecutor(op, pExt); err == nil {
+				op = pExec
+			} else {
+				return children, err
+			}
+		}
+
 		if wtExt, ok := getExtensionObject(extensionPrefix, "wait-termination", desc.op.Extensions, nil); ok && wtExt != nil {
 			if tExec, err := wrapInTerminatorExecutor(op, wtExt); err == nil {
 				op = tExec
@@ -0,0 +1,51 @@
+package acl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func TestSetAndGet(t *testing.T) {
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	const tenantId = "a4900b57-7dbb-4906-b7e8-efed938e325c"
+	_, err := setACL(ctx, setBucketACLParams{
+		Bucket: "bucket",
+		ACLPermissions: common.ACLPermissions{ACLStandardPermissions: common.ACLStandardPermissions{
+			GrantWrite: []common.ACLPermission{{ID: tenantId}},
+		}},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("setACL() failed: %s", err)
+	}
+
+	if _, err = setACL(ctx, setBucketACLParams{Bucket: "bucket"}, cfg); err == nil {
+		t.Error("expected an error without permissions")
+	}
+
+	policy, err := GetACL(ctx, GetBucketACLParams{Bucket: "bucket"}, cfg)
+	if err != nil {
+		t.Fatalf("GetACL() failed: %s", err)
+	}
+
+	// The tenant is granted both as itself and as its user project
+	grants := policy.AccessControlList.Grant
+	if len(grants) != 3 {
+		t.Fatalf("expected 3 grants, got %+v", grants)
+	}
+	if grants[0].Permission != "FULL_CONTROL" || grants[0].Grantee.ID != policy.Owner.ID {
+		t.Errorf("owner must keep full control: %+v", grants[0])
+	}
+	if grants[1].Permission != "WRITE" || grants[1].Grantee.ID != tenantId {
+		t.Errorf("unexpected grant: %+v", grants[1])
+	}
+	if expected := "cloud_br-se1_prod_" + tenantId + ":cloud_br-se1_prod_" + tenantId; grants[2].Grantee.ID != expected {
+		t.Errorf("expected grantee %q, got %q", expected, grants[2].Grantee.ID)
+	}
+}
@@ -0,0 +1,487 @@
+package buckets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/acl"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+const (
+	severityInfo     = "info"
+	severityLow      = "low"
+	severityMedium   = "medium"
+	severityHigh     = "high"
+	severityCritical = "critical"
+	// Findings never make the audit fail
+	severityNone = "none"
+
+	allUsersURI           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	authenticatedUsersURI = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// From the least to the most severe
+var severities = []string{severityInfo, severityLow, severityMedium, severityHigh, severityCritical}
+
+func severityRank(severity string) int {
+	return slices.Index(severities, severity)
+}
+
+// One level less severe, for access that is restricted somehow, such as by policy conditions
+func lowerSeverity(severity string) string {
+	return severities[max(severityRank(severity)-1, 0)]
+}
+
+type auditParams struct {
+	Buckets     []string `json:"bucket,omitempty" jsonschema_description:"Buckets to audit. If omitted, every bucket is audited"`
+	Objects     bool     `json:"objects,omitempty" jsonschema_description:"Also audit the ACL of each object, with a request per object"`
+	Prefixes    []string `json:"prefix,omitempty" jsonschema_description:"Only audit the objects under these key prefixes of each bucket. Implies objects"`
+	FailOn      string   `json:"fail_on,omitempty" jsonschema_description:"Severity from which findings count against max_findings, high if omitted. Use none to never fail" jsonschema:"enum=,enum=low,enum=medium,enum=high,enum=critical,enum=none,default="`
+	MaxFindings int      `json:"max_findings,omitempty" jsonschema:"description=Fail if more findings than this have the fail_on severity or above,default=0,minimum=0"`
+}
+
+func (p auditParams) failOn() string {
+	if p.FailOn == "" {
+		return severityHigh
+	}
+	return p.FailOn
+}
+
+type auditFinding struct {
+	Severity string `json:"severity"`
+	Bucket   string `json:"bucket"`
+	Object   string `json:"object,omitempty"`
+	// Where the access is granted: acl or policy
+	Source      string `json:"source"`
+	Grantee     string `json:"grantee"`
+	Access      string `json:"access"`
+	Description string `json:"description"`
+}
+
+type auditedBucket struct {
+	Bucket     string `json:"bucket"`
+	Owner      string `json:"owner,omitempty"`
+	ObjectLock bool   `json:"object_lock"`
+	PublicURL  string `json:"public_url,omitempty"`
+	Objects    int    `json:"objects,omitempty"`
+	Findings   int    `json:"findings"`
+	Error      string `json:"error,omitempty"`
+}
+
+type auditResult struct {
+	Findings []auditFinding  `json:"findings"`
+	Buckets  []auditedBucket `json:"buckets"`
+	// Set when the findings exceed max_findings or buckets could not be audited
+	Failure string `json:"failure,omitempty"`
+}
+
+var getAudit = utils.NewLazyLoader[core.Executor](func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:    "audit",
+			Summary: "Report buckets and objects exposed to the public or to other tenants",
+			Description: `Checks the ACL, policy and object lock state of every bucket, or of the given ones, and
+reports the grants of public read or write access and of access to other tenants, with their
+severity. Public write access is less severe on buckets with object lock, since the locked
+versions can't be overwritten or deleted.
+
+With --objects or --prefix, the ACL of each object is checked as well.
+
+The command fails, after reporting the findings, if more than --max-findings of them have the
+--fail-on severity or above, or if a bucket could not be audited.`,
+		},
+		audit,
+	)
+	exec = core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "table"
+	})
+	return core.NewExecuteResultWrapper(exec, func(exec core.ExecutorWrapper, result core.Result) (core.Result, error) {
+		withValue, ok := core.ResultAs[core.ResultWithValue](result)
+		if !ok {
+			return nil, fmt.Errorf("result is not core.ResultWithValue: %T %+v", result, result)
+		}
+		audited, err := utils.DecodeNewValue[auditResult](withValue.Value())
+		if err != nil {
+			return nil, err
+		}
+		if audited.Failure != "" {
+			// The findings are still output, see core.FailedTerminationError
+			return result, core.FailedTerminationError{Result: result, Message: audited.Failure}
+		}
+		return result, nil
+	})
+})
+
+func audit(ctx context.Context, params auditParams, cfg common.Config) (result auditResult, err error) {
+	if params.failOn() != severityNone && severityRank(params.failOn()) < 0 {
+		return result, core.UsageError{Err: fmt.Errorf("invalid fail_on %q", params.FailOn)}
+	}
+	if params.MaxFindings < 0 {
+		return result, core.UsageError{Err: fmt.Errorf("max_findings cannot be negative")}
+	}
+
+	names := params.Buckets
+	if len(names) == 0 {
+		listed, err := list(ctx, struct{}{}, cfg)
+		if err != nil {
+			return result, err
+		}
+		for _, bucket := range listed.Buckets {
+			names = append(names, bucket.Name)
+		}
+	}
+
+	result.Findings = []auditFinding{}
+	failedBuckets := 0
+	for _, name := range names {
+		bucket, findings, err := auditBucket(ctx, cfg, common.BucketName(name), params)
+		if err != nil {
+			bucket.Error = err.Error()
+			failedBuckets++
+		}
+		for i := range findings {
+			findings[i].Bucket = name
+		}
+		bucket.Findings = len(findings)
+		result.Buckets = append(result.Buckets, bucket)
+		result.Findings = append(result.Findings, findings...)
+	}
+
+	slices.SortStableFunc(result.Findings, func(a, b auditFinding) int {
+		return severityRank(b.Severity) - severityRank(a.Severity)
+	})
+
+	if params.failOn() == severityNone {
+		return result, nil
+	}
+	counted := 0
+	for _, finding := range result.Findings {
+		if severityRank(finding.Severity) >= severityRank(params.failOn()) {
+			counted++
+		}
+	}
+	switch {
+	case counted > params.MaxFindings:
+		result.Failure = fmt.Sprintf("%d findings of severity %s or above, more than the %d allowed by max_findings", counted, params.failOn(), params.MaxFindings)
+	case failedBuckets > 0:
+		result.Failure = fmt.Sprintf("%d buckets could not be audited", failedBuckets)
+	}
+	return result, nil
+}
+
+// The bucket is returned, with the error set, even if it could not be audited
+func auditBucket(ctx context.Context, cfg common.Config, name common.BucketName, params auditParams) (bucket auditedBucket, findings []auditFinding, err error) {
+	bucket.Bucket = name.String()
+
+	policy, err := acl.GetACL(ctx, acl.GetBucketACLParams{Bucket: name}, cfg)
+	if err != nil {
+		return bucket, nil, fmt.Errorf("error reading ACL: %w", err)
+	}
+	bucket.Owner = policy.Owner.ID
+
+	bucket.ObjectLock, err = isObjectLockEnabled(ctx, cfg, name)
+	if err != nil {
+		return bucket, nil, fmt.Errorf("error reading object lock: %w", err)
+	}
+
+	publicUrl, err := common.PublicUrl(ctx, cfg, name.AsURI())
+	if err != nil {
+		return bucket, nil, err
+	}
+
+	findings = auditACL(policy, bucket.ObjectLock, false)
+
+	document, err := common.GetBucketPolicy(ctx, cfg, name)
+	if isNotFound(err, "NoSuchBucketPolicy") {
+		document, err = nil, nil
+	}
+	if err != nil {
+		return bucket, nil, fmt.Errorf("error reading policy: %w", err)
+	}
+	if document != nil {
+		findings = append(findings, auditPolicy(*document, policy.Owner.ID, bucket.ObjectLock)...)
+	}
+
+	if params.Objects || len(params.Prefixes) > 0 {
+		objectFindings, objects, err := auditObjects(ctx, cfg, name, params.Prefixes, bucket.ObjectLock)
+		bucket.Objects = objects
+		findings = append(findings, objectFindings...)
+		if err != nil {
+			return bucket, findings, err
+		}
+	}
+
+	for _, finding := range findings {
+		if severityRank(finding.Severity) >= severityRank(severityHigh) {
+			// Shown so the exposure can be confirmed from outside
+			bucket.PublicURL = publicUrl.URL.String()
+			break
+		}
+	}
+	return bucket, findings, nil
+}
+
+func describeGrantee(grantee common.Grantee) string {
+	switch grantee.URI {
+	case allUsersURI:
+		return "everyone"
+	case authenticatedUsersURI:
+		return "authenticated users"
+	case "":
+		return grantee.ID
+	default:
+		return grantee.URI
+	}
+}
+
+// Severity of write access, which can't destroy the data of buckets with object lock
+func writeSeverity(objectLock bool) string {
+	if objectLock {
+		return severityHigh
+	}
+	return severityCritical
+}
+
+// Findings of the grants given to anyone other than the owner. isObject tells whether the
+// ACL is of an object, instead of a bucket
+func auditACL(policy common.AccessControlPolicy, objectLock bool, isObject bool) (findings []auditFinding) {
+	for _, grant := range policy.AccessControlList.Grant {
+		var severity, description string
+		grantee := describeGrantee(grant.Grantee)
+
+		switch grant.Grantee.URI {
+		case allUsersURI, authenticatedUsersURI:
+			switch grant.Permission {
+			case "READ":
+				severity = severityHigh
+				description = grantee + " can list the objects"
+				if isObject {
+					description = grantee + " can read the object"
+				}
+			case "READ_ACP":
+				severity = severityMedium
+				description = grantee + " can read the ACL"
+			case "WRITE":
+				severity = writeSeverity(objectLock)
+				description = grantee + " can write and delete objects"
+			default:
+				severity = severityCritical
+				description = grantee + " can change the ACL"
+			}
+			if grant.Grantee.URI == authenticatedUsersURI {
+				// Users of any tenant, but not anonymous requests
+				severity = lowerSeverity(severity)
+			}
+		default:
+			if grant.Grantee.ID == "" || grant.Grantee.ID == policy.Owner.ID {
+				continue
+			}
+			severity = severityLow
+			description = "another tenant has " + grant.Permission + " access"
+			if grant.Permission != "READ" && grant.Permission != "READ_ACP" {
+				severity = severityMedium
+			}
+		}
+
+		findings = append(findings, auditFinding{
+			Severity:    severity,
+			Source:      "acl",
+			Grantee:     grantee,
+			Access:      grant.Permission,
+			Description: description,
+		})
+	}
+	return findings
+}
+
+var (
+	policyReadActions  = []string{"s3:GetObject", "s3:ListBucket"}
+	policyWriteActions = []string{"s3:PutObject", "s3:DeleteObject"}
+	// Changing these gives away control of the bucket
+	policyAdminActions = []string{"s3:PutBucketPolicy", "s3:PutBucketAcl", "s3:PutObjectAcl", "s3:DeleteBucket"}
+)
+
+func matchedActions(statement common.PolicyStatement, actions []string) (matched []string) {
+	for _, action := range actions {
+		if statement.MatchesAction(action) {
+			matched = append(matched, action)
+		}
+	}
+	return matched
+}
+
+// Findings of the statements allowing access to anyone other than the owner. Deny statements
+// are not taken into account, so the access may be narrower than reported
+func auditPolicy(document common.PolicyDocument, ownerID string, objectLock bool) (findings []auditFinding) {
+	for _, statement := range document.Statement {
+		if statement.Effect != common.PolicyEffectAllow || statement.Principal == nil {
+			continue
+		}
+
+		public := statement.Principal.IsPublic()
+		var grantees []string
+		if public {
+			grantees = []string{"everyone"}
+		} else {
+			for _, ids := range statement.Principal.IDs {
+				for _, id := range ids {
+					if id != ownerID && !slices.Contains(grantees, id) {
+						grantees = append(grantees, id)
+					}
+				}
+			}
+		}
+
+		for _, grantee := range grantees {
+			for _, access := range []struct {
+				actions  []string
+				severity string
+				what     string
+			}{
+				{policyReadActions, severityHigh, "read"},
+				{policyWriteActions, writeSeverity(objectLock), "write"},
+				{policyAdminActions, severityCritical, "administer"},
+			} {
+				actions := matchedActions(statement, access.actions)
+				if len(actions) == 0 {
+					continue
+				}
+
+				severity := access.severity
+				description := grantee + " can " + access.what + " objects"
+				if access.what == "administer" {
+					description = grantee + " can change the bucket policy or ACLs"
+				}
+				if !public {
+					severity = severityLow
+					if access.what != "read" {
+						severity = severityMedium
+					}
+					description = "another tenant can " + access.what
+				}
+				if len(statement.Condition) > 0 {
+					severity = lowerSeverity(severity)
+					description += ", restricted by conditions"
+				}
+				if statement.Sid != "" {
+					description += fmt.Sprintf(" (statement %s)", statement.Sid)
+				}
+
+				findings = append(findings, auditFinding{
+					Severity:    severity,
+					Source:      "policy",
+					Grantee:     grantee,
+					Access:      strings.Join(actions, ","),
+					Description: description,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// True if the error is a 404 with one of the given S3 codes, meaning the configuration is not
+// set. Other errors, such as a missing bucket or permission, must not be taken as not configured
+func isNotFound(err error, codes ...string) bool {
+	var httpErr *mgcHttpPkg.HttpError
+	return errors.As(err, &httpErr) && httpErr.Code == http.StatusNotFound && slices.Contains(codes, httpErr.Slug)
+}
+
+func newBucketSubresourceRequest(ctx context.Context, cfg common.Config, name common.BucketName, subresource string) (*http.Request, error) {
+	url, err := common.BuildBucketHostURL(cfg, name)
+	if err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+
+	query := url.Query()
+	query.Add(subresource, "")
+	url.RawQuery = query.Encode()
+
+	return http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+}
+
+func isObjectLockEnabled(ctx context.Context, cfg common.Config, name common.BucketName) (bool, error) {
+	req, err := newBucketSubresourceRequest(ctx, cfg, name, "object-lock")
+	if err != nil {
+		return false, err
+	}
+	res, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return false, err
+	}
+	config, err := common.UnwrapResponse[ObjectLockConfiguration](res, req)
+	if isNotFound(err, "ObjectLockConfigurationNotFoundError") {
+		return false, nil
+	}
+	return config.ObjectLockStatus == "Enabled", err
+}
+
+func getObjectACL(ctx context.Context, cfg common.Config, objURI mgcSchemaPkg.URI) (result common.AccessControlPolicy, err error) {
+	url, err := common.BuildBucketHostWithPathURL(cfg, common.NewBucketNameFromURI(objURI), objURI.Path())
+	if err != nil {
+		return result, core.UsageError{Err: err}
+	}
+	query := url.Query()
+	query.Add("acl", "")
+	url.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+	if err != nil {
+		return result, err
+	}
+	res, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return result, err
+	}
+	return common.UnwrapResponse[common.AccessControlPolicy](res, req)
+}
+
+// Findings of the objects under the prefixes, or all of them. Returns the number of objects audited
+func auditObjects(ctx context.Context, cfg common.Config, name common.BucketName, prefixes []string, objectLock bool) (findings []auditFinding, objects int, err error) {
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+	// Stops the listing when returning early, so its goroutine isn't left blocked sending
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, prefix := range prefixes {
+		listParams := common.ListObjectsParams{
+			Destination: name.AsURI().JoinPath(prefix),
+			Recursive:   true,
+			PaginationParams: common.PaginationParams{
+				MaxItems: math.MaxInt64,
+			},
+		}
+		for entry := range common.ListGenerator(ctx, listParams, cfg, func(uint64) {}) {
+			if err = entry.Err(); err != nil {
+				return findings, objects, fmt.Errorf("error listing objects: %w", err)
+			}
+			if _, ok := entry.DirEntry().(*common.BucketContent); !ok {
+				continue
+			}
+
+			objURI := name.AsURI().JoinPath(entry.Path())
+			policy, err := getObjectACL(ctx, cfg, objURI)
+			if err != nil {
+				return findings, objects, &common.ObjectError{Url: objURI, Err: err}
+			}
+			objects++
+			for _, finding := range auditACL(policy, objectLock, true) {
+				finding.Object = entry.Path()
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings, objects, nil
+}
@@ -0,0 +1,150 @@
+package buckets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func findingsOf(result auditResult, bucket string) (findings []auditFinding) {
+	for _, finding := range result.Findings {
+		if finding.Bucket == bucket {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+func TestAudit(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	server.CreateBucket("private")
+	server.PutObject("private", "docs/shared.txt", []byte("shared"))
+	server.PutObject("private", "other/file.txt", []byte("other"))
+	server.SetObjectACL("private", "docs/shared.txt", "public-read")
+	server.SetObjectACL("private", "other/file.txt", "public-read")
+	_, err := create(ctx, createParams{
+		BucketName:     "site",
+		ACLPermissions: common.ACLPermissions{ACLCannedPermissions: common.ACLCannedPermissions{PublicRead: true}},
+	}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.CreateBucket("uploads")
+	server.EnableObjectLock("uploads")
+	server.SetBucketPolicy("uploads", []byte(`{
+		"Version": "2012-10-17",
+		"Statement": [
+			{"Sid": "PublicUpload", "Effect": "Allow", "Principal": "*", "Action": "s3:Put*", "Resource": "uploads/*"},
+			{"Effect": "Allow", "Principal": {"MGC": ["other-tenant"]}, "Action": ["s3:GetObject"], "Resource": "uploads/*",
+			 "Condition": {"IpAddress": {"aws:SourceIp": "10.0.0.0/8"}}},
+			{"Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": "uploads/*"}
+		]
+	}`))
+
+	result, err := audit(ctx, auditParams{Prefixes: []string{"docs/"}, FailOn: severityNone}, cfg)
+	if err != nil {
+		t.Fatalf("audit() failed: %s", err)
+	}
+	if len(result.Buckets) != 3 || result.Failure != "" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	private := findingsOf(result, "private")
+	if len(private) != 1 || private[0].Object != "docs/shared.txt" || private[0].Severity != severityHigh {
+		t.Errorf("only the public object under the prefix must be reported: %+v", private)
+	}
+
+	site := findingsOf(result, "site")
+	if len(site) != 1 || site[0].Severity != severityHigh || site[0].Grantee != "everyone" || site[0].Access != "READ" {
+		t.Errorf("public read ACL was not reported: %+v", site)
+	}
+	for _, bucket := range result.Buckets {
+		if bucket.Bucket == "site" && bucket.PublicURL == "" {
+			t.Error("public URL of the exposed bucket must be shown")
+		}
+	}
+
+	uploads := findingsOf(result, "uploads")
+	if len(uploads) != 3 {
+		t.Fatalf("expected the public and cross-tenant statements, got %+v", uploads)
+	}
+	// s3:Put* also allows changing the policy and ACLs
+	if uploads[0].Severity != severityCritical || uploads[0].Access != "s3:PutBucketPolicy,s3:PutBucketAcl,s3:PutObjectAcl" {
+		t.Errorf("unexpected public administration finding: %+v", uploads[0])
+	}
+	// Object lock makes public writes less severe
+	if uploads[1].Severity != severityHigh || uploads[1].Source != "policy" || uploads[1].Access != "s3:PutObject" {
+		t.Errorf("unexpected public write finding: %+v", uploads[1])
+	}
+	if uploads[2].Severity != severityInfo || uploads[2].Grantee != "other-tenant" {
+		t.Errorf("conditions must lower the cross-tenant finding: %+v", uploads[2])
+	}
+
+	if result, err = audit(ctx, auditParams{Buckets: []string{"private"}}, cfg); err != nil || len(result.Findings) != 0 || result.Failure != "" {
+		t.Errorf("objects must only be audited when asked: %+v %v", result, err)
+	}
+}
+
+func TestAuditThreshold(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	_, err := create(ctx, createParams{
+		BucketName:     "site",
+		ACLPermissions: common.ACLPermissions{ACLCannedPermissions: common.ACLCannedPermissions{PublicRead: true}},
+	}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := utils.SimplifyAny(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execute := func(params core.Parameters) (core.Result, error) {
+		return getAudit().Execute(ctx, params, configs.(map[string]any))
+	}
+
+	result, err := execute(core.Parameters{})
+	var failed core.FailedTerminationError
+	if !errors.As(err, &failed) || failed.Result == nil {
+		t.Fatalf("expected failure with the findings, got %v", err)
+	}
+	if _, err = execute(core.Parameters{"max_findings": 1}); err != nil {
+		t.Errorf("findings within max_findings must not fail: %v", err)
+	}
+	if result, err = execute(core.Parameters{"fail_on": severityCritical}); err != nil || result == nil {
+		t.Errorf("findings below fail_on must not fail: %v", err)
+	}
+	if _, err = execute(core.Parameters{"bucket": []any{"missing"}, "fail_on": severityCritical}); !errors.As(err, &failed) {
+		t.Errorf("buckets that can't be audited must fail: %v", err)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "not configured", err: &mgcHttpPkg.HttpError{Code: http.StatusNotFound, Slug: "NoSuchBucketPolicy"}, expected: true},
+		{name: "missing bucket", err: &mgcHttpPkg.HttpError{Code: http.StatusNotFound, Slug: "NoSuchBucket"}},
+		{name: "bad request", err: &mgcHttpPkg.HttpError{Code: http.StatusBadRequest, Slug: "NoSuchBucketPolicy"}},
+		{name: "denied", err: &mgcHttpPkg.HttpError{Code: http.StatusForbidden, Slug: "AccessDenied"}},
+		{name: "other", err: errors.New("connection refused")},
+	} {
+		if got := isNotFound(tc.err, "NoSuchBucketPolicy"); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package buckets
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/versioning"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func TestCreateAndList(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	_, err := create(ctx, createParams{
+		BucketName:       "versioned",
+		EnableVersioning: true,
+		ACLPermissions:   common.ACLPermissions{ACLCannedPermissions: common.ACLCannedPermissions{PublicRead: true}},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("create() failed: %s", err)
+	}
+	if _, err = create(ctx, createParams{BucketName: "unversioned"}, cfg); err != nil {
+		t.Fatalf("create() failed: %s", err)
+	}
+	if _, err = create(ctx, createParams{BucketName: "versioned", EnableVersioning: true}, cfg); err == nil {
+		t.Error("expected an error when creating an existing bucket")
+	}
+
+	result, err := list(ctx, struct{}{}, cfg)
+	if err != nil {
+		t.Fatalf("list() failed: %s", err)
+	}
+	if len(result.Buckets) != 2 || result.Buckets[0].Name != "unversioned" || result.Buckets[1].Name != "versioned" {
+		t.Errorf("unexpected buckets: %+v", result.Buckets)
+	}
+
+	status, err := versioning.GetBucketVersioning(ctx, versioning.GetBucketVersioningParams{Bucket: "unversioned"}, cfg)
+	if err != nil {
+		t.Fatalf("GetBucketVersioning() failed: %s", err)
+	}
+	if status.Status != "Suspended" {
+		t.Errorf("expected versioning to be suspended, got %q", status.Status)
+	}
+	if server.Versioning("versioned") != "Enabled" {
+		t.Errorf("expected versioning to be enabled, got %q", server.Versioning("versioned"))
+	}
+
+	grants := server.BucketGrants("versioned")
+	if len(grants) != 2 || grants[1] != "READ:http://acs.amazonaws.com/groups/global/AllUsers" {
+		t.Errorf("canned ACL was not applied: %v", grants)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.PutObject("bucket", "file.txt", []byte("content"))
+
+	if _, err := deleteBucket(ctx, deleteParams{BucketName: "bucket"}, cfg); err == nil {
+		t.Fatal("expected an error when deleting a bucket that is not empty")
+	}
+	if !server.HasBucket("bucket") {
+		t.Fatal("bucket must not be deleted")
+	}
+
+	if _, err := deleteBucket(ctx, deleteParams{BucketName: "bucket", Recursive: true}, cfg); err != nil {
+		t.Fatalf("deleteBucket() failed: %s", err)
+	}
+	if server.HasBucket("bucket") {
+		t.Error("bucket was not deleted")
+	}
+}
+
+func TestPublicUrlWebsite(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("docs")
+	server.SetBucketWebsite("docs", []byte("<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument></WebsiteConfiguration>"))
+
+	result, err := bucketPublicUrl(ctx, publicUrlParams{Destination: "docs"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.URL == "" || result.WebsiteURL != "" || !result.WebsiteEnabled {
+		t.Errorf("website hosting must be reported when its endpoint can't be derived from serverUrl: %+v", result)
+	}
+
+	cfg.WebsiteEndpoint = "https://{bucket}.sites.example.com"
+	server.CreateBucket("plain")
+	result, err = bucketPublicUrl(ctx, publicUrlParams{Destination: "plain"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.URL == "" || result.WebsiteURL != "" || result.WebsiteEnabled {
+		t.Errorf("website endpoint must only be shown when hosting is enabled: %+v", result)
+	}
+
+	if result, err = bucketPublicUrl(ctx, publicUrlParams{Destination: "docs/guide"}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if expected := mgcSchemaPkg.URI("https://docs.sites.example.com/guide"); result.WebsiteURL != expected {
+		t.Errorf("expected website endpoint %s, got %s", expected, result.WebsiteURL)
+	}
+
+	server.FailRequests(http.MethodGet, "docs", "", -1)
+	if _, err = bucketPublicUrl(ctx, publicUrlParams{Destination: "docs"}, cfg); err == nil {
+		t.Error("errors other than not found must not be taken as website hosting disabled")
+	}
+}
+
+func TestWebsiteUrlDefault(t *testing.T) {
+	websiteUrl, err := common.WebsiteUrl(common.Config{Region: "br-ne1"}, "docs/guide")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := mgcSchemaPkg.URI("https://docs.website.br-ne1.magaluobjects.com/guide"); websiteUrl != expected {
+		t.Errorf("expected the website endpoint of the region %s, got %s", expected, websiteUrl)
+	}
+}
@@ -0,0 +1,96 @@
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+const corsMaxRules = 100
+
+var corsMethods = []string{"GET", "PUT", "POST", "DELETE", "HEAD"}
+
+type CORSRule struct {
+	ID             string   `xml:"ID,omitempty" json:"id,omitempty" jsonschema:"description=Unique identifier of the rule,example=frontend"`
+	AllowedOrigins []string `xml:"AllowedOrigin" json:"allowed_origins" jsonschema:"description=Origins allowed to make cross-origin requests. May contain one '*' wildcard,example=https://*.example.com,minItems=1"`
+	AllowedMethods []string `xml:"AllowedMethod" json:"allowed_methods" jsonschema:"description=HTTP methods allowed for the origins,minItems=1,enum=GET,enum=PUT,enum=POST,enum=DELETE,enum=HEAD"`
+	AllowedHeaders []string `xml:"AllowedHeader,omitempty" json:"allowed_headers,omitempty" jsonschema:"description=Headers allowed in preflight requests,example=*"`
+	ExposeHeaders  []string `xml:"ExposeHeader,omitempty" json:"expose_headers,omitempty" jsonschema:"description=Response headers the browser is allowed to access,example=ETag"`
+	MaxAgeSeconds  int      `xml:"MaxAgeSeconds,omitempty" json:"max_age_seconds,omitempty" jsonschema:"description=Time in seconds the browser may cache the preflight response,minimum=0,example=3600"`
+}
+
+type CORSConfiguration struct {
+	Rules []CORSRule `xml:"CORSRule" json:"rules"`
+
+	Namespace string   `xml:"xmlns,omitempty,attr" json:"-"`
+	XMLName   struct{} `xml:"CORSConfiguration" json:"-"`
+}
+
+func (r *CORSRule) validate() error {
+	if len(r.AllowedOrigins) == 0 {
+		return errors.New("must have at least one allowed origin")
+	}
+	if len(r.AllowedMethods) == 0 {
+		return errors.New("must have at least one allowed method")
+	}
+	for _, method := range r.AllowedMethods {
+		if !slices.Contains(corsMethods, method) {
+			return fmt.Errorf("invalid allowed method %q, must be one of %s", method, strings.Join(corsMethods, ", "))
+		}
+	}
+	for _, origin := range r.AllowedOrigins {
+		if wildcards := countWildcards(origin); wildcards > 1 {
+			return fmt.Errorf("allowed origin %q must have at most one '*' wildcard", origin)
+		}
+	}
+	for _, header := range r.AllowedHeaders {
+		if wildcards := countWildcards(header); wildcards > 1 {
+			return fmt.Errorf("allowed header %q must have at most one '*' wildcard", header)
+		}
+	}
+	if r.MaxAgeSeconds < 0 {
+		return fmt.Errorf("max age must not be negative, got %d", r.MaxAgeSeconds)
+	}
+	return nil
+}
+
+func countWildcards(s string) (n int) {
+	for _, c := range s {
+		if c == '*' {
+			n++
+		}
+	}
+	return
+}
+
+func (c *CORSConfiguration) validate() error {
+	if len(c.Rules) == 0 {
+		return core.UsageError{Err: errors.New("CORS configuration must have at least one rule")}
+	}
+	if len(c.Rules) > corsMaxRules {
+		return core.UsageError{Err: fmt.Errorf("CORS configuration must have at most %d rules, got %d", corsMaxRules, len(c.Rules))}
+	}
+	for i := range c.Rules {
+		if err := c.Rules[i].validate(); err != nil {
+			return core.UsageError{Err: fmt.Errorf("rule %d (%q): %w", i, c.Rules[i].ID, err)}
+		}
+	}
+	return nil
+}
+
+func newCORSRequestURL(cfg common.Config, bucketName common.BucketName) (string, error) {
+	url, err := common.BuildBucketHostURL(cfg, bucketName)
+	if err != nil {
+		return "", core.UsageError{Err: err}
+	}
+
+	query := url.Query()
+	query.Set("cors", "")
+	url.RawQuery = query.Encode()
+
+	return url.String(), nil
+}
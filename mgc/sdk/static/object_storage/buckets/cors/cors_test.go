@@ -0,0 +1,51 @@
+package cors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+)
+
+func TestCORSValidation(t *testing.T) {
+	origins := []string{"https://*.example.com"}
+	methods := []string{"GET", "PUT"}
+
+	for _, tc := range []struct {
+		name  string
+		rules []CORSRule
+		valid bool
+	}{
+		{name: "minimal", rules: []CORSRule{{AllowedOrigins: origins, AllowedMethods: methods}}, valid: true},
+		{name: "all fields", rules: []CORSRule{{
+			ID:             "frontend",
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET", "PUT", "POST", "DELETE", "HEAD"},
+			AllowedHeaders: []string{"x-amz-*"},
+			ExposeHeaders:  []string{"ETag"},
+			MaxAgeSeconds:  3600,
+		}}, valid: true},
+		{name: "no rules"},
+		{name: "too many rules", rules: make([]CORSRule, corsMaxRules+1)},
+		{name: "no origins", rules: []CORSRule{{AllowedMethods: methods}}},
+		{name: "two origin wildcards", rules: []CORSRule{{AllowedOrigins: []string{"https://*.*.example.com"}, AllowedMethods: methods}}},
+		{name: "no methods", rules: []CORSRule{{AllowedOrigins: origins}}},
+		{name: "unknown method", rules: []CORSRule{{AllowedOrigins: origins, AllowedMethods: []string{"PATCH"}}}},
+		{name: "lowercase method", rules: []CORSRule{{AllowedOrigins: origins, AllowedMethods: []string{"get"}}}},
+		{name: "two header wildcards", rules: []CORSRule{{AllowedOrigins: origins, AllowedMethods: methods, AllowedHeaders: []string{"*-*"}}}},
+		{name: "negative max age", rules: []CORSRule{{AllowedOrigins: origins, AllowedMethods: methods, MaxAgeSeconds: -1}}},
+	} {
+		config := CORSConfiguration{Rules: tc.rules}
+		err := config.validate()
+		if tc.valid {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tc.name, err)
+			}
+			continue
+		}
+		var usageErr core.UsageError
+		if !errors.As(err, &usageErr) {
+			t.Errorf("%s: expected usage error, got %v", tc.name, err)
+		}
+	}
+}
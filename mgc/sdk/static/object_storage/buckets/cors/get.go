@@ -0,0 +1,46 @@
+package cors
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type getBucketCORSParams struct {
+	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to get the CORS configuration from,example=my-bucket" mgc:"positional"`
+}
+
+var getGet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "get",
+			Description: "Get the CORS configuration of the specified bucket",
+		},
+		getCORS,
+	)
+	return core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "json"
+	})
+})
+
+func getCORS(ctx context.Context, params getBucketCORSParams, cfg common.Config) (result CORSConfiguration, err error) {
+	url, err := newCORSRequestURL(cfg, params.Bucket)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+
+	res, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	return common.UnwrapResponse[CORSConfiguration](res, req)
+}
@@ -0,0 +1,81 @@
+package cors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/core/xml"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type setBucketCORSParams struct {
+	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to set the CORS configuration for,example=my-bucket" mgc:"positional"`
+	Rules  []CORSRule        `json:"rules" jsonschema:"description=CORS rules of the bucket. Use @./cors.json or @./cors.yaml to load them from a file,minItems=1"`
+}
+
+var getSet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "set",
+			Description: "Set the CORS configuration for the specified bucket, replacing the existing one",
+		},
+		setCORS,
+	)
+
+	return core.NewExecuteFormat(exec, func(exec core.Executor, result core.Result) string {
+		return fmt.Sprintf("Successfully set CORS configuration for bucket %q", result.Source().Parameters["dst"])
+	})
+})
+
+func setCORS(ctx context.Context, params setBucketCORSParams, cfg common.Config) (result core.Value, err error) {
+	cors := CORSConfiguration{
+		Rules:     params.Rules,
+		Namespace: "http://s3.amazonaws.com/doc/2006-03-01/",
+	}
+	if err = cors.validate(); err != nil {
+		return
+	}
+
+	req, err := newSetBucketCORSRequest(ctx, params.Bucket, cors, cfg)
+	if err != nil {
+		return
+	}
+
+	resp, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	err = common.ExtractErr(resp, req)
+	return
+}
+
+func newSetBucketCORSRequest(ctx context.Context, bucketName common.BucketName, cors CORSConfiguration, cfg common.Config) (*http.Request, error) {
+	url, err := newCORSRequestURL(cfg, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := xml.Marshal(cors)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	req.ContentLength = int64(len(body))
+
+	return req, nil
+}
@@ -4,10 +4,15 @@ import (
 	"github.com/MagaluCloud/magalu/mgc/core"
 	"github.com/MagaluCloud/magalu/mgc/core/utils"
 	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/acl"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/cors"
 	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/label"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/lifecycle"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/multipart"
 	object_lock "github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/object-lock"
 	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/policy"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/replication"
 	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/versioning"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/website"
 )
 
 var GetGroup = utils.NewLazyLoader[core.Grouper](func() core.Grouper {
@@ -23,11 +28,17 @@ var GetGroup = utils.NewLazyLoader[core.Grouper](func() core.Grouper {
 				getList(),              // object-storage buckets list
 				getBucket(),            // object-storage buckets get
 				getPublicUrl(),         // object-storage objects public-url
+				getAudit(),             // object-storage buckets audit
 				acl.GetGroup(),         // object-storage buckets acl
 				versioning.GetGroup(),  // object-storage buckets versioning
 				policy.GetGroup(),      // object-storage buckets policy
+				cors.GetGroup(),        // object-storage buckets cors
 				label.GetGroup(),       // object-storage buckets label
 				object_lock.GetGroup(), // object-storage buckets object-lock
+				multipart.GetGroup(),   // object-storage buckets multipart
+				lifecycle.GetGroup(),   // object-storage buckets lifecycle
+				website.GetGroup(),     // object-storage buckets website
+				replication.GetGroup(), // object-storage buckets replication
 			}
 		},
 	)
@@ -0,0 +1,254 @@
+package lifecycle
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+const (
+	lifecycleMaxRules    = 1000
+	lifecycleMaxIDLength = 255
+
+	lifecycleStatusEnabled  = "Enabled"
+	lifecycleStatusDisabled = "Disabled"
+)
+
+type LifecycleTag struct {
+	Key   string `xml:"Key" json:"key"`
+	Value string `xml:"Value" json:"value"`
+}
+
+// Objects must match all the conditions for the rule to apply. An empty filter applies to the whole bucket
+type LifecycleFilter struct {
+	Prefix string         `json:"prefix,omitempty" jsonschema:"description=Only apply to keys starting with this prefix,example=logs/"`
+	Tags   []LifecycleTag `json:"tags,omitempty" jsonschema:"description=Only apply to objects with all these tags"`
+}
+
+type lifecycleFilterConditions struct {
+	Prefix string         `xml:"Prefix,omitempty"`
+	Tags   []LifecycleTag `xml:"Tag,omitempty"`
+}
+
+type lifecycleFilterXML struct {
+	lifecycleFilterConditions
+	And *lifecycleFilterConditions `xml:"And,omitempty"`
+}
+
+func (f LifecycleFilter) conditionsCount() int {
+	n := len(f.Tags)
+	if f.Prefix != "" {
+		n++
+	}
+	return n
+}
+
+// S3 requires multiple conditions to be wrapped in <And>
+func (f LifecycleFilter) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	conditions := lifecycleFilterConditions{Prefix: f.Prefix, Tags: f.Tags}
+	if f.conditionsCount() > 1 {
+		return e.EncodeElement(lifecycleFilterXML{And: &conditions}, start)
+	}
+	return e.EncodeElement(lifecycleFilterXML{lifecycleFilterConditions: conditions}, start)
+}
+
+func (f *LifecycleFilter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v lifecycleFilterXML
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	if v.And != nil {
+		*f = LifecycleFilter{Prefix: v.And.Prefix, Tags: v.And.Tags}
+	} else {
+		*f = LifecycleFilter{Prefix: v.Prefix, Tags: v.Tags}
+	}
+	return nil
+}
+
+type LifecycleExpiration struct {
+	Days                      int    `xml:"Days,omitempty" json:"days,omitempty" jsonschema:"description=Expire objects this number of days after their creation,minimum=1"`
+	Date                      string `xml:"Date,omitempty" json:"date,omitempty" jsonschema:"description=Expire objects at this date (midnight UTC),example=2030-01-31"`
+	ExpiredObjectDeleteMarker bool   `xml:"ExpiredObjectDeleteMarker,omitempty" json:"expired_object_delete_marker,omitempty" jsonschema:"description=Remove delete markers without noncurrent versions"`
+}
+
+type LifecycleNoncurrentVersionExpiration struct {
+	NoncurrentDays          int `xml:"NoncurrentDays" json:"noncurrent_days" jsonschema:"description=Expire versions this number of days after they become noncurrent,minimum=1"`
+	NewerNoncurrentVersions int `xml:"NewerNoncurrentVersions,omitempty" json:"newer_noncurrent_versions,omitempty" jsonschema:"description=Number of most recent noncurrent versions to retain,minimum=0"`
+}
+
+type LifecycleAbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int `xml:"DaysAfterInitiation" json:"days_after_initiation" jsonschema:"description=Abort incomplete multipart uploads this number of days after they were initiated,minimum=1"`
+}
+
+type LifecycleRule struct {
+	ID                             string                                   `xml:"ID,omitempty" json:"id,omitempty" jsonschema:"description=Unique identifier of the rule,example=expire-logs"`
+	Status                         string                                   `xml:"Status" json:"status,omitempty" jsonschema:"description=Whether the rule is applied,enum=Enabled,enum=Disabled,default=Enabled"`
+	Filter                         *LifecycleFilter                         `xml:"Filter" json:"filter,omitempty"`
+	Expiration                     *LifecycleExpiration                     `xml:"Expiration,omitempty" json:"expiration,omitempty"`
+	NoncurrentVersionExpiration    *LifecycleNoncurrentVersionExpiration    `xml:"NoncurrentVersionExpiration,omitempty" json:"noncurrent_version_expiration,omitempty"`
+	AbortIncompleteMultipartUpload *LifecycleAbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty" json:"abort_incomplete_multipart_upload,omitempty"`
+}
+
+type LifecycleConfiguration struct {
+	Rules []LifecycleRule `xml:"Rule" json:"rules" jsonschema:"description=Lifecycle rules of the bucket"`
+
+	Namespace string   `xml:"xmlns,omitempty,attr" json:"-"`
+	XMLName   struct{} `xml:"LifecycleConfiguration" json:"-"`
+}
+
+func (e *LifecycleExpiration) validate() error {
+	set := 0
+	if e.Days != 0 {
+		set++
+		if e.Days < 0 {
+			return fmt.Errorf("days must be positive, got %d", e.Days)
+		}
+	}
+	if e.Date != "" {
+		set++
+		date, err := parseLifecycleDate(e.Date)
+		if err != nil {
+			return err
+		}
+		e.Date = date.Format(time.RFC3339)
+	}
+	if e.ExpiredObjectDeleteMarker {
+		set++
+	}
+	if set != 1 {
+		return errors.New("must have exactly one of days, date or expired_object_delete_marker")
+	}
+	return nil
+}
+
+// Dates must be at midnight UTC, accept both the short and the full format
+func parseLifecycleDate(s string) (date time.Time, err error) {
+	date, err = time.Parse(time.DateOnly, s)
+	if err != nil {
+		date, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return date, fmt.Errorf("invalid date %q, expected format YYYY-MM-DD", s)
+		}
+	}
+	date = date.UTC()
+	if !date.Equal(date.Truncate(24 * time.Hour)) {
+		return date, fmt.Errorf("invalid date %q, must be at midnight UTC", s)
+	}
+	return date, nil
+}
+
+func (r *LifecycleRule) validate() error {
+	// The server requires the element, an empty filter applies to the whole bucket
+	if r.Filter == nil {
+		r.Filter = &LifecycleFilter{}
+	}
+	if len(r.ID) > lifecycleMaxIDLength {
+		return fmt.Errorf("id must have at most %d characters", lifecycleMaxIDLength)
+	}
+
+	switch r.Status {
+	case "":
+		r.Status = lifecycleStatusEnabled
+	case lifecycleStatusEnabled, lifecycleStatusDisabled:
+	default:
+		return fmt.Errorf("invalid status %q, must be %q or %q", r.Status, lifecycleStatusEnabled, lifecycleStatusDisabled)
+	}
+
+	tagKeys := make(map[string]struct{}, len(r.Filter.Tags))
+	for _, tag := range r.Filter.Tags {
+		if tag.Key == "" {
+			return errors.New("filter tags must have a key")
+		}
+		if _, ok := tagKeys[tag.Key]; ok {
+			return fmt.Errorf("duplicated filter tag %q", tag.Key)
+		}
+		tagKeys[tag.Key] = struct{}{}
+	}
+
+	if r.Expiration == nil && r.NoncurrentVersionExpiration == nil && r.AbortIncompleteMultipartUpload == nil {
+		return errors.New("must have at least one action: expiration, noncurrent_version_expiration or abort_incomplete_multipart_upload")
+	}
+
+	if r.Expiration != nil {
+		if err := r.Expiration.validate(); err != nil {
+			return fmt.Errorf("expiration: %w", err)
+		}
+		if r.Expiration.ExpiredObjectDeleteMarker && len(r.Filter.Tags) > 0 {
+			return errors.New("expiration: expired_object_delete_marker can't be used with tag filters")
+		}
+	}
+
+	if r.NoncurrentVersionExpiration != nil {
+		if r.NoncurrentVersionExpiration.NoncurrentDays <= 0 {
+			return errors.New("noncurrent_version_expiration: noncurrent_days must be positive")
+		}
+		if r.NoncurrentVersionExpiration.NewerNoncurrentVersions < 0 {
+			return errors.New("noncurrent_version_expiration: newer_noncurrent_versions can't be negative")
+		}
+	}
+
+	if r.AbortIncompleteMultipartUpload != nil {
+		if r.AbortIncompleteMultipartUpload.DaysAfterInitiation <= 0 {
+			return errors.New("abort_incomplete_multipart_upload: days_after_initiation must be positive")
+		}
+		if len(r.Filter.Tags) > 0 {
+			return errors.New("abort_incomplete_multipart_upload can't be used with tag filters")
+		}
+	}
+
+	return nil
+}
+
+// Validates the configuration locally, before sending it to the server. Fills defaults and
+// normalizes the values in place
+func (c *LifecycleConfiguration) validate() error {
+	if len(c.Rules) == 0 {
+		return core.UsageError{Err: errors.New("lifecycle configuration must have at least one rule")}
+	}
+	if len(c.Rules) > lifecycleMaxRules {
+		return core.UsageError{Err: fmt.Errorf("lifecycle configuration must have at most %d rules, got %d", lifecycleMaxRules, len(c.Rules))}
+	}
+
+	ids := make(map[string]struct{}, len(c.Rules))
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if err := rule.validate(); err != nil {
+			return core.UsageError{Err: fmt.Errorf("rule %d (%q): %w", i, rule.ID, err)}
+		}
+		if rule.ID == "" {
+			continue
+		}
+		if _, ok := ids[rule.ID]; ok {
+			return core.UsageError{Err: fmt.Errorf("rule %d: duplicated id %q", i, rule.ID)}
+		}
+		ids[rule.ID] = struct{}{}
+	}
+
+	return nil
+}
+
+// Empty filters are omitted when showing the configuration
+func (c *LifecycleConfiguration) omitEmptyFilters() {
+	for i := range c.Rules {
+		if f := c.Rules[i].Filter; f != nil && f.conditionsCount() == 0 {
+			c.Rules[i].Filter = nil
+		}
+	}
+}
+
+func newLifecycleRequestURL(cfg common.Config, bucketName common.BucketName) (string, error) {
+	url, err := common.BuildBucketHostURL(cfg, bucketName)
+	if err != nil {
+		return "", core.UsageError{Err: err}
+	}
+
+	query := url.Query()
+	query.Set("lifecycle", "")
+	url.RawQuery = query.Encode()
+
+	return url.String(), nil
+}
@@ -0,0 +1,49 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type deleteBucketLifecycleParams struct {
+	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to delete the lifecycle rules from,example=my-bucket" mgc:"positional"`
+}
+
+var getDelete = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "delete",
+			Description: "Delete all the lifecycle rules of the specified bucket",
+		},
+		deleteLifecycle,
+	)
+
+	return core.NewExecuteFormat(exec, func(exec core.Executor, result core.Result) string {
+		return fmt.Sprintf("Successfully deleted lifecycle rules for bucket %q", result.Source().Parameters["dst"])
+	})
+})
+
+func deleteLifecycle(ctx context.Context, params deleteBucketLifecycleParams, cfg common.Config) (result core.Value, err error) {
+	url, err := newLifecycleRequestURL(cfg, params.Bucket)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	err = common.ExtractErr(resp, req)
+	return
+}
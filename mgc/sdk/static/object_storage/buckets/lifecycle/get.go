@@ -0,0 +1,48 @@
+package lifecycle
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type getBucketLifecycleParams struct {
+	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to get the lifecycle rules from,example=my-bucket" mgc:"positional"`
+}
+
+var getGet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "get",
+			Description: "Get the lifecycle rules of the specified bucket",
+		},
+		getLifecycle,
+	)
+	return core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "json"
+	})
+})
+
+func getLifecycle(ctx context.Context, params getBucketLifecycleParams, cfg common.Config) (result LifecycleConfiguration, err error) {
+	url, err := newLifecycleRequestURL(cfg, params.Bucket)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+
+	res, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	result, err = common.UnwrapResponse[LifecycleConfiguration](res, req)
+	result.omitEmptyFilters()
+	return
+}
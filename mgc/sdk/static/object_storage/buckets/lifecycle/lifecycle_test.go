@@ -0,0 +1,110 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func TestParseLifecycleDate(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected string
+		fails    bool
+	}{
+		{value: "2030-01-31", expected: "2030-01-31T00:00:00Z"},
+		{value: "2030-01-31T00:00:00Z", expected: "2030-01-31T00:00:00Z"},
+		{value: "2030-01-31T03:00:00+03:00", expected: "2030-01-31T00:00:00Z"},
+		{value: "2030-01-31T12:00:00Z", fails: true},
+		{value: "31/01/2030", fails: true},
+		{value: "", fails: true},
+	} {
+		date, err := parseLifecycleDate(tc.value)
+		if tc.fails {
+			if err == nil {
+				t.Errorf("%q: expected error, got %s", tc.value, date)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tc.value, err)
+		} else if got := date.Format("2006-01-02T15:04:05Z07:00"); got != tc.expected {
+			t.Errorf("%q: expected %s, got %s", tc.value, tc.expected, got)
+		}
+	}
+}
+
+func TestLifecycleValidation(t *testing.T) {
+	expire := &LifecycleExpiration{Days: 30}
+	tagged := &LifecycleFilter{Tags: []LifecycleTag{{Key: "a", Value: "1"}}}
+
+	for _, tc := range []struct {
+		name  string
+		rules []LifecycleRule
+		valid bool
+	}{
+		{name: "days", rules: []LifecycleRule{{Expiration: expire}}, valid: true},
+		{name: "date", rules: []LifecycleRule{{Expiration: &LifecycleExpiration{Date: "2030-01-31"}}}, valid: true},
+		{name: "prefix and tags", rules: []LifecycleRule{{Filter: &LifecycleFilter{Prefix: "logs/", Tags: tagged.Tags}, Expiration: expire}}, valid: true},
+		{name: "noncurrent", rules: []LifecycleRule{{NoncurrentVersionExpiration: &LifecycleNoncurrentVersionExpiration{NoncurrentDays: 7}}}, valid: true},
+		{name: "abort uploads", rules: []LifecycleRule{{AbortIncompleteMultipartUpload: &LifecycleAbortIncompleteMultipartUpload{DaysAfterInitiation: 1}}}, valid: true},
+		{name: "no rules"},
+		{name: "no action", rules: []LifecycleRule{{ID: "empty"}}},
+		{name: "invalid status", rules: []LifecycleRule{{Status: "On", Expiration: expire}}},
+		{name: "duplicated ids", rules: []LifecycleRule{{ID: "a", Expiration: expire}, {ID: "a", Expiration: expire}}},
+		{name: "negative days", rules: []LifecycleRule{{Expiration: &LifecycleExpiration{Days: -1}}}},
+		{name: "days and date", rules: []LifecycleRule{{Expiration: &LifecycleExpiration{Days: 1, Date: "2030-01-31"}}}},
+		{name: "date not at midnight", rules: []LifecycleRule{{Expiration: &LifecycleExpiration{Date: "2030-01-31T10:00:00Z"}}}},
+		{name: "noncurrent days", rules: []LifecycleRule{{NoncurrentVersionExpiration: &LifecycleNoncurrentVersionExpiration{}}}},
+		{name: "newer versions", rules: []LifecycleRule{{NoncurrentVersionExpiration: &LifecycleNoncurrentVersionExpiration{NoncurrentDays: 1, NewerNoncurrentVersions: -1}}}},
+		{name: "abort days", rules: []LifecycleRule{{AbortIncompleteMultipartUpload: &LifecycleAbortIncompleteMultipartUpload{}}}},
+		{name: "abort with tags", rules: []LifecycleRule{{Filter: tagged, AbortIncompleteMultipartUpload: &LifecycleAbortIncompleteMultipartUpload{DaysAfterInitiation: 1}}}},
+		{name: "delete markers with tags", rules: []LifecycleRule{{Filter: tagged, Expiration: &LifecycleExpiration{ExpiredObjectDeleteMarker: true}}}},
+		{name: "tag without key", rules: []LifecycleRule{{Filter: &LifecycleFilter{Tags: []LifecycleTag{{Value: "1"}}}, Expiration: expire}}},
+		{name: "duplicated tags", rules: []LifecycleRule{{Filter: &LifecycleFilter{Tags: []LifecycleTag{{Key: "a"}, {Key: "a"}}}, Expiration: expire}}},
+	} {
+		config := LifecycleConfiguration{Rules: tc.rules}
+		err := config.validate()
+		if tc.valid {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tc.name, err)
+			}
+			continue
+		}
+		var usageErr core.UsageError
+		if !errors.As(err, &usageErr) {
+			t.Errorf("%s: expected usage error, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("logs")
+
+	rules := []LifecycleRule{
+		{ID: "all", Expiration: &LifecycleExpiration{Date: "2030-01-31"}},
+		{ID: "tmp", Filter: &LifecycleFilter{Prefix: "tmp/"}, Expiration: &LifecycleExpiration{Days: 1}},
+	}
+	if _, err := setLifecycle(ctx, setBucketLifecycleParams{Bucket: "logs", Lifecycle: LifecycleConfiguration{Rules: rules}}, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := getLifecycle(ctx, getBucketLifecycleParams{Bucket: "logs"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []LifecycleRule{
+		{ID: "all", Status: lifecycleStatusEnabled, Expiration: &LifecycleExpiration{Date: "2030-01-31T00:00:00Z"}},
+		{ID: "tmp", Status: lifecycleStatusEnabled, Filter: &LifecycleFilter{Prefix: "tmp/"}, Expiration: &LifecycleExpiration{Days: 1}},
+	}
+	if !reflect.DeepEqual(result.Rules, expected) {
+		t.Errorf("expected rules %+v, got %+v", expected, result.Rules)
+	}
+}
@@ -0,0 +1,78 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/core/xml"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type setBucketLifecycleParams struct {
+	Bucket    common.BucketName      `json:"dst" jsonschema:"description=Name of the bucket to set the lifecycle rules for,example=my-bucket" mgc:"positional"`
+	Lifecycle LifecycleConfiguration `json:"lifecycle" jsonschema:"description=Lifecycle configuration to be set. Use @./lifecycle.json or @./lifecycle.yaml to load it from a file" mgc:"positional"`
+}
+
+var getSet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "set",
+			Description: "Set the lifecycle rules for the specified bucket, replacing the existing ones. The configuration can be provided as a direct JSON string or a JSON/YAML file path using @./lifecycle.yaml.",
+		},
+		setLifecycle,
+	)
+
+	return core.NewExecuteFormat(exec, func(exec core.Executor, result core.Result) string {
+		return fmt.Sprintf("Successfully set lifecycle rules for bucket %q", result.Source().Parameters["dst"])
+	})
+})
+
+func setLifecycle(ctx context.Context, params setBucketLifecycleParams, cfg common.Config) (result core.Value, err error) {
+	if err = params.Lifecycle.validate(); err != nil {
+		return
+	}
+
+	req, err := newSetBucketLifecycleRequest(ctx, params, cfg)
+	if err != nil {
+		return
+	}
+
+	resp, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	err = common.ExtractErr(resp, req)
+	return
+}
+
+func newSetBucketLifecycleRequest(ctx context.Context, p setBucketLifecycleParams, cfg common.Config) (*http.Request, error) {
+	url, err := newLifecycleRequestURL(cfg, p.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Lifecycle.Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+	body, err := xml.Marshal(p.Lifecycle)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	req.ContentLength = int64(len(body))
+
+	return req, nil
+}
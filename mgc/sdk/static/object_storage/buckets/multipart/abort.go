@@ -0,0 +1,36 @@
+package multipart
+
+import (
+	"context"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type abortParams struct {
+	Destination mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the object being uploaded,example=bucket1/file.txt" mgc:"positional"`
+	UploadId    string           `json:"upload_id" jsonschema:"description=ID of the multipart upload to be aborted,required"`
+}
+
+var getAbort = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "abort",
+			Description: "Abort an incomplete multipart upload, discarding its uploaded parts",
+		},
+		abort,
+	)
+	return core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return `template=Aborted upload {{.upload_id}} of {{.dst}}` + "\n"
+	})
+})
+
+func abort(ctx context.Context, params abortParams, cfg common.Config) (result core.Value, err error) {
+	err = common.AbortMultipartUpload(ctx, cfg, params.Destination, params.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
@@ -0,0 +1,93 @@
+package multipart
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"go.uber.org/zap"
+)
+
+var abortAllLogger = utils.NewLazyLoader(func() *zap.SugaredLogger {
+	return logger().Named("abort-all")
+})
+
+type abortAllParams struct {
+	Bucket    common.BucketName `json:"bucket" jsonschema:"description=Name of the bucket to abort incomplete multipart uploads from" mgc:"positional"`
+	Prefix    string            `json:"prefix,omitempty" jsonschema:"description=Only abort uploads of keys starting with this prefix,example=dir/"`
+	OlderThan string            `json:"older_than,omitempty" jsonschema_description:"Only abort uploads initiated longer than this duration ago. Valid time units are 'ns', 'us' (or 'µs'), 'ms', 's', 'm' and 'h'" jsonschema:"example=24h"`
+}
+
+var getAbortAll = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "abort-all",
+			Description: "Abort all the incomplete multipart uploads of a Bucket, discarding their uploaded parts",
+		},
+		abortAll,
+	)
+
+	exec = core.NewPromptInputExecutor(
+		exec,
+		core.NewPromptInput(
+			`This command will abort incomplete multipart uploads at {{.confirmationValue}}, and its result is NOT reversible.
+Please confirm by retyping: {{.confirmationValue}}`,
+			"{{.parameters.bucket}}",
+		),
+	)
+
+	return core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "table"
+	})
+})
+
+// Failing to abort an upload doesn't stop the others, all the failures are returned together
+func abortAll(ctx context.Context, params abortAllParams, cfg common.Config) (result []common.MultipartUpload, err error) {
+	var olderThan time.Duration
+	if params.OlderThan != "" {
+		olderThan, err = time.ParseDuration(params.OlderThan)
+		if err != nil {
+			return nil, core.UsageError{Err: fmt.Errorf("error parsing older_than: %w", err)}
+		}
+	}
+
+	uploads, err := common.ListMultipartUploads(ctx, cfg, params.Bucket, params.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(-olderThan)
+	result = []common.MultipartUpload{}
+	var errs utils.MultiError
+	for _, upload := range uploads {
+		logger := abortAllLogger().With("key", upload.Key, "uploadId", upload.UploadId)
+
+		if olderThan > 0 {
+			initiated, err := upload.InitiatedTime()
+			if err != nil {
+				logger.Warnw("skipped upload with unknown initiation time", "initiated", upload.Initiated, "error", err)
+				continue
+			}
+			if initiated.After(deadline) {
+				continue
+			}
+		}
+
+		dst := params.Bucket.AsURI().JoinPath(upload.Key)
+		if err = common.AbortMultipartUpload(ctx, cfg, dst, upload.UploadId); err != nil {
+			logger.Debugw("failed to abort", "error", err)
+			errs = append(errs, &common.ObjectError{Url: dst, Err: fmt.Errorf("upload %s: %w", upload.UploadId, err)})
+			continue
+		}
+		logger.Debug("aborted")
+		result = append(result, upload)
+	}
+
+	if len(errs) > 0 {
+		return result, errs
+	}
+	return result, nil
+}
@@ -0,0 +1,23 @@
+package multipart
+
+import (
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+)
+
+var GetGroup = utils.NewLazyLoader(func() core.Grouper {
+	return core.NewStaticGroup(
+		core.DescriptorSpec{
+			Name:        "multipart",
+			Description: "Incomplete multipart uploads related commands",
+		},
+		func() []core.Descriptor {
+			return []core.Descriptor{
+				getList(),      // object-storage buckets multipart list
+				getListParts(), // object-storage buckets multipart list-parts
+				getAbort(),     // object-storage buckets multipart abort
+				getAbortAll(),  // object-storage buckets multipart abort-all
+			}
+		},
+	)
+})
@@ -0,0 +1,31 @@
+package multipart
+
+import (
+	"context"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type listParams struct {
+	Bucket common.BucketName `json:"bucket" jsonschema:"description=Name of the bucket to list incomplete multipart uploads from" mgc:"positional"`
+	Prefix string            `json:"prefix,omitempty" jsonschema:"description=Only list uploads of keys starting with this prefix,example=dir/"`
+}
+
+var getList = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "list",
+			Description: "List the incomplete multipart uploads of a Bucket",
+		},
+		list,
+	)
+	return core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "table"
+	})
+})
+
+func list(ctx context.Context, params listParams, cfg common.Config) (result []common.MultipartUpload, err error) {
+	return common.ListMultipartUploads(ctx, cfg, params.Bucket, params.Prefix)
+}
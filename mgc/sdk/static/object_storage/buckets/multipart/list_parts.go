@@ -0,0 +1,32 @@
+package multipart
+
+import (
+	"context"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type listPartsParams struct {
+	Destination mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the object being uploaded,example=bucket1/file.txt" mgc:"positional"`
+	UploadId    string           `json:"upload_id" jsonschema:"description=ID of the multipart upload,required"`
+}
+
+var getListParts = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "list-parts",
+			Description: "List the parts already uploaded to an incomplete multipart upload",
+		},
+		listParts,
+	)
+	return core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "table"
+	})
+})
+
+func listParts(ctx context.Context, params listPartsParams, cfg common.Config) (result []common.UploadPart, err error) {
+	return common.ListParts(ctx, cfg, params.Destination, params.UploadId)
+}
@@ -0,0 +1,39 @@
+package multipart
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func TestAbortAllContinuesOnFailure(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("bucket")
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := common.CreateMultipartUpload(ctx, cfg, mgcSchemaPkg.URI("bucket/"+key), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.FailRequestsWithParam(http.MethodDelete, "uploadId", "bucket", "a", -1)
+
+	result, err := abortAll(ctx, abortAllParams{Bucket: "bucket"}, cfg)
+	var errs utils.MultiError
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected a single failure, got %v", err)
+	}
+	if len(result) != 2 {
+		t.Errorf("uploads after the failure must still be aborted, got %+v", result)
+	}
+	if n := server.UploadCount("bucket"); n != 1 {
+		t.Errorf("only the failed upload must be left, got %d", n)
+	}
+}
@@ -0,0 +1,50 @@
+package object_lock
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func TestSetAndUnset(t *testing.T) {
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	if _, err := setObjectLocking(ctx, setBucketObjectLockParams{Bucket: "bucket", Days: 1, Years: 1}, cfg); err == nil {
+		t.Error("expected an error when giving both days and years")
+	}
+
+	if _, err := setObjectLocking(ctx, setBucketObjectLockParams{Bucket: "bucket", Days: 1}, cfg); err != nil {
+		t.Fatalf("setObjectLocking() failed: %s", err)
+	}
+	config := string(server.ObjectLockConfiguration("bucket"))
+	if !strings.Contains(config, "<Days>1</Days>") || !strings.Contains(config, "<Mode>COMPLIANCE</Mode>") {
+		t.Errorf("unexpected configuration: %s", config)
+	}
+
+	// New objects are locked by the default retention
+	server.PutObject("bucket", "locked.txt", []byte("locked"))
+	obj, _ := server.Object("bucket", "locked.txt")
+	err := common.Delete(ctx, common.DeleteObjectParams{Destination: "bucket/locked.txt", Version: obj.VersionId}, cfg)
+	if err == nil {
+		t.Error("expected an error when deleting a locked version")
+	}
+
+	if _, err = unsetObjectLocking(ctx, unsetBucketObjectLockParams{Bucket: "bucket"}, cfg); err != nil {
+		t.Fatalf("unsetObjectLocking() failed: %s", err)
+	}
+	if config = string(server.ObjectLockConfiguration("bucket")); strings.Contains(config, "DefaultRetention") {
+		t.Errorf("default retention was not removed: %s", config)
+	}
+
+	server.PutObject("bucket", "unlocked.txt", []byte("unlocked"))
+	obj, _ = server.Object("bucket", "unlocked.txt")
+	if err = common.Delete(ctx, common.DeleteObjectParams{Destination: "bucket/unlocked.txt", Version: obj.VersionId}, cfg); err != nil {
+		t.Errorf("Delete() failed: %s", err)
+	}
+}
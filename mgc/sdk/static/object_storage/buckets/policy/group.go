@@ -13,9 +13,11 @@ var GetGroup = utils.NewLazyLoader(func() core.Grouper {
 		},
 		func() []core.Descriptor {
 			return []core.Descriptor{
-				getGet(),    // object-storage buckets acl get
-				getSet(),    // object-storage buckets acl set
-				getDelete(), // object-storage buckets acl delete
+				getGet(),      // object-storage buckets policy get
+				getSet(),      // object-storage buckets policy set
+				getDelete(),   // object-storage buckets policy delete
+				getValidate(), // object-storage buckets policy validate
+				getSimulate(), // object-storage buckets policy simulate
 			}
 		},
 	)
@@ -14,8 +14,9 @@ import (
 )
 
 type setBucketPolicyParams struct {
-	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to set permissions for,example=my-bucket" mgc:"positional"`
-	Policy map[string]any    `json:"policy" jsonschema:"description=Policy file path to be uploaded,example=@./policy.json or ./policy.json" mgc:"positional"`
+	Bucket         common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to set permissions for,example=my-bucket" mgc:"positional"`
+	Policy         map[string]any    `json:"policy" jsonschema:"description=Policy file path to be uploaded,example=@./policy.json or ./policy.json" mgc:"positional"`
+	SkipValidation bool              `json:"skip_validation,omitempty" jsonschema:"description=Send the policy without validating it locally first,default=false"`
 }
 
 var getSet = utils.NewLazyLoader(func() core.Executor {
@@ -35,6 +36,12 @@ var getSet = utils.NewLazyLoader(func() core.Executor {
 })
 
 func setPolicy(ctx context.Context, params setBucketPolicyParams, cfg common.Config) (result core.Value, err error) {
+	if !params.SkipValidation {
+		if _, err = common.ParsePolicyValue(params.Policy, string(params.Bucket)); err != nil {
+			return nil, core.UsageError{Err: err}
+		}
+	}
+
 	req, err := newSetBucketPolicyRequest(ctx, params, cfg)
 	if err != nil {
 		return
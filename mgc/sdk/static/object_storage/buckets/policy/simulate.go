@@ -0,0 +1,98 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type simulatePolicyParams struct {
+	Bucket     common.BucketName     `json:"dst" jsonschema:"description=Name of the bucket the request is made to,example=my-bucket" mgc:"positional"`
+	PolicyFile mgcSchemaPkg.FilePath `json:"policy_file,omitempty" jsonschema_description:"Policy document to evaluate. If omitted, the current policy of the bucket is used" jsonschema:"example=./policy.json"`
+	Principal  string                `json:"principal,omitempty" jsonschema_description:"ID of the principal making the request, such as a tenant ID. If omitted, the request is anonymous"`
+	Action     string                `json:"action" jsonschema:"description=Action of the request,example=s3:GetObject,required"`
+	Key        string                `json:"key,omitempty" jsonschema:"description=Key of the object the request is made to. Required for object actions,example=path/to/file.txt"`
+	Context    map[string]string     `json:"context,omitempty" jsonschema_description:"Values of the condition keys of the request, such as {\"aws:SourceIp\": \"10.0.0.1\"}"`
+}
+
+type simulatedStatement struct {
+	Index  int    `json:"index"`
+	Sid    string `json:"sid,omitempty"`
+	Effect string `json:"effect"`
+}
+
+type simulatePolicyResult struct {
+	Allowed    bool                 `json:"allowed"`
+	Decision   string               `json:"decision"`
+	Resource   string               `json:"resource"`
+	Statements []simulatedStatement `json:"statements"`
+}
+
+var getSimulate = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "simulate",
+			Summary:     "Check whether a policy allows a request",
+			Description: "Evaluate locally whether a principal may perform an action on a bucket or object under a policy document, without making the request. Explicit denies win over allows, and requests without any allowing statement are denied.",
+		},
+		simulatePolicy,
+	)
+
+	exec = core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "template={{if .allowed}}Allowed{{else}}Denied{{end}} ({{.decision}}) on {{.resource}}{{range .statements}}\n  statement {{.index}}{{if .sid}} {{.sid}}{{end}}: {{.effect}}{{end}}\n"
+	})
+
+	return exec
+})
+
+func simulatePolicy(ctx context.Context, params simulatePolicyParams, cfg common.Config) (result simulatePolicyResult, err error) {
+	req := common.PolicyRequest{
+		Principal: params.Principal,
+		Action:    params.Action,
+		Bucket:    string(params.Bucket),
+		Key:       params.Key,
+		Context:   params.Context,
+	}
+	if params.Key == "" && !common.IsBucketPolicyAction(params.Action) {
+		return result, core.UsageError{Err: fmt.Errorf("key is required for the object action %s", params.Action)}
+	}
+
+	document, err := readSimulatedPolicy(ctx, params, cfg)
+	if err != nil {
+		return
+	}
+
+	decision := document.Evaluate(req)
+	result = simulatePolicyResult{
+		Allowed:    decision.Allowed,
+		Decision:   decision.Decision,
+		Resource:   req.Resource(),
+		Statements: make([]simulatedStatement, 0, len(decision.Statements)),
+	}
+	for _, i := range decision.Statements {
+		statement := document.Statement[i]
+		result.Statements = append(result.Statements, simulatedStatement{Index: i, Sid: statement.Sid, Effect: statement.Effect})
+	}
+	return
+}
+
+// The policy file is validated, while the policy of the bucket was already accepted by the server
+func readSimulatedPolicy(ctx context.Context, params simulatePolicyParams, cfg common.Config) (*common.PolicyDocument, error) {
+	if params.PolicyFile != "" {
+		data, err := os.ReadFile(params.PolicyFile.String())
+		if err != nil {
+			return nil, core.UsageError{Err: err}
+		}
+		document, err := common.ParsePolicy(data, string(params.Bucket))
+		if err != nil {
+			return nil, core.UsageError{Err: err}
+		}
+		return document, nil
+	}
+	return common.GetBucketPolicy(ctx, cfg, params.Bucket)
+}
@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func TestSetPolicyValidation(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("site")
+
+	invalid := map[string]any{
+		"Statement": []any{map[string]any{"Effect": "Allow", "Principal": "*", "Action": "GetObject", "Resource": "site/*"}},
+	}
+	_, err := setPolicy(ctx, setBucketPolicyParams{Bucket: "site", Policy: invalid}, cfg)
+	var usageErr core.UsageError
+	if !errors.As(err, &usageErr) {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+	if n := server.CountRequests("PUT", "policy"); n != 0 {
+		t.Errorf("invalid policy must not be sent, got %d requests", n)
+	}
+
+	if _, err = setPolicy(ctx, setBucketPolicyParams{Bucket: "site", Policy: invalid, SkipValidation: true}, cfg); err != nil {
+		t.Errorf("skip_validation must send the policy as given: %v", err)
+	}
+}
+
+func TestSimulatePolicy(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("site")
+
+	policy := map[string]any{
+		"Version": "2012-10-17",
+		"Statement": []any{
+			map[string]any{"Sid": "PublicRead", "Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "site/*"},
+			map[string]any{"Effect": "Deny", "Principal": "*", "Action": "s3:GetObject", "Resource": "site/private/*"},
+		},
+	}
+	if _, err := setPolicy(ctx, setBucketPolicyParams{Bucket: "site", Policy: policy}, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := simulatePolicy(ctx, simulatePolicyParams{Bucket: "site", Action: "s3:GetObject", Key: "index.html"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed || result.Resource != "site/index.html" || len(result.Statements) != 1 || result.Statements[0].Sid != "PublicRead" {
+		t.Errorf("unexpected result for the bucket policy: %+v", result)
+	}
+
+	if result, err = simulatePolicy(ctx, simulatePolicyParams{Bucket: "site", Action: "s3:GetObject", Key: "private/a"}, cfg); err != nil || result.Decision != common.PolicyDecisionDeny {
+		t.Errorf("expected explicit deny: %+v %v", result, err)
+	}
+
+	if _, err = simulatePolicy(ctx, simulatePolicyParams{Bucket: "site", Action: "s3:GetObject"}, cfg); err == nil {
+		t.Error("object actions must require a key")
+	}
+
+	file := filepath.Join(t.TempDir(), "policy.json")
+	data := `{"Statement": {"Effect": "Allow", "Principal": {"MGC": "partner"}, "Action": "s3:ListBucket", "Resource": "site"}}`
+	if err = os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	params := simulatePolicyParams{Bucket: "site", PolicyFile: mgcSchemaPkg.FilePath(file), Principal: "partner", Action: "s3:ListBucket"}
+	if result, err = simulatePolicy(ctx, params, cfg); err != nil || !result.Allowed || result.Resource != "site" {
+		t.Errorf("expected the policy file to allow listing: %+v %v", result, err)
+	}
+	params.Principal = ""
+	if result, err = simulatePolicy(ctx, params, cfg); err != nil || result.Decision != common.PolicyDecisionImplicitDeny {
+		t.Errorf("expected anonymous requests to be denied: %+v %v", result, err)
+	}
+}
+
+func TestSimulatePolicyNegatedConditionWithoutKey(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("site")
+
+	policy := map[string]any{
+		"Statement": []any{
+			map[string]any{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "site/*"},
+			map[string]any{
+				"Sid": "OnlyFromVpce", "Effect": "Deny", "Principal": "*", "Action": "s3:GetObject", "Resource": "site/*",
+				"Condition": map[string]any{"StringNotEquals": map[string]any{"aws:SourceVpce": "vpce-1"}},
+			},
+		},
+	}
+	if _, err := setPolicy(ctx, setBucketPolicyParams{Bucket: "site", Policy: policy}, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the key, the request doesn't come from the endpoint, so it's denied
+	params := simulatePolicyParams{Bucket: "site", Action: "s3:GetObject", Key: "index.html"}
+	if result, err := simulatePolicy(ctx, params, cfg); err != nil || result.Decision != common.PolicyDecisionDeny {
+		t.Errorf("expected explicit deny without the condition key: %+v %v", result, err)
+	}
+
+	params.Context = map[string]string{"aws:SourceVpce": "vpce-1"}
+	if result, err := simulatePolicy(ctx, params, cfg); err != nil || !result.Allowed {
+		t.Errorf("expected requests from the endpoint to be allowed: %+v %v", result, err)
+	}
+}
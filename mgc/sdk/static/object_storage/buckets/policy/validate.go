@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"context"
+	"os"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type validatePolicyParams struct {
+	PolicyFile mgcSchemaPkg.FilePath `json:"policy_file" jsonschema:"description=Policy document to validate,example=./policy.json" mgc:"positional"`
+	Bucket     common.BucketName     `json:"bucket,omitempty" jsonschema:"description=Bucket the policy is for. If given the resources must be in it,example=my-bucket"`
+}
+
+type validatePolicyResult struct {
+	File       mgcSchemaPkg.FilePath `json:"file"`
+	Statements int                   `json:"statements"`
+	Warnings   []string              `json:"warnings,omitempty"`
+}
+
+var getValidate = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "validate",
+			Summary:     "Validate a policy document locally",
+			Description: "Check the principals, actions, resources and conditions of a policy document without sending it, reporting the line and column of each problem.",
+		},
+		validatePolicy,
+	)
+
+	exec = core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "template=Policy {{.file}} is valid, with {{.statements}} statements\n{{range .warnings}}Warning: {{.}}\n{{end}}"
+	})
+
+	return exec
+})
+
+func validatePolicy(ctx context.Context, params validatePolicyParams, cfg common.Config) (result validatePolicyResult, err error) {
+	data, err := os.ReadFile(params.PolicyFile.String())
+	if err != nil {
+		return result, core.UsageError{Err: err}
+	}
+
+	document, warnings, err := common.CheckPolicy(data, string(params.Bucket))
+	if err != nil {
+		return result, core.UsageError{Err: err}
+	}
+
+	result = validatePolicyResult{File: params.PolicyFile, Statements: len(document.Statement)}
+	for _, warning := range warnings {
+		result.Warnings = append(result.Warnings, warning.Error())
+	}
+	return result, nil
+}
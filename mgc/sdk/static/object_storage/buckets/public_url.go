@@ -2,10 +2,12 @@ package buckets
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/MagaluCloud/magalu/mgc/core"
 	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
 	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/buckets/website"
 	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
 )
 
@@ -17,15 +19,29 @@ var getPublicUrl = utils.NewLazyLoader[core.Executor](func() core.Executor {
 	executor := core.NewStaticExecute(
 		core.DescriptorSpec{
 			Name:        "public-url",
-			Description: "Get bucket public url",
+			Description: "Get bucket public url. If the bucket has static website hosting enabled, its website endpoint is shown instead",
 		},
 		bucketPublicUrl,
 	)
 	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
-		return "template={{.url}}\n"
+		return "template={{if .website_url}}{{.website_url}}{{else}}{{.url}}{{if .website_enabled}} (static website hosting is enabled, set the websiteEndpoint config to show its URL){{end}}{{end}}\n"
 	})
 })
 
 func bucketPublicUrl(ctx context.Context, p publicUrlParams, cfg common.Config) (*common.PublicUrlResult, error) {
-	return common.PublicUrl(ctx, cfg, p.Destination)
+	result, err := common.PublicUrl(ctx, cfg, p.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = website.GetWebsite(ctx, cfg, common.NewBucketNameFromURI(p.Destination)); err != nil {
+		if isNotFound(err, "NoSuchWebsiteConfiguration") {
+			return result, nil
+		}
+		return nil, fmt.Errorf("error checking the website hosting of %s: %w", p.Destination, err)
+	}
+
+	result.WebsiteEnabled = true
+	result.WebsiteURL, err = common.WebsiteUrl(cfg, p.Destination)
+	return result, err
 }
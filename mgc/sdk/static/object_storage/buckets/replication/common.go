@@ -0,0 +1,193 @@
+package replication
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+const (
+	replicationMaxRules    = 1000
+	replicationMaxIDLength = 255
+
+	replicationStatusEnabled  = "Enabled"
+	replicationStatusDisabled = "Disabled"
+)
+
+// Objects must match all the conditions to be replicated. An empty filter replicates the whole bucket
+type ReplicationFilter struct {
+	Prefix string             `json:"prefix,omitempty" jsonschema:"description=Only replicate keys starting with this prefix,example=backups/"`
+	Tags   []common.ObjectTag `json:"tags,omitempty" jsonschema:"description=Only replicate objects with all these tags"`
+}
+
+type replicationFilterConditions struct {
+	Prefix string             `xml:"Prefix,omitempty"`
+	Tags   []common.ObjectTag `xml:"Tag,omitempty"`
+}
+
+type replicationFilterXML struct {
+	replicationFilterConditions
+	And *replicationFilterConditions `xml:"And,omitempty"`
+}
+
+// S3 requires multiple conditions to be wrapped in <And>
+func (f ReplicationFilter) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	conditions := replicationFilterConditions{Prefix: f.Prefix, Tags: f.Tags}
+	if len(f.Tags) > 1 || (len(f.Tags) == 1 && f.Prefix != "") {
+		return e.EncodeElement(replicationFilterXML{And: &conditions}, start)
+	}
+	return e.EncodeElement(replicationFilterXML{replicationFilterConditions: conditions}, start)
+}
+
+func (f *ReplicationFilter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v replicationFilterXML
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	if v.And != nil {
+		*f = ReplicationFilter{Prefix: v.And.Prefix, Tags: v.And.Tags}
+	} else {
+		*f = ReplicationFilter{Prefix: v.Prefix, Tags: v.Tags}
+	}
+	return nil
+}
+
+type ReplicationDestination struct {
+	Bucket       string `xml:"Bucket" json:"bucket" jsonschema:"description=Name of the bucket to replicate to. It may be in another region,example=my-bucket-replica"`
+	StorageClass string `xml:"StorageClass,omitempty" json:"storage_class,omitempty" jsonschema:"description=Storage class of the replicas. Defaults to the one of the source objects,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
+}
+
+type ReplicationRule struct {
+	ID                      string                 `xml:"ID,omitempty" json:"id,omitempty" jsonschema:"description=Unique identifier of the rule,example=disaster-recovery"`
+	Priority                int                    `xml:"Priority" json:"priority,omitempty" jsonschema:"description=Rules with higher priority win when several rules replicate an object to the same bucket,minimum=0"`
+	Status                  string                 `xml:"Status" json:"status,omitempty" jsonschema:"description=Whether the rule is applied,enum=Enabled,enum=Disabled,default=Enabled"`
+	Filter                  *ReplicationFilter     `xml:"Filter" json:"filter,omitempty"`
+	DeleteMarkerReplication string                 `xml:"DeleteMarkerReplication>Status" json:"delete_marker_replication,omitempty" jsonschema:"description=Whether delete markers are replicated,enum=Enabled,enum=Disabled,default=Disabled"`
+	Destination             ReplicationDestination `xml:"Destination" json:"destination"`
+}
+
+type ReplicationConfiguration struct {
+	Role  string            `xml:"Role,omitempty" json:"role,omitempty" jsonschema:"description=Identity the server assumes to replicate the objects"`
+	Rules []ReplicationRule `xml:"Rule" json:"rules" jsonschema:"description=Replication rules of the bucket"`
+
+	Namespace string   `xml:"xmlns,omitempty,attr" json:"-"`
+	XMLName   struct{} `xml:"ReplicationConfiguration" json:"-"`
+}
+
+func validateStatus(name string, status *string, fallback string) error {
+	switch *status {
+	case "":
+		*status = fallback
+	case replicationStatusEnabled, replicationStatusDisabled:
+	default:
+		return fmt.Errorf("invalid %s %q, must be %q or %q", name, *status, replicationStatusEnabled, replicationStatusDisabled)
+	}
+	return nil
+}
+
+func (r *ReplicationRule) validate(source common.BucketName) error {
+	// The server requires the element, an empty filter replicates the whole bucket
+	if r.Filter == nil {
+		r.Filter = &ReplicationFilter{}
+	}
+	if len(r.ID) > replicationMaxIDLength {
+		return fmt.Errorf("id must have at most %d characters", replicationMaxIDLength)
+	}
+	if r.Priority < 0 {
+		return fmt.Errorf("priority can't be negative, got %d", r.Priority)
+	}
+	if err := validateStatus("status", &r.Status, replicationStatusEnabled); err != nil {
+		return err
+	}
+	if err := validateStatus("delete_marker_replication", &r.DeleteMarkerReplication, replicationStatusDisabled); err != nil {
+		return err
+	}
+
+	tagKeys := make(map[string]struct{}, len(r.Filter.Tags))
+	for _, tag := range r.Filter.Tags {
+		if tag.Key == "" {
+			return errors.New("filter tags must have a key")
+		}
+		if _, ok := tagKeys[tag.Key]; ok {
+			return fmt.Errorf("duplicated filter tag %q", tag.Key)
+		}
+		tagKeys[tag.Key] = struct{}{}
+	}
+	if len(r.Filter.Tags) > 0 && r.DeleteMarkerReplication == replicationStatusEnabled {
+		return errors.New("delete markers can't be replicated by rules with tag filters")
+	}
+
+	destination := strings.TrimPrefix(r.Destination.Bucket, common.BucketARNPrefix)
+	if destination == "" {
+		return errors.New("destination bucket is required")
+	}
+	if destination == string(source) {
+		return errors.New("destination bucket must not be the source bucket")
+	}
+	return nil
+}
+
+func (c *ReplicationConfiguration) validate(source common.BucketName) error {
+	if len(c.Rules) == 0 {
+		return core.UsageError{Err: errors.New("replication configuration must have at least one rule")}
+	}
+	if len(c.Rules) > replicationMaxRules {
+		return core.UsageError{Err: fmt.Errorf("replication configuration must have at most %d rules, got %d", replicationMaxRules, len(c.Rules))}
+	}
+
+	ids := make(map[string]struct{}, len(c.Rules))
+	priorities := make(map[int]string, len(c.Rules))
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if err := rule.validate(source); err != nil {
+			return core.UsageError{Err: fmt.Errorf("rule %d (%q): %w", i, rule.ID, err)}
+		}
+		if rule.ID != "" {
+			if _, ok := ids[rule.ID]; ok {
+				return core.UsageError{Err: fmt.Errorf("rule %d: duplicated id %q", i, rule.ID)}
+			}
+			ids[rule.ID] = struct{}{}
+		}
+		if other, ok := priorities[rule.Priority]; ok {
+			return core.UsageError{Err: fmt.Errorf("rule %d (%q): priority %d is already used by rule %q", i, rule.ID, rule.Priority, other)}
+		}
+		priorities[rule.Priority] = rule.ID
+	}
+	return nil
+}
+
+// Destinations are sent as ARNs, but shown as bucket names. Returns a copy, leaving the rules
+// given by the caller untouched
+func (c ReplicationConfiguration) withDestinationARNs() ReplicationConfiguration {
+	c.Rules = slices.Clone(c.Rules)
+	for i := range c.Rules {
+		if bucket := c.Rules[i].Destination.Bucket; !strings.HasPrefix(bucket, common.BucketARNPrefix) {
+			c.Rules[i].Destination.Bucket = common.BucketARNPrefix + bucket
+		}
+	}
+	return c
+}
+
+func (c *ReplicationConfiguration) destinationsAsNames() {
+	for i := range c.Rules {
+		c.Rules[i].Destination.Bucket = strings.TrimPrefix(c.Rules[i].Destination.Bucket, common.BucketARNPrefix)
+	}
+}
+
+func newReplicationRequestURL(cfg common.Config, bucketName common.BucketName) (string, error) {
+	url, err := common.BuildBucketHostURL(cfg, bucketName)
+	if err != nil {
+		return "", core.UsageError{Err: err}
+	}
+
+	query := url.Query()
+	query.Set("replication", "")
+	url.RawQuery = query.Encode()
+
+	return url.String(), nil
+}
@@ -0,0 +1,49 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type deleteBucketReplicationParams struct {
+	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to delete the replication configuration from,example=my-bucket" mgc:"positional"`
+}
+
+var getDelete = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "delete",
+			Description: "Delete the replication configuration of the specified bucket",
+		},
+		deleteReplication,
+	)
+
+	return core.NewExecuteFormat(exec, func(exec core.Executor, result core.Result) string {
+		return fmt.Sprintf("Successfully deleted replication configuration for bucket %q", result.Source().Parameters["dst"])
+	})
+})
+
+func deleteReplication(ctx context.Context, params deleteBucketReplicationParams, cfg common.Config) (result core.Value, err error) {
+	url, err := newReplicationRequestURL(cfg, params.Bucket)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	err = common.ExtractErr(resp, req)
+	return
+}
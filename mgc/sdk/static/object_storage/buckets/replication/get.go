@@ -0,0 +1,48 @@
+package replication
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type getBucketReplicationParams struct {
+	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to get the replication configuration from,example=my-bucket" mgc:"positional"`
+}
+
+var getGet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "get",
+			Description: "Get the replication configuration of the specified bucket",
+		},
+		getReplication,
+	)
+	return core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "json"
+	})
+})
+
+func getReplication(ctx context.Context, params getBucketReplicationParams, cfg common.Config) (result ReplicationConfiguration, err error) {
+	url, err := newReplicationRequestURL(cfg, params.Bucket)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+
+	res, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	result, err = common.UnwrapResponse[ReplicationConfiguration](res, req)
+	result.destinationsAsNames()
+	return
+}
@@ -0,0 +1,22 @@
+package replication
+
+import (
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+)
+
+var GetGroup = utils.NewLazyLoader(func() core.Grouper {
+	return core.NewStaticGroup(
+		core.DescriptorSpec{
+			Name:        "replication",
+			Description: "Replication-related commands",
+		},
+		func() []core.Descriptor {
+			return []core.Descriptor{
+				getGet(),    // object-storage buckets replication get
+				getSet(),    // object-storage buckets replication set
+				getDelete(), // object-storage buckets replication delete
+			}
+		},
+	)
+})
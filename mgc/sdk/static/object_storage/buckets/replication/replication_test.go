@@ -0,0 +1,111 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func TestReplication(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("source")
+
+	if _, err := getReplication(ctx, getBucketReplicationParams{Bucket: "source"}, cfg); err == nil {
+		t.Fatal("expected error for bucket without replication configuration")
+	}
+
+	rules := []ReplicationRule{
+		{
+			ID:                      "all",
+			Priority:                1,
+			DeleteMarkerReplication: replicationStatusEnabled,
+			Destination:             ReplicationDestination{Bucket: "replica"},
+		},
+		{
+			ID:          "tagged",
+			Priority:    2,
+			Filter:      &ReplicationFilter{Prefix: "backups/", Tags: []common.ObjectTag{{Key: "dr", Value: "true"}}},
+			Destination: ReplicationDestination{Bucket: "archive", StorageClass: "cold"},
+		},
+	}
+	if _, err := setReplication(ctx, setBucketReplicationParams{Bucket: "source", Rules: rules}, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := newSetBucketReplicationRequest(ctx, "source", ReplicationConfiguration{Rules: rules}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(req.Body)
+	body := string(data)
+	for _, expected := range []string{
+		"<Bucket>arn:aws:s3:::replica</Bucket>",
+		"<DeleteMarkerReplication><Status>Enabled</Status></DeleteMarkerReplication>",
+		"<And><Prefix>backups/</Prefix><Tag><Key>dr</Key><Value>true</Value></Tag></And>",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %s in the request, got %s", expected, body)
+		}
+	}
+
+	result, err := getReplication(ctx, getBucketReplicationParams{Bucket: "source"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Defaults are filled on validation
+	rules[0].Status = replicationStatusEnabled
+	rules[1].Status = replicationStatusEnabled
+	rules[1].DeleteMarkerReplication = replicationStatusDisabled
+	if !reflect.DeepEqual(result.Rules, rules) {
+		t.Errorf("expected rules %+v, got %+v", rules, result.Rules)
+	}
+
+	if _, err = deleteReplication(ctx, deleteBucketReplicationParams{Bucket: "source"}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = getReplication(ctx, getBucketReplicationParams{Bucket: "source"}, cfg); err == nil {
+		t.Error("replication configuration must be deleted")
+	}
+}
+
+func TestReplicationValidation(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("source")
+
+	replica := ReplicationDestination{Bucket: "replica"}
+	for name, rules := range map[string][]ReplicationRule{
+		"no rules":          nil,
+		"missing bucket":    {{}},
+		"same bucket":       {{Destination: ReplicationDestination{Bucket: "source"}}},
+		"invalid status":    {{Status: "On", Destination: replica}},
+		"duplicated ids":    {{ID: "a", Priority: 1, Destination: replica}, {ID: "a", Priority: 2, Destination: replica}},
+		"same priority":     {{ID: "a", Destination: replica}, {ID: "b", Destination: replica}},
+		"negative priority": {{Priority: -1, Destination: replica}},
+		"duplicated tags":   {{Filter: &ReplicationFilter{Tags: []common.ObjectTag{{Key: "a"}, {Key: "a"}}}, Destination: replica}},
+		"tags and delete markers": {{
+			Filter:                  &ReplicationFilter{Tags: []common.ObjectTag{{Key: "a"}}},
+			DeleteMarkerReplication: replicationStatusEnabled,
+			Destination:             replica,
+		}},
+	} {
+		_, err := setReplication(ctx, setBucketReplicationParams{Bucket: "source", Rules: rules}, cfg)
+		var usageErr core.UsageError
+		if !errors.As(err, &usageErr) {
+			t.Errorf("%s: expected usage error, got %v", name, err)
+		}
+	}
+	if n := server.CountRequests("PUT", "replication"); n != 0 {
+		t.Errorf("invalid configurations must not be sent, got %d requests", n)
+	}
+}
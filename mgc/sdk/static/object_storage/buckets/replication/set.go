@@ -0,0 +1,83 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/core/xml"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type setBucketReplicationParams struct {
+	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to set the replication configuration for,example=my-bucket" mgc:"positional"`
+	Role   string            `json:"role,omitempty" jsonschema:"description=Identity the server assumes to replicate the objects"`
+	Rules  []ReplicationRule `json:"rules" jsonschema:"description=Replication rules of the bucket. Use @./replication.json or @./replication.yaml to load them from a file,minItems=1"`
+}
+
+var getSet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "set",
+			Description: "Set the replication configuration for the specified bucket, replacing the existing one. Versioning must be enabled on the source and destination buckets.",
+		},
+		setReplication,
+	)
+
+	return core.NewExecuteFormat(exec, func(exec core.Executor, result core.Result) string {
+		return fmt.Sprintf("Successfully set replication configuration for bucket %q", result.Source().Parameters["dst"])
+	})
+})
+
+func setReplication(ctx context.Context, params setBucketReplicationParams, cfg common.Config) (result core.Value, err error) {
+	replication := ReplicationConfiguration{
+		Role:      params.Role,
+		Rules:     params.Rules,
+		Namespace: "http://s3.amazonaws.com/doc/2006-03-01/",
+	}
+	if err = replication.validate(params.Bucket); err != nil {
+		return
+	}
+
+	req, err := newSetBucketReplicationRequest(ctx, params.Bucket, replication, cfg)
+	if err != nil {
+		return
+	}
+
+	resp, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	err = common.ExtractErr(resp, req)
+	return
+}
+
+func newSetBucketReplicationRequest(ctx context.Context, bucketName common.BucketName, replication ReplicationConfiguration, cfg common.Config) (*http.Request, error) {
+	url, err := newReplicationRequestURL(cfg, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := xml.Marshal(replication.withDestinationARNs())
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	req.ContentLength = int64(len(body))
+
+	return req, nil
+}
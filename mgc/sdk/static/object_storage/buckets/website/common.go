@@ -0,0 +1,157 @@
+package website
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+const websiteMaxRoutingRules = 50
+
+type RedirectAllRequestsTo struct {
+	HostName string `xml:"HostName" json:"host_name" jsonschema:"description=Host to redirect all requests to,example=www.example.com"`
+	Protocol string `xml:"Protocol,omitempty" json:"protocol,omitempty" jsonschema:"description=Protocol of the redirects. Defaults to the one of the request,enum=http,enum=https"`
+}
+
+// A rule applies if the request matches all the given conditions
+type RoutingRuleCondition struct {
+	KeyPrefixEquals             string `xml:"KeyPrefixEquals,omitempty" json:"key_prefix_equals,omitempty" jsonschema:"description=Apply to keys starting with this prefix,example=docs/"`
+	HttpErrorCodeReturnedEquals string `xml:"HttpErrorCodeReturnedEquals,omitempty" json:"http_error_code_returned_equals,omitempty" jsonschema:"description=Apply if the request would fail with this HTTP error code,example=404"`
+}
+
+type RoutingRuleRedirect struct {
+	HostName             string `xml:"HostName,omitempty" json:"host_name,omitempty" jsonschema:"description=Host to redirect to. Defaults to the one of the request,example=www.example.com"`
+	HttpRedirectCode     string `xml:"HttpRedirectCode,omitempty" json:"http_redirect_code,omitempty" jsonschema:"description=HTTP status code of the redirect. Defaults to 301,example=302"`
+	Protocol             string `xml:"Protocol,omitempty" json:"protocol,omitempty" jsonschema:"description=Protocol of the redirect. Defaults to the one of the request,enum=http,enum=https"`
+	ReplaceKeyPrefixWith string `xml:"ReplaceKeyPrefixWith,omitempty" json:"replace_key_prefix_with,omitempty" jsonschema:"description=Replace the prefix matched by the condition with this one,example=documents/"`
+	ReplaceKeyWith       string `xml:"ReplaceKeyWith,omitempty" json:"replace_key_with,omitempty" jsonschema:"description=Replace the whole key with this one,example=error.html"`
+}
+
+type RoutingRule struct {
+	Condition *RoutingRuleCondition `xml:"Condition,omitempty" json:"condition,omitempty" jsonschema:"description=When to apply the rule. If omitted the rule applies to all requests"`
+	Redirect  RoutingRuleRedirect   `xml:"Redirect" json:"redirect" jsonschema:"description=Where to redirect the matching requests"`
+}
+
+type WebsiteConfiguration struct {
+	IndexDocument         string                 `xml:"IndexDocument>Suffix,omitempty" json:"index_document,omitempty"`
+	ErrorDocument         string                 `xml:"ErrorDocument>Key,omitempty" json:"error_document,omitempty"`
+	RedirectAllRequestsTo *RedirectAllRequestsTo `xml:"RedirectAllRequestsTo,omitempty" json:"redirect_all_requests_to,omitempty"`
+	RoutingRules          []RoutingRule          `xml:"RoutingRules>RoutingRule,omitempty" json:"routing_rules,omitempty"`
+
+	Namespace string   `xml:"xmlns,omitempty,attr" json:"-"`
+	XMLName   struct{} `xml:"WebsiteConfiguration" json:"-"`
+}
+
+func validateProtocol(protocol string) error {
+	if protocol != "" && protocol != "http" && protocol != "https" {
+		return fmt.Errorf("protocol must be http or https, got %q", protocol)
+	}
+	return nil
+}
+
+func (r *RoutingRule) validate() error {
+	if c := r.Condition; c != nil {
+		if c.KeyPrefixEquals == "" && c.HttpErrorCodeReturnedEquals == "" {
+			return errors.New("condition must have a key prefix or an HTTP error code")
+		}
+		if code := c.HttpErrorCodeReturnedEquals; code != "" && !isStatusCode(code, 4, 5) {
+			return fmt.Errorf("HTTP error code must be 4xx or 5xx, got %q", code)
+		}
+	}
+
+	redirect := r.Redirect
+	if redirect.ReplaceKeyPrefixWith != "" && redirect.ReplaceKeyWith != "" {
+		return errors.New("redirect must not replace both the key prefix and the whole key")
+	}
+	if redirect.ReplaceKeyPrefixWith != "" && (r.Condition == nil || r.Condition.KeyPrefixEquals == "") {
+		return errors.New("replacing the key prefix requires a key prefix condition")
+	}
+	if code := redirect.HttpRedirectCode; code != "" && !isStatusCode(code, 3) {
+		return fmt.Errorf("HTTP redirect code must be 3xx, got %q", code)
+	}
+	if redirect == (RoutingRuleRedirect{}) {
+		return errors.New("redirect must change the host, protocol, code or key")
+	}
+	return validateProtocol(redirect.Protocol)
+}
+
+func isStatusCode(code string, classes ...byte) bool {
+	if len(code) != 3 || strings.Trim(code, "0123456789") != "" {
+		return false
+	}
+	for _, class := range classes {
+		if code[0] == '0'+class {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *WebsiteConfiguration) validate() error {
+	if c.RedirectAllRequestsTo != nil {
+		if c.IndexDocument != "" || c.ErrorDocument != "" || len(c.RoutingRules) > 0 {
+			return core.UsageError{Err: errors.New("redirecting all requests can't be combined with documents or routing rules")}
+		}
+		if c.RedirectAllRequestsTo.HostName == "" {
+			return core.UsageError{Err: errors.New("host to redirect all requests to must not be empty")}
+		}
+		if err := validateProtocol(c.RedirectAllRequestsTo.Protocol); err != nil {
+			return core.UsageError{Err: err}
+		}
+		return nil
+	}
+
+	if c.IndexDocument == "" {
+		return core.UsageError{Err: errors.New("index document is required unless all requests are redirected")}
+	}
+	if strings.Contains(c.IndexDocument, "/") {
+		return core.UsageError{Err: fmt.Errorf("index document must be a file name without '/', got %q", c.IndexDocument)}
+	}
+	if len(c.RoutingRules) > websiteMaxRoutingRules {
+		return core.UsageError{Err: fmt.Errorf("website configuration must have at most %d routing rules, got %d", websiteMaxRoutingRules, len(c.RoutingRules))}
+	}
+	for i := range c.RoutingRules {
+		if err := c.RoutingRules[i].validate(); err != nil {
+			return core.UsageError{Err: fmt.Errorf("routing rule %d: %w", i, err)}
+		}
+	}
+	return nil
+}
+
+func newWebsiteRequestURL(cfg common.Config, bucketName common.BucketName) (string, error) {
+	url, err := common.BuildBucketHostURL(cfg, bucketName)
+	if err != nil {
+		return "", core.UsageError{Err: err}
+	}
+
+	query := url.Query()
+	query.Set("website", "")
+	url.RawQuery = query.Encode()
+
+	return url.String(), nil
+}
+
+// Website configuration of the bucket, failing with a 404 HttpError if it doesn't have one
+func GetWebsite(ctx context.Context, cfg common.Config, bucketName common.BucketName) (result WebsiteConfiguration, err error) {
+	url, err := newWebsiteRequestURL(cfg, bucketName)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+
+	res, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	return common.UnwrapResponse[WebsiteConfiguration](res, req)
+}
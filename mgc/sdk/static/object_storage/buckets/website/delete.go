@@ -0,0 +1,49 @@
+package website
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type deleteBucketWebsiteParams struct {
+	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to disable website hosting for,example=my-bucket" mgc:"positional"`
+}
+
+var getDelete = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "delete",
+			Description: "Delete the website configuration of the specified bucket, disabling static website hosting",
+		},
+		deleteWebsite,
+	)
+
+	return core.NewExecuteFormat(exec, func(exec core.Executor, result core.Result) string {
+		return fmt.Sprintf("Successfully deleted website configuration for bucket %q", result.Source().Parameters["dst"])
+	})
+})
+
+func deleteWebsite(ctx context.Context, params deleteBucketWebsiteParams, cfg common.Config) (result core.Value, err error) {
+	url, err := newWebsiteRequestURL(cfg, params.Bucket)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	err = common.ExtractErr(resp, req)
+	return
+}
@@ -0,0 +1,30 @@
+package website
+
+import (
+	"context"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type getBucketWebsiteParams struct {
+	Bucket common.BucketName `json:"dst" jsonschema:"description=Name of the bucket to get the website configuration from,example=my-bucket" mgc:"positional"`
+}
+
+var getGet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "get",
+			Description: "Get the static website hosting configuration of the specified bucket",
+		},
+		getWebsite,
+	)
+	return core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "json"
+	})
+})
+
+func getWebsite(ctx context.Context, params getBucketWebsiteParams, cfg common.Config) (WebsiteConfiguration, error) {
+	return GetWebsite(ctx, cfg, params.Bucket)
+}
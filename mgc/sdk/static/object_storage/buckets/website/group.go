@@ -0,0 +1,22 @@
+package website
+
+import (
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+)
+
+var GetGroup = utils.NewLazyLoader(func() core.Grouper {
+	return core.NewStaticGroup(
+		core.DescriptorSpec{
+			Name:        "website",
+			Description: "Static website hosting commands",
+		},
+		func() []core.Descriptor {
+			return []core.Descriptor{
+				getGet(),    // object-storage buckets website get
+				getSet(),    // object-storage buckets website set
+				getDelete(), // object-storage buckets website delete
+			}
+		},
+	)
+})
@@ -0,0 +1,87 @@
+package website
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/core/xml"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type setBucketWebsiteParams struct {
+	Bucket                common.BucketName      `json:"dst" jsonschema:"description=Name of the bucket to set the website configuration for,example=my-bucket" mgc:"positional"`
+	IndexDocument         string                 `json:"index_document,omitempty" jsonschema_description:"Object returned for requests to the root or to a folder, such as docs/. Required unless all requests are redirected" jsonschema:"example=index.html"`
+	ErrorDocument         string                 `json:"error_document,omitempty" jsonschema:"description=Key of the object returned when a request fails with a 4xx error,example=error.html"`
+	RedirectAllRequestsTo *RedirectAllRequestsTo `json:"redirect_all_requests_to,omitempty" jsonschema:"description=Redirect all requests to another host instead of serving the objects"`
+	RoutingRules          []RoutingRule          `json:"routing_rules,omitempty" jsonschema:"description=Rules redirecting requests by key prefix or error code. Use @./rules.json or @./rules.yaml to load them from a file"`
+}
+
+var getSet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "set",
+			Description: "Set the static website hosting configuration for the specified bucket, replacing the existing one",
+		},
+		setWebsite,
+	)
+
+	return core.NewExecuteFormat(exec, func(exec core.Executor, result core.Result) string {
+		return fmt.Sprintf("Successfully set website configuration for bucket %q", result.Source().Parameters["dst"])
+	})
+})
+
+func setWebsite(ctx context.Context, params setBucketWebsiteParams, cfg common.Config) (result core.Value, err error) {
+	website := WebsiteConfiguration{
+		IndexDocument:         params.IndexDocument,
+		ErrorDocument:         params.ErrorDocument,
+		RedirectAllRequestsTo: params.RedirectAllRequestsTo,
+		RoutingRules:          params.RoutingRules,
+		Namespace:             "http://s3.amazonaws.com/doc/2006-03-01/",
+	}
+	if err = website.validate(); err != nil {
+		return
+	}
+
+	req, err := newSetBucketWebsiteRequest(ctx, params.Bucket, website, cfg)
+	if err != nil {
+		return
+	}
+
+	resp, err := common.SendRequest(ctx, req, cfg)
+	if err != nil {
+		return
+	}
+
+	err = common.ExtractErr(resp, req)
+	return
+}
+
+func newSetBucketWebsiteRequest(ctx context.Context, bucketName common.BucketName, website WebsiteConfiguration, cfg common.Config) (*http.Request, error) {
+	url, err := newWebsiteRequestURL(cfg, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := xml.Marshal(website)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	req.ContentLength = int64(len(body))
+
+	return req, nil
+}
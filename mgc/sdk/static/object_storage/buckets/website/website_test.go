@@ -0,0 +1,103 @@
+package website
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func TestWebsite(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("docs")
+
+	if _, err := getWebsite(ctx, getBucketWebsiteParams{Bucket: "docs"}, cfg); err == nil {
+		t.Fatal("expected error for bucket without website configuration")
+	}
+
+	params := setBucketWebsiteParams{
+		Bucket:        "docs",
+		IndexDocument: "index.html",
+		ErrorDocument: "errors/404.html",
+		RoutingRules: []RoutingRule{
+			{
+				Condition: &RoutingRuleCondition{KeyPrefixEquals: "v1/"},
+				Redirect:  RoutingRuleRedirect{ReplaceKeyPrefixWith: "v2/", HttpRedirectCode: "302"},
+			},
+			{
+				Condition: &RoutingRuleCondition{HttpErrorCodeReturnedEquals: "404"},
+				Redirect:  RoutingRuleRedirect{HostName: "example.com", Protocol: "https"},
+			},
+		},
+	}
+	if _, err := setWebsite(ctx, params, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	website, err := getWebsite(ctx, getBucketWebsiteParams{Bucket: "docs"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if website.IndexDocument != "index.html" || website.ErrorDocument != "errors/404.html" || website.RedirectAllRequestsTo != nil {
+		t.Errorf("unexpected documents: %+v", website)
+	}
+	if !reflect.DeepEqual(website.RoutingRules, params.RoutingRules) {
+		t.Errorf("expected routing rules %+v, got %+v", params.RoutingRules, website.RoutingRules)
+	}
+
+	if _, err = deleteWebsite(ctx, deleteBucketWebsiteParams{Bucket: "docs"}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = getWebsite(ctx, getBucketWebsiteParams{Bucket: "docs"}, cfg); err == nil {
+		t.Error("website configuration must be deleted")
+	}
+}
+
+func TestWebsiteValidation(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+	server.CreateBucket("docs")
+
+	redirectAll := &RedirectAllRequestsTo{HostName: "example.com"}
+	for name, params := range map[string]setBucketWebsiteParams{
+		"missing index":       {},
+		"index with path":     {IndexDocument: "docs/index.html"},
+		"redirect with index": {IndexDocument: "index.html", RedirectAllRequestsTo: redirectAll},
+		"invalid protocol":    {RedirectAllRequestsTo: &RedirectAllRequestsTo{HostName: "example.com", Protocol: "ftp"}},
+		"empty redirect":      {IndexDocument: "index.html", RoutingRules: []RoutingRule{{}}},
+		"both replacements": {IndexDocument: "index.html", RoutingRules: []RoutingRule{{
+			Condition: &RoutingRuleCondition{KeyPrefixEquals: "a/"},
+			Redirect:  RoutingRuleRedirect{ReplaceKeyPrefixWith: "b/", ReplaceKeyWith: "c"},
+		}}},
+		"prefix without condition": {IndexDocument: "index.html", RoutingRules: []RoutingRule{{
+			Redirect: RoutingRuleRedirect{ReplaceKeyPrefixWith: "b/"},
+		}}},
+		"redirect code": {IndexDocument: "index.html", RoutingRules: []RoutingRule{{
+			Redirect: RoutingRuleRedirect{HostName: "example.com", HttpRedirectCode: "404"},
+		}}},
+		"error code": {IndexDocument: "index.html", RoutingRules: []RoutingRule{{
+			Condition: &RoutingRuleCondition{HttpErrorCodeReturnedEquals: "200"},
+			Redirect:  RoutingRuleRedirect{HostName: "example.com"},
+		}}},
+	} {
+		params.Bucket = "docs"
+		_, err := setWebsite(ctx, params, cfg)
+		var usageErr core.UsageError
+		if !errors.As(err, &usageErr) {
+			t.Errorf("%s: expected usage error, got %v", name, err)
+		}
+	}
+	if n := server.CountRequests("PUT", "website"); n != 0 {
+		t.Errorf("invalid configurations must not be sent, got %d requests", n)
+	}
+
+	if _, err := setWebsite(ctx, setBucketWebsiteParams{Bucket: "docs", RedirectAllRequestsTo: redirectAll}, cfg); err != nil {
+		t.Errorf("redirecting all requests must not require an index document: %v", err)
+	}
+}
@@ -24,6 +24,11 @@ type bigFileCopier struct {
 	progressReporter *progress_report.BytesReporter
 	version          string
 	storageClass     string
+	tags             ObjectTags
+	headers          ObjectHeaders
+	encryption       SSECustomerKey
+	sourceEncryption SSECustomerKey
+	conditions       WriteConditions
 }
 
 var _ copier = (*bigFileCopier)(nil)
@@ -39,15 +44,14 @@ func (u *bigFileCopier) newPreparationRequest(ctx context.Context) (*http.Reques
 	}
 	req.Method = http.MethodPost
 	req.Header.Set("Content-Type", "application/octet-stream")
+	u.headers.setHeaders(req)
 	if u.storageClass != "" {
 		req.Header.Set("X-Amz-Storage-Class", u.storageClass)
 	}
+	setTaggingHeader(req, u.tags)
+	u.encryption.setHeaders(req)
 	q := req.URL.Query()
 	q.Set("uploads", "")
-
-	if u.version != "" {
-		q.Set("versionId", u.version)
-	}
 	req.URL.RawQuery = q.Encode()
 
 	return req, nil
@@ -91,6 +95,8 @@ func (u *bigFileCopier) createMultipartRequest(ctx context.Context, partNumber i
 
 	downloadByteRange := fmt.Sprintf("bytes=%d-%d", startOffset, endOffset)
 	req.Header.Set("x-amz-copy-source-range", downloadByteRange)
+	u.encryption.setHeaders(req)
+	u.sourceEncryption.setCopySourceHeaders(req)
 
 	return req, nil
 }
@@ -123,6 +129,7 @@ func (u *bigFileCopier) sendCompletionRequest(ctx context.Context, parts []compl
 	q := req.URL.Query()
 	q.Set("uploadId", uploadId)
 	req.URL.RawQuery = q.Encode()
+	u.conditions.setHeaders(req)
 
 	resp, err := SendRequestWithIgnoredHeaders(ctx, req, u.cfg, bigFileCopierExcludedHeaders)
 	if err != nil {
@@ -131,7 +138,7 @@ func (u *bigFileCopier) sendCompletionRequest(ctx context.Context, parts []compl
 
 	err = ExtractErr(resp, req)
 	if err != nil {
-		return err
+		return u.conditions.checkError(u.dst, err)
 	}
 
 	return nil
@@ -174,7 +181,7 @@ func (u *bigFileCopier) createPartSenderProcessor(cancel context.CancelCauseFunc
 	}
 }
 
-func (u *bigFileCopier) Copy(ctx context.Context) error {
+func (u *bigFileCopier) Copy(ctx context.Context) (err error) {
 	bigfileUploaderLogger().Debug("start")
 
 	name := "Preparing to copy " + u.src.String()
@@ -189,6 +196,16 @@ func (u *bigFileCopier) Copy(ctx context.Context) error {
 		return err
 	}
 
+	// Copies can't be resumed, the parts would be orphaned on any failure
+	defer func() {
+		if err != nil {
+			abortUpload(ctx, u.cfg, u.dst, uploadId)
+		}
+	}()
+
+	stopCancelOnInterrupt := cancelOnInterrupt(cancel)
+	defer stopCancelOnInterrupt()
+
 	chunkChan := pipeline.PrepareWriteChunks(ctx, nil, u.fileSize, int64(u.cfg.chunkSizeInBytes()))
 	partChan := pipeline.ParallelProcess(ctx, u.cfg.Workers, chunkChan, u.createPartSenderProcessor(cancel, uploadId), nil)
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path"
 
@@ -20,19 +21,17 @@ type bigFileDownloader struct {
 	version          string
 	fileSize         int64
 	progressReporter *progress_report.BytesReporter
+	encryption       SSECustomerKey
 }
 
 func (u *bigFileDownloader) createPartDownloaderProcessor(cancel context.CancelCauseFunc, cfg Config) pipeline.Processor[pipeline.WriteableChunk, error] {
 	return func(ctx context.Context, chunk pipeline.WriteableChunk) (error, pipeline.ProcessStatus) {
-		req, err := NewDownloadRequest(ctx, cfg, u.src, u.version)
+		req, err := newRangedDownloadRequest(ctx, cfg, u.src, u.version, u.encryption, fmt.Sprintf("%d-%d", chunk.StartOffset, chunk.EndOffset))
 		if err != nil {
 			cancel(err)
 			return err, pipeline.ProcessAbort
 		}
 
-		downloadByteRange := fmt.Sprintf("bytes=%d-%d", chunk.StartOffset, chunk.EndOffset)
-		req.Header.Set("Range", downloadByteRange)
-
 		resp, err := SendRequest(ctx, req, cfg)
 		if err != nil {
 			cancel(err)
@@ -45,7 +44,7 @@ func (u *bigFileDownloader) createPartDownloaderProcessor(cancel context.CancelC
 			return err, pipeline.ProcessAbort
 		}
 
-		reporterWriter := progress_report.NewReporterWriter(chunk.Writer, u.progressReporter.Report)
+		reporterWriter := progress_report.NewLimitedReporterWriter(ctx, chunk.Writer, u.progressReporter.Report, u.cfg.bandwidthLimiter())
 
 		_, err = io.Copy(reporterWriter, resp.Body)
 		if err != nil {
@@ -87,3 +86,19 @@ func (u *bigFileDownloader) Download(ctx context.Context) error {
 
 	return nil
 }
+
+// byteRange is in the format of the HTTP Range header without the unit: "start-end",
+// "start-" or "-suffixLength". If empty, the whole object is requested
+func newRangedDownloadRequest(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, version string, key SSECustomerKey, byteRange string) (*http.Request, error) {
+	req, err := NewDownloadRequest(ctx, cfg, src, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if byteRange != "" {
+		req.Header.Set("Range", "bytes="+byteRange)
+	}
+	key.setHeaders(req)
+
+	return req, nil
+}
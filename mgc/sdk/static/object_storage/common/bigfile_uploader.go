@@ -3,13 +3,20 @@ package common
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/fs"
 	"math"
 	"net/http"
+	"os"
+	"os/signal"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
 	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
@@ -25,6 +32,10 @@ func bigfileUploaderLogger() *zap.SugaredLogger {
 	return deleteBucketsLogger
 }
 
+const abortMultipartUploadTimeout = 30 * time.Second
+
+var errUploadInterrupted = errors.New("upload interrupted")
+
 type preparationResponse struct {
 	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
 	Bucket   string
@@ -35,6 +46,37 @@ type preparationResponse struct {
 type completionPart struct {
 	Etag       string `xml:",innerxml"`
 	PartNumber int
+	// local checksum of the part, used to verify the composite checksum of the object
+	sum []byte
+}
+
+// Adds the flexible checksum of the part, required by the server if the upload was created with one
+func (p completionPart) withChecksum(algorithm ChecksumAlgorithm, sum []byte) completionPart {
+	p.sum = sum
+	if name := algorithm.s3Name(); name != "" {
+		p.Etag += fmt.Sprintf("<Checksum%s>%s</Checksum%s>", name, algorithm.encode(sum), name)
+	}
+	return p
+}
+
+type completionResponse struct {
+	XMLName        xml.Name `xml:"CompleteMultipartUploadResult"`
+	ETag           string   `xml:"ETag"`
+	ChecksumCRC32C string   `xml:"ChecksumCRC32C"`
+	ChecksumSHA256 string   `xml:"ChecksumSHA256"`
+}
+
+func (r completionResponse) storedValue(algorithm ChecksumAlgorithm) string {
+	switch algorithm {
+	case ChecksumMD5:
+		return trimETag(r.ETag)
+	case ChecksumCRC32C:
+		return r.ChecksumCRC32C
+	case ChecksumSHA256:
+		return r.ChecksumSHA256
+	default:
+		return ""
+	}
 }
 
 func NewCompletionPart(partNumber int, etag string) completionPart {
@@ -56,12 +98,22 @@ type completionRequest struct {
 type bigFileUploader struct {
 	cfg          Config
 	dst          mgcSchemaPkg.URI
-	mimeType     string
+	headers      ObjectHeaders
 	fileInfo     fs.FileInfo
 	filePath     mgcSchemaPkg.FilePath
 	workerN      int
 	uploadId     string
 	storageClass string
+	tags         ObjectTags
+	encryption   SSECustomerKey
+	checksum     ChecksumAlgorithm
+	resume       bool
+	// Keep the upload when interrupted, instead of aborting it, see UploadOptions
+	keepOnInterrupt bool
+	conditions      WriteConditions
+	journal         *uploadJournal
+	// parts already uploaded by a previous, interrupted, execution
+	doneParts map[int]string
 }
 
 var _ uploader = (*bigFileUploader)(nil)
@@ -73,10 +125,16 @@ func (u *bigFileUploader) newPreparationRequest(ctx context.Context) (*http.Requ
 	}
 	req.Method = http.MethodPost
 	req.Header.Set("Content-Type", "application/octet-stream")
+	u.headers.setHeaders(req)
 
 	if u.storageClass != "" {
 		req.Header.Set("X-Amz-Storage-Class", u.storageClass)
 	}
+	setTaggingHeader(req, u.tags)
+	u.encryption.setHeaders(req)
+	if name := u.checksum.s3Name(); name != "" {
+		req.Header.Set(checksumAlgorithmHeader, name)
+	}
 
 	q := req.URL.Query()
 	q.Set("uploads", "")
@@ -121,7 +179,7 @@ func (u *bigFileUploader) createMultipartRequest(ctx context.Context, partNumber
 	q.Set("partNumber", fmt.Sprint(partNumber))
 	req.URL.RawQuery = q.Encode()
 
-	req.Header.Set("Content-Type", u.mimeType)
+	u.encryption.setHeaders(req)
 
 	return req, nil
 }
@@ -153,34 +211,71 @@ func (u *bigFileUploader) sendCompletionRequest(ctx context.Context, parts []com
 	q := req.URL.Query()
 	q.Set("uploadId", uploadId)
 	req.URL.RawQuery = q.Encode()
+	u.conditions.setHeaders(req)
 
 	resp, err := SendRequestWithIgnoredHeaders(ctx, req, u.cfg, bigFileCopierExcludedHeaders)
 	if err != nil {
 		return err
 	}
 
-	err = ExtractErr(resp, req)
+	if u.checksum == ChecksumNone {
+		return u.conditions.checkError(u.dst, ExtractErr(resp, req))
+	}
+
+	result, err := UnwrapResponse[completionResponse](resp, req)
 	if err != nil {
-		return err
+		return u.conditions.checkError(u.dst, err)
 	}
 
-	return nil
+	return u.verifyCompletion(parts, result)
+}
+
+func (u *bigFileUploader) verifyCompletion(parts []completionPart, result completionResponse) error {
+	if u.checksum == ChecksumMD5 && len(u.encryption) > 0 {
+		return nil
+	}
+
+	stored := result.storedValue(u.checksum)
+	if stored == "" {
+		bigfileUploaderLogger().Infow("server did not return the object checksum, only parts were verified", "dst", u.dst, "algorithm", u.checksum)
+		return nil
+	}
+
+	partSums := make([][]byte, len(parts))
+	for i, part := range parts {
+		partSums[i] = part.sum
+	}
+	return verifyChecksum(u.dst, u.checksum, 0, stored, u.checksum.composite(partSums))
 }
 
 func (u *bigFileUploader) createPartSenderProcessor(cancel context.CancelCauseFunc, totalParts int, uploadId string) pipeline.Processor[pipeline.ReadableChunk, completionPart] {
 	return func(ctx context.Context, chunk pipeline.ReadableChunk) (part completionPart, status pipeline.ProcessStatus) {
 		var err error
 
+		if chunk.Err != nil {
+			cancel(fmt.Errorf("error reading file: %w", chunk.Err))
+			return part, pipeline.ProcessAbort
+		}
+
 		newReader := func() (io.ReadCloser, error) {
 			return io.NopCloser(io.NewSectionReader(chunk.Reader, 0, int64(u.cfg.chunkSizeInBytes()))), nil
 		}
 
 		partNumber := int(chunk.StartOffset/int64(u.cfg.chunkSizeInBytes())) + 1
+		if etag, ok := u.doneParts[partNumber]; ok {
+			if u.checksum != ChecksumMD5 || len(u.encryption) > 0 || trimETag(etag) == hex.EncodeToString(chunk.Checksum) {
+				bigfileUploaderLogger().Debugw("Skipping part uploaded by previous execution", "part", partNumber, "total", totalParts)
+				return NewCompletionPart(partNumber, etag).withChecksum(u.checksum, chunk.Checksum), pipeline.ProcessOutput
+			}
+			bigfileUploaderLogger().Infow("Part uploaded by previous execution differs from the local content, sending it again", "part", partNumber)
+		}
+
 		req, err := u.createMultipartRequest(ctx, partNumber, newReader)
 		if err != nil {
 			cancel(err)
 			return part, pipeline.ProcessAbort
 		}
+		u.checksum.setHeaders(req, chunk.Checksum)
 
 		bigfileUploaderLogger().Debugw("Sending part", "part", partNumber, "total", totalParts)
 		res, err := SendRequest(ctx, req, u.cfg)
@@ -195,8 +290,114 @@ func (u *bigFileUploader) createPartSenderProcessor(cancel context.CancelCauseFu
 			return part, pipeline.ProcessAbort
 		}
 
-		return NewCompletionPart(partNumber, res.Header.Get("etag")), pipeline.ProcessOutput
+		if err = u.checksum.verifyResponse(u.dst, partNumber, res, chunk.Checksum, u.encryption); err != nil {
+			cancel(err)
+			return part, pipeline.ProcessAbort
+		}
+
+		etag := res.Header.Get("etag")
+		if err = u.journal.addPart(partNumber, etag); err != nil {
+			bigfileUploaderLogger().Warnw("failed to journal uploaded part, it won't be resumed", "part", partNumber, "error", err)
+		}
+
+		return NewCompletionPart(partNumber, etag).withChecksum(u.checksum, chunk.Checksum), pipeline.ProcessOutput
+	}
+}
+
+func trimETag(etag string) string {
+	return strings.Trim(etag, `"`)
+}
+
+// Loads the state of a previous interrupted upload of the same source to the same destination.
+//
+// Only parts that are still known by the server, with the same ETag, are considered done.
+// If the server doesn't know the upload anymore (completed or aborted), it starts over,
+// aborting the previous upload ID so its parts aren't orphaned.
+func (u *bigFileUploader) restoreState(ctx context.Context) {
+	state, ok := u.journal.load()
+	if !ok {
+		return
+	}
+
+	logger := bigfileUploaderLogger().With("uploadId", state.UploadId)
+	serverParts, err := ListParts(ctx, u.cfg, u.dst, state.UploadId)
+	if err != nil {
+		logger.Infow("previous upload can't be resumed, starting over", "error", err)
+		u.abort(ctx, state.UploadId)
+		return
+	}
+
+	done := make(map[int]string, len(serverParts))
+	for _, part := range serverParts {
+		if etag, ok := state.Parts[part.PartNumber]; ok && trimETag(etag) == trimETag(part.ETag) {
+			done[part.PartNumber] = etag
+		}
+	}
+
+	logger.Infow("resuming previous upload", "doneParts", len(done))
+	u.uploadId = state.UploadId
+	u.doneParts = done
+}
+
+// Whether the user interrupted the transfer, with SIGINT or by cancelling the context
+func isInterrupted(ctx context.Context) bool {
+	cause := context.Cause(ctx)
+	return errors.Is(cause, errUploadInterrupted) || errors.Is(cause, context.Canceled)
+}
+
+// Cancels the upload on SIGINT until stop() is called
+func cancelOnInterrupt(cancel context.CancelCauseFunc) (stop func()) {
+	signalChan := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signalChan, os.Interrupt)
+
+	go func() {
+		select {
+		case sig := <-signalChan:
+			cancel(fmt.Errorf("%w by signal: %v", errUploadInterrupted, sig))
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(signalChan)
+		close(done)
+	}
+}
+
+// Aborts the upload ID if the upload was interrupted by the user, so the parts won't be
+// orphaned. It's only kept, to be resumed by the next execution, if the user asked for it.
+// Other failures keep resumable uploads, since executing it again continues them
+func (u *bigFileUploader) abortIfInterrupted(ctx context.Context, uploadId string) {
+	if !isInterrupted(ctx) {
+		return
+	}
+
+	if u.journal != nil && u.keepOnInterrupt {
+		bigfileUploaderLogger().Infow("upload interrupted, execute it again to resume", "uploadId", uploadId, "dst", u.dst)
+		return
+	}
+
+	u.abort(ctx, uploadId)
+	u.journal.remove()
+}
+
+func (u *bigFileUploader) abort(ctx context.Context, uploadId string) {
+	abortUpload(ctx, u.cfg, u.dst, uploadId)
+}
+
+// Aborts the upload ID even if ctx is already cancelled, errors are only logged
+func abortUpload(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, uploadId string) {
+	logger := bigfileUploaderLogger().With("uploadId", uploadId, "dst", dst)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), abortMultipartUploadTimeout)
+	defer cancel()
+
+	if err := AbortMultipartUpload(ctx, cfg, dst, uploadId); err != nil {
+		logger.Warnw("failed to abort upload", "error", err)
+		return
 	}
+	logger.Infow("aborted upload")
 }
 
 func (u *bigFileUploader) Upload(ctx context.Context) error {
@@ -213,18 +414,41 @@ func (u *bigFileUploader) Upload(ctx context.Context) error {
 		cancel(err)
 	}()
 
+	if u.resume {
+		u.journal = newUploadJournal(ctx, u.filePath, u.fileInfo, u.dst, u.cfg.chunkSizeInBytes())
+		u.restoreState(ctx)
+	}
+
 	uploadId, err := u.getUploadId(ctx)
 	if err != nil {
 		return err
 	}
 
+	if err = u.journal.start(uploadId, u.doneParts); err != nil {
+		bigfileUploaderLogger().Warnw("failed to journal upload, it won't be resumed", "error", err)
+		u.journal = nil
+	}
+	defer func() {
+		if err := u.journal.flush(); err != nil {
+			bigfileUploaderLogger().Warnw("failed to journal uploaded parts, they won't be resumed", "error", err)
+		}
+	}()
+
 	reader, err := readContent(u.filePath, u.fileInfo)
 	if err != nil {
 		return fmt.Errorf("error reading file: %w", err)
 	}
 
+	stopCancelOnInterrupt := cancelOnInterrupt(cancel)
+	defer u.abortIfInterrupted(ctx, uploadId)
+	defer stopCancelOnInterrupt()
+
 	totalParts := int(math.Ceil(float64(u.fileInfo.Size()) / float64(u.cfg.chunkSizeInBytes())))
-	chunkChan := pipeline.ReadChunks(ctx, reader, u.fileInfo.Size(), int64(u.cfg.chunkSizeInBytes()))
+	var newHash func() hash.Hash
+	if u.checksum != ChecksumNone {
+		newHash = u.checksum.newHash
+	}
+	chunkChan := pipeline.ReadChunksWithHash(ctx, reader, u.fileInfo.Size(), int64(u.cfg.chunkSizeInBytes()), newHash)
 
 	partChan := pipeline.ParallelProcess(ctx, u.workerN, chunkChan, u.createPartSenderProcessor(cancel, totalParts, uploadId), nil)
 
@@ -233,5 +457,15 @@ func (u *bigFileUploader) Upload(ctx context.Context) error {
 		return err
 	}
 
-	return u.sendCompletionRequest(ctx, parts, uploadId)
+	if err = u.sendCompletionRequest(ctx, parts, uploadId); err != nil {
+		// Retrying won't help, the object must be checked again first
+		if errors.As(err, new(*PreconditionFailedError)) {
+			u.abort(ctx, uploadId)
+			u.journal.remove()
+		}
+		return err
+	}
+
+	u.journal.remove()
+	return nil
 }
@@ -0,0 +1,336 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"go.uber.org/zap"
+)
+
+const defaultBulkRetries = 2
+
+// Returned by the function given to BulkRun.Process() for items that were not processed on purpose
+var ErrSkipItem = errors.New("skip item")
+
+// Waited before the first retry of an item, doubled on each retry
+var bulkRetryDelay = 500 * time.Millisecond
+
+var bulkLogger = utils.NewLazyLoader(func() *zap.SugaredLogger {
+	return logger().Named("bulk")
+})
+
+// Options of the operations on many objects or files, such as copy-all and upload-dir
+type BulkParams struct {
+	ContinueOnError bool                  `json:"continue_on_error,omitempty" jsonschema_description:"Keep going when an item fails, after retrying it, instead of stopping at the first failure. The failed items are written to a manifest"`
+	Retries         *int                  `json:"retries,omitempty" jsonschema:"description=Times each failed item is retried with continue_on_error,default=2,minimum=0,maximum=10"`
+	Manifest        mgcSchemaPkg.FilePath `json:"manifest,omitempty" jsonschema_description:"File to write the failed items to, as JSON lines. If omitted, a file named after the operation is created in the current directory when items fail"`
+	FromManifest    mgcSchemaPkg.FilePath `json:"from_manifest,omitempty" jsonschema_description:"Process only the items of a manifest written by a previous execution with the same source and destination, instead of listing the source"`
+}
+
+func (p BulkParams) retries() int {
+	if !p.ContinueOnError {
+		return 0
+	}
+	if p.Retries == nil {
+		return defaultBulkRetries
+	}
+	return *p.Retries
+}
+
+// A failed item, written as a line of the manifest
+type ManifestEntry struct {
+	Operation string `json:"operation"`
+	// As listed from the source: the object key or the file path
+	Path        string `json:"path"`
+	Source      string `json:"src"`
+	Destination string `json:"dst,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Attempts    int    `json:"attempts,omitempty"`
+}
+
+type BulkSummary struct {
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Skipped   int    `json:"skipped"`
+	Manifest  string `json:"manifest,omitempty"`
+}
+
+func (s BulkSummary) String() string {
+	return fmt.Sprintf("%d succeeded, %d failed, %d skipped", s.Succeeded, s.Failed, s.Skipped)
+}
+
+// Returned when items failed with continue_on_error, once all the others were processed
+type BulkError struct {
+	Summary BulkSummary
+	Errors  utils.MultiError
+}
+
+func (e *BulkError) Error() string {
+	msg := fmt.Sprintf("%d items failed (%s)", e.Summary.Failed, e.Summary)
+	if e.Summary.Manifest != "" {
+		msg += fmt.Sprintf(", retry them with --from-manifest=%s", e.Summary.Manifest)
+	}
+	return msg + ": " + e.Errors.Error()
+}
+
+func (e *BulkError) Unwrap() []error {
+	return e.Errors
+}
+
+// Keeps track of the items of a bulk operation, safe to be used by all the workers.
+//
+// Without continue_on_error, the first failure cancels the operation, as done by cancel.
+// Otherwise, failed items are retried and, if they still fail, written to the manifest by Finish()
+type BulkRun struct {
+	operation string
+	params    BulkParams
+	cancel    context.CancelCauseFunc
+
+	mu      sync.Mutex
+	summary BulkSummary
+	failed  []ManifestEntry
+	errors  utils.MultiError
+}
+
+func NewBulkRun(operation string, params BulkParams, cancel context.CancelCauseFunc) (*BulkRun, error) {
+	if params.Retries != nil && (*params.Retries < 0 || *params.Retries > 10) {
+		return nil, core.UsageError{Err: fmt.Errorf("invalid retries %d, must be between 0 and 10", *params.Retries)}
+	}
+	return &BulkRun{operation: operation, params: params, cancel: cancel}, nil
+}
+
+func (r *BulkRun) ContinueOnError() bool {
+	return r.params.ContinueOnError
+}
+
+func isRetryable(err error) bool {
+	return !errors.Is(err, ErrSkipItem) &&
+		!errors.As(err, new(core.UsageError)) &&
+		!errors.As(err, new(*PreconditionFailedError)) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// Waits before the given retry, 1 for the first one. Fails if ctx is done first
+func (r *BulkRun) waitRetry(ctx context.Context, retry int) error {
+	timer := time.NewTimer(bulkRetryDelay << (retry - 1))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Calls fn until it succeeds or the retries are exhausted
+func (r *BulkRun) retry(ctx context.Context, fn func() error) (attempts int, err error) {
+	for {
+		attempts++
+		if err = fn(); err == nil || attempts > r.params.retries() || !isRetryable(err) || ctx.Err() != nil {
+			return attempts, err
+		}
+		bulkLogger().Infow("retrying failed item", "operation", r.operation, "attempt", attempts, "error", err)
+		if r.waitRetry(ctx, attempts) != nil {
+			return attempts, err
+		}
+	}
+}
+
+// Processes a single item with fn, entry identifies it in the manifest. Returns the error only
+// if the operation must stop
+func (r *BulkRun) Process(ctx context.Context, entry ManifestEntry, fn func() error) error {
+	attempts, err := r.retry(ctx, fn)
+	if errors.Is(err, ErrSkipItem) {
+		r.Skip()
+		return nil
+	}
+	if err != nil {
+		entry.Attempts = attempts
+		return r.Fail(ctx, entry, err)
+	}
+	r.Succeed(1)
+	return nil
+}
+
+func (r *BulkRun) Succeed(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.Succeeded += n
+}
+
+func (r *BulkRun) Skip() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.Skipped++
+}
+
+// Records the failed item. Returns the error, after cancelling the operation, if it must stop
+func (r *BulkRun) Fail(ctx context.Context, entry ManifestEntry, err error) error {
+	if ctx.Err() != nil && !r.params.ContinueOnError {
+		// Cancelled by an earlier failure, which is the one reported
+		return err
+	}
+
+	entry.Operation = r.operation
+	entry.Error = err.Error()
+	if entry.Attempts == 0 {
+		entry.Attempts = 1
+	}
+
+	r.mu.Lock()
+	r.summary.Failed++
+	r.failed = append(r.failed, entry)
+	r.errors = append(r.errors, err)
+	r.mu.Unlock()
+
+	if !r.params.ContinueOnError {
+		r.cancel(err)
+		return err
+	}
+	bulkLogger().Warnw("item failed, continuing", "operation", r.operation, "src", entry.Source, "attempts", entry.Attempts, "error", err)
+	return nil
+}
+
+// Writes the manifest if items failed with continue_on_error. err is the error that stopped
+// the operation, if any, such as a failure listing the source. Otherwise, the returned error is
+// the first failure or, with continue_on_error, a *BulkError
+func (r *BulkRun) Finish(err error) (BulkSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.params.ContinueOnError {
+		if len(r.errors) > 0 {
+			// err is only the cancellation caused by it
+			return r.summary, r.errors[0]
+		}
+		return r.summary, err
+	}
+
+	if len(r.failed) > 0 {
+		path := r.params.Manifest.String()
+		if path == "" {
+			path = fmt.Sprintf("%s-failures-%s.jsonl", r.operation, time.Now().UTC().Format("20060102T150405Z"))
+		}
+		if writeErr := writeManifest(path, r.failed); writeErr != nil {
+			r.errors = append(r.errors, fmt.Errorf("error writing manifest: %w", writeErr))
+		} else {
+			r.summary.Manifest = path
+		}
+	}
+
+	switch {
+	case err != nil:
+		return r.summary, err
+	case len(r.errors) > 0:
+		return r.summary, &BulkError{Summary: r.summary, Errors: r.errors}
+	default:
+		return r.summary, nil
+	}
+}
+
+func writeManifest(path string, entries []ManifestEntry) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, utils.FILE_PERMISSION)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err = encoder.Encode(entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Reads the manifest written by an execution of the same operation. The sources of all the
+// entries must be under root, so the manifest isn't used with another source by mistake
+func ReadManifest(path mgcSchemaPkg.FilePath, operation string, root string) ([]ManifestEntry, error) {
+	f, err := os.Open(path.String())
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	defer f.Close()
+
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	var entries []ManifestEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry ManifestEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, core.UsageError{Err: fmt.Errorf("invalid manifest %s, line %d: %w", path, line, err)}
+		}
+		if entry.Operation != operation {
+			return nil, core.UsageError{Err: fmt.Errorf("manifest %s was written by %s, not %s", path, entry.Operation, operation)}
+		}
+		if !strings.HasPrefix(entry.Source, prefix) {
+			return nil, core.UsageError{Err: fmt.Errorf("manifest %s is for another source: %s is not under %s", path, entry.Source, root)}
+		}
+		entries = append(entries, entry)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	return entries, nil
+}
+
+// Lists the objects of the manifest, as ListGenerator() would, with only their keys
+func ManifestObjectsGenerator(ctx context.Context, entries []ManifestEntry) <-chan pipeline.WalkDirEntry {
+	ch := make(chan pipeline.WalkDirEntry)
+	go func() {
+		defer close(ch)
+		for _, entry := range entries {
+			dirEntry := pipeline.NewSimpleWalkDirEntry(entry.Path, &BucketContent{Key: entry.Path}, nil)
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- dirEntry:
+			}
+		}
+	}()
+	return ch
+}
+
+// Lists the source objects: those of the manifest, if given, or all under src kept by the filters
+func ListBulkObjects(ctx context.Context, cfg Config, operation string, params BulkParams, src mgcSchemaPkg.URI, filters []FilterParams, cancel context.CancelCauseFunc, onNewPage func(uint64)) (<-chan pipeline.WalkDirEntry, error) {
+	if params.FromManifest != "" {
+		entries, err := ReadManifest(params.FromManifest, operation, NewBucketNameFromURI(src).AsURI().String())
+		if err != nil {
+			return nil, err
+		}
+		onNewPage(uint64(len(entries)))
+		return ManifestObjectsGenerator(ctx, entries), nil
+	}
+
+	listParams := ListObjectsParams{
+		Destination: src,
+		Recursive:   true,
+		PaginationParams: PaginationParams{
+			MaxItems: math.MaxInt64,
+		},
+	}
+	objs := ListGenerator(ctx, listParams, cfg, onNewPage)
+	return ApplyFilters(ctx, objs, filters, cancel), nil
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/progress_report"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+)
+
+var byteRangeRegex = regexp.MustCompile(`^(\d+-\d*|-\d+)$`)
+
+type CatObjectParams struct {
+	Source           mgcSchemaPkg.URI `json:"src" jsonschema:"description=Path of the object to be written to the standard output,example=bucket1/file.txt" mgc:"positional"`
+	Version          string           `json:"obj_version,omitempty" jsonschema:"description=Version of the object to be read"`
+	Range            string           `json:"range,omitempty" jsonschema_description:"Byte range to be read as 'start-end' or 'start-' (both inclusive and zero-based) or '-N' for the last N bytes" jsonschema:"example=0-1023"`
+	EncryptionParams `json:",squash"` // nolint
+}
+
+// Opens the object (or the given byte range of it) for reading, the caller must close it.
+//
+// The content is streamed from the response, it's never fully loaded in memory
+func OpenObject(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, version string, key SSECustomerKey, byteRange string) (io.ReadCloser, error) {
+	if byteRange != "" && !byteRangeRegex.MatchString(byteRange) {
+		return nil, core.UsageError{Err: fmt.Errorf("invalid range %q, expected 'start-end', 'start-' or '-N'", byteRange)}
+	}
+
+	if err := cfg.prepareBandwidthLimit(); err != nil {
+		return nil, err
+	}
+
+	req, err := newRangedDownloadRequest(ctx, cfg, src, version, key, byteRange)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := SendRequest(ctx, req, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ExtractErr(resp, req); err != nil {
+		return nil, err
+	}
+
+	if limiter := cfg.bandwidthLimiter(); limiter != nil {
+		return progress_report.NewLimitedReporterReader(ctx, resp.Body, nil, limiter), nil
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,270 @@
+package common
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"go.uber.org/zap"
+)
+
+type ChecksumAlgorithm string
+
+const (
+	ChecksumNone   ChecksumAlgorithm = ""
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+	ChecksumCRC32C ChecksumAlgorithm = "crc32c"
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+
+	checksumAlgorithmHeader = "X-Amz-Checksum-Algorithm"
+	checksumModeHeader      = "X-Amz-Checksum-Mode"
+)
+
+var checksumLogger = utils.NewLazyLoader(func() *zap.SugaredLogger {
+	return logger().Named("checksum")
+})
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+func (a ChecksumAlgorithm) validate() error {
+	switch a {
+	case ChecksumNone, ChecksumMD5, ChecksumCRC32C, ChecksumSHA256:
+		return nil
+	default:
+		return core.UsageError{Err: fmt.Errorf("invalid checksum algorithm %q", a)}
+	}
+}
+
+// Returns nil for ChecksumNone
+func (a ChecksumAlgorithm) newHash() hash.Hash {
+	switch a {
+	case ChecksumMD5:
+		return md5.New()
+	case ChecksumCRC32C:
+		return crc32.New(crc32cTable)
+	case ChecksumSHA256:
+		return sha256.New()
+	default:
+		return nil
+	}
+}
+
+// The value of x-amz-checksum-algorithm, MD5 is not a flexible checksum and uses Content-MD5 and the ETag instead
+func (a ChecksumAlgorithm) s3Name() string {
+	switch a {
+	case ChecksumCRC32C:
+		return "CRC32C"
+	case ChecksumSHA256:
+		return "SHA256"
+	default:
+		return ""
+	}
+}
+
+func (a ChecksumAlgorithm) header() string {
+	if name := a.s3Name(); name != "" {
+		return http.CanonicalHeaderKey("X-Amz-Checksum-" + name)
+	}
+	return ""
+}
+
+// ETags are hex encoded, flexible checksums are base64 encoded
+func (a ChecksumAlgorithm) encode(sum []byte) string {
+	if a == ChecksumMD5 {
+		return hex.EncodeToString(sum)
+	}
+	return base64.StdEncoding.EncodeToString(sum)
+}
+
+// Sets the checksum of the request payload, so the server verifies it on arrival
+func (a ChecksumAlgorithm) setHeaders(req *http.Request, sum []byte) {
+	switch a {
+	case ChecksumNone:
+	case ChecksumMD5:
+		req.Header.Set(contentMD5Header, base64.StdEncoding.EncodeToString(sum))
+	default:
+		req.Header.Set(a.header(), base64.StdEncoding.EncodeToString(sum))
+	}
+}
+
+// The value stored by the server for the given response, either the flexible checksum
+// or the ETag for MD5. Empty if not available
+func (a ChecksumAlgorithm) storedValue(resp *http.Response) string {
+	if a == ChecksumMD5 {
+		return trimETag(resp.Header.Get("ETag"))
+	}
+	return resp.Header.Get(a.header())
+}
+
+// Multipart objects have composite values: the checksum of the concatenated
+// part checksums, followed by "-" and the number of parts
+func (a ChecksumAlgorithm) composite(partSums [][]byte) string {
+	h := a.newHash()
+	for _, sum := range partSums {
+		h.Write(sum)
+	}
+	return fmt.Sprintf("%s-%d", a.encode(h.Sum(nil)), len(partSums))
+}
+
+func splitCompositeChecksum(value string) (checksum string, parts int) {
+	checksum, partsStr, found := strings.Cut(value, "-")
+	if !found {
+		return value, 0
+	}
+	parts, err := strconv.Atoi(partsStr)
+	if err != nil {
+		return value, 0
+	}
+	return checksum, parts
+}
+
+type ChecksumParams struct {
+	Checksum ChecksumAlgorithm `json:"checksum,omitempty" jsonschema_description:"Verify the integrity of the transferred data with this algorithm. Fails if the local and remote checksums differ" jsonschema:"enum=,enum=md5,enum=crc32c,enum=sha256,default="`
+}
+
+type ChecksumMismatchError struct {
+	Url       mgcSchemaPkg.URI
+	Algorithm ChecksumAlgorithm
+	// 0 for the whole object
+	PartNumber int
+	// Value stored by the server
+	Expected string
+	// Value computed from the local content
+	Actual string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	what := "object"
+	if e.PartNumber > 0 {
+		what = fmt.Sprintf("part %d", e.PartNumber)
+	}
+	return fmt.Sprintf("%s %s checksum mismatch for %s: expected %q, got %q", e.Url, e.Algorithm, what, e.Expected, e.Actual)
+}
+
+func verifyChecksum(url mgcSchemaPkg.URI, algorithm ChecksumAlgorithm, partNumber int, expected, actual string) error {
+	if expected == actual {
+		return nil
+	}
+	return &ChecksumMismatchError{Url: url, Algorithm: algorithm, PartNumber: partNumber, Expected: expected, Actual: actual}
+}
+
+// Verifies the checksum returned by the server after receiving content with the given sum.
+// Skipped if the server doesn't return one, or for MD5 of encrypted objects, as their ETag is not the MD5
+func (a ChecksumAlgorithm) verifyResponse(url mgcSchemaPkg.URI, partNumber int, resp *http.Response, sum []byte, key SSECustomerKey) error {
+	if a == ChecksumNone || (a == ChecksumMD5 && len(key) > 0) {
+		return nil
+	}
+	stored := a.storedValue(resp)
+	if stored == "" {
+		return nil
+	}
+	return verifyChecksum(url, a, partNumber, stored, a.encode(sum))
+}
+
+func hashReader(algorithm ChecksumAlgorithm, r io.Reader) ([]byte, error) {
+	h := algorithm.newHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func newChecksumHeadRequest(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, version string, key SSECustomerKey, partNumber int) (*http.Request, error) {
+	req, err := newHeadRequest(ctx, cfg, src, version, key)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(checksumModeHeader, "ENABLED")
+	if partNumber > 0 {
+		q := req.URL.Query()
+		q.Set("partNumber", strconv.Itoa(partNumber))
+		req.URL.RawQuery = q.Encode()
+	}
+	return req, nil
+}
+
+func headChecksum(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, version string, key SSECustomerKey, partNumber int) (*http.Response, error) {
+	req, err := newChecksumHeadRequest(ctx, cfg, src, version, key, partNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := SendRequest(ctx, req, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, ExtractErr(resp, req)
+}
+
+// Verifies the downloaded file against the checksum stored by the server, or the ETag for MD5.
+//
+// Multipart objects are verified part by part, the part size is taken from the first part
+func verifyDownloadedFile(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, version string, key SSECustomerKey, dst mgcSchemaPkg.FilePath, algorithm ChecksumAlgorithm) error {
+	resp, err := headChecksum(ctx, cfg, src, version, key, 0)
+	if err != nil {
+		return err
+	}
+
+	stored := algorithm.storedValue(resp)
+	if stored == "" {
+		return fmt.Errorf("unable to verify %s: object has no stored %s checksum", src, algorithm)
+	}
+	if algorithm == ChecksumMD5 && len(key) > 0 {
+		return fmt.Errorf("unable to verify %s: the ETag of objects encrypted with SSE-C is not their MD5", src)
+	}
+
+	file, err := os.Open(dst.String())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, parts := splitCompositeChecksum(stored)
+	if parts == 0 {
+		sum, err := hashReader(algorithm, file)
+		if err != nil {
+			return err
+		}
+		return verifyChecksum(src, algorithm, 0, stored, algorithm.encode(sum))
+	}
+
+	firstPart, err := headChecksum(ctx, cfg, src, version, key, 1)
+	if err != nil {
+		return fmt.Errorf("unable to get the part size of %s: %w", src, err)
+	}
+	partSize := firstPart.ContentLength
+	if partSize <= 0 {
+		return fmt.Errorf("unable to verify %s: unknown part size", src)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	partSums := make([][]byte, 0, parts)
+	for offset := int64(0); offset < stat.Size(); offset += partSize {
+		sum, err := hashReader(algorithm, io.NewSectionReader(file, offset, partSize))
+		if err != nil {
+			return err
+		}
+		partSums = append(partSums, sum)
+	}
+
+	checksumLogger().Debugw("verifying composite checksum", "src", src, "parts", parts, "partSize", partSize)
+	return verifyChecksum(src, algorithm, 0, stored, algorithm.composite(partSums))
+}
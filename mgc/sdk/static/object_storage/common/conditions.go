@@ -0,0 +1,77 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+)
+
+// Conditions on the current object at the destination for a write to happen, so concurrent
+// writers don't overwrite each other. The server answers 412 Precondition Failed otherwise,
+// returned as *PreconditionFailedError
+type WriteConditions struct {
+	IfMatch     string `json:"if_match,omitempty" jsonschema:"description=Only write if the ETag of the current object is this one"`
+	IfNoneMatch string `json:"if_none_match,omitempty" jsonschema:"description=Use * to only write if no object exists at the destination,enum=,enum=*,default="`
+}
+
+func (c WriteConditions) IsEmpty() bool {
+	return c.IfMatch == "" && c.IfNoneMatch == ""
+}
+
+func (c WriteConditions) validate() error {
+	if c.IfNoneMatch != "" && c.IfNoneMatch != "*" {
+		return core.UsageError{Err: fmt.Errorf("invalid if-none-match %q, only * is supported", c.IfNoneMatch)}
+	}
+	if c.IfMatch != "" && c.IfNoneMatch != "" {
+		return core.UsageError{Err: fmt.Errorf("if-match and if-none-match cannot be given together")}
+	}
+	return nil
+}
+
+func (c WriteConditions) setHeaders(req *http.Request) {
+	if c.IfMatch != "" {
+		req.Header.Set("If-Match", quoteETag(c.IfMatch))
+	}
+	if c.IfNoneMatch != "" {
+		req.Header.Set("If-None-Match", c.IfNoneMatch)
+	}
+}
+
+// ETags are sent quoted, as returned by the server, but are usually given without the quotes
+func quoteETag(etag string) string {
+	if etag == "*" || (len(etag) >= 2 && etag[0] == '"' && etag[len(etag)-1] == '"') {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// Returns a *PreconditionFailedError if err is the server rejecting the conditions
+func (c WriteConditions) checkError(dst mgcSchemaPkg.URI, err error) error {
+	var httpErr *mgcHttpPkg.HttpError
+	if !c.IsEmpty() && errors.As(err, &httpErr) && httpErr.Code == http.StatusPreconditionFailed {
+		return &PreconditionFailedError{Url: dst, Conditions: c, Err: err}
+	}
+	return err
+}
+
+// The object at Url doesn't match the conditions, it was changed or created by someone else
+type PreconditionFailedError struct {
+	Url        mgcSchemaPkg.URI
+	Conditions WriteConditions
+	Err        error
+}
+
+func (e *PreconditionFailedError) Error() string {
+	if e.Conditions.IfNoneMatch != "" {
+		return fmt.Sprintf("precondition failed: %s already exists", e.Url)
+	}
+	return fmt.Sprintf("precondition failed: the ETag of %s is no longer %s", e.Url, quoteETag(e.Conditions.IfMatch))
+}
+
+func (e *PreconditionFailedError) Unwrap() error {
+	return e.Err
+}
@@ -1,19 +1,39 @@
 package common
 
-import "github.com/MagaluCloud/magalu/mgc/core/config"
+import (
+	"context"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/auth"
+	"github.com/MagaluCloud/magalu/mgc/core/config"
+	"github.com/MagaluCloud/magalu/mgc/core/progress_report"
+)
 
 type Config struct {
 	Workers   int    `json:"workers,omitempty" jsonschema:"description=Number of routines that spawn to do parallel operations within object_storage,default=5,minimum=1,required"`
 	ChunkSize uint64 `json:"chunkSize,omitempty" jsonschema:"description=Chunk size to consider when doing multipart requests. Specified in Mb,default=8,minimum=8,maximum=5120,required"`
 	Region    string `json:"region,omitempty" jsonschema:"description=Region to reach the service,default=br-se1"`
+	// Such as 50MiB/s, see progress_report.ParseBandwidth()
+	BandwidthLimit string `json:"bandwidthLimit,omitempty" jsonschema_description:"Maximum transfer rate of uploads, downloads and copies between different endpoints shared by all workers (e.g. 50MiB/s). Server-side copies are not limited. Unlimited if empty"`
+	// Such as https://{bucket}.website.example.com, see WebsiteUrl()
+	WebsiteEndpoint string `json:"websiteEndpoint,omitempty" jsonschema_description:"Endpoint serving the buckets with static website hosting enabled, with {bucket} replaced by the bucket name (e.g. https://{bucket}.example.com). If empty, the endpoint of the region is used, unless serverUrl is set"`
 
 	// See more about the 'squash' directive here: https://pkg.go.dev/github.com/mitchellh/mapstructure#hdr-Embedded_Structs_and_Squashing
 	config.NetworkConfig `json:",squash"` // nolint
+
+	// Signs the requests instead of the key pair of the current authentication. Set for the
+	// source of copies given src_key_pair, see CopyEndpointParams
+	keyPair config.KeyPair
+	// Shared by the transfers of an execution, see WithBandwidthLimiter()
+	limiter *progress_report.BandwidthLimiter
 }
 
+// Lowered by tests, so multipart transfers don't need hundreds of megabytes
+var minChunkSize uint64 = MIN_CHUNK_SIZE
+
 func (c *Config) chunkSizeInBytes() uint64 {
-	if c.ChunkSize <= MIN_CHUNK_SIZE {
-		return MIN_CHUNK_SIZE
+	if c.ChunkSize <= minChunkSize {
+		return minChunkSize
 	}
 	if c.ChunkSize >= MAX_CHUNK_SIZE {
 		return MAX_CHUNK_SIZE
@@ -21,3 +41,44 @@ func (c *Config) chunkSizeInBytes() uint64 {
 
 	return c.ChunkSize * (1024 * 1024)
 }
+
+// Returns a config whose transfers share a new bandwidth limiter. Executors running transfers
+// in parallel, such as upload-dir or sync, call it once so the limit holds for all of them.
+// Otherwise each transfer gets its own limiter, see prepareBandwidthLimit()
+func (c Config) WithBandwidthLimiter() Config {
+	c.limiter = nil
+	if bytesPerSecond, err := progress_report.ParseBandwidth(c.BandwidthLimit); err == nil && bytesPerSecond > 0 {
+		c.limiter = progress_report.NewBandwidthLimiter(bytesPerSecond)
+	}
+	return c
+}
+
+func (c *Config) validateBandwidthLimit() error {
+	if _, err := progress_report.ParseBandwidth(c.BandwidthLimit); err != nil {
+		return core.UsageError{Err: err}
+	}
+	return nil
+}
+
+// Validates the limit and creates the limiter if the execution didn't, see WithBandwidthLimiter()
+func (c *Config) prepareBandwidthLimit() error {
+	if err := c.validateBandwidthLimit(); err != nil {
+		return err
+	}
+	if c.limiter == nil {
+		*c = c.WithBandwidthLimiter()
+	}
+	return nil
+}
+
+func (c *Config) accessKeyPair(ctx context.Context) (accessKeyId, secretAccessKey string) {
+	if c.keyPair.KeyID != "" {
+		return c.keyPair.KeyID, c.keyPair.KeySecret
+	}
+	return auth.FromContext(ctx).AccessKeyPair()
+}
+
+// Returns nil if the bandwidth is unlimited
+func (c *Config) bandwidthLimiter() *progress_report.BandwidthLimiter {
+	return c.limiter
+}
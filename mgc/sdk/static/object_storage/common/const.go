@@ -34,10 +34,16 @@ const (
 
 	templateUrl = "https://{{region}}.magaluobjects.com"
 
+	// Default of Config.WebsiteEndpoint, see WebsiteUrl()
+	websiteTemplateUrl = "https://{bucket}.website.{{region}}.magaluobjects.com"
+
 	unsignedPayloadHeader = "UNSIGNED-PAYLOAD"
 
 	URIPrefix = "s3://"
 
+	// Prefix of buckets and objects given as ARNs, as in policy resources and replication destinations
+	BucketARNPrefix = "arn:aws:s3:::"
+
 	MIN_CHUNK_SIZE = 1024 * 1024 * 200
 	MAX_CHUNK_SIZE = 1024 * 1024 * 1024 * 5
 
@@ -29,13 +29,59 @@ type CopyObjectParams struct {
 	Destination  mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Full destination path in the bucket with desired filename,example=bucket2/dir/file.txt" mgc:"positional"`
 	Version      string           `json:"obj_version,omitempty" jsonschema:"description=Version of the object to be copied"`
 	StorageClass string           `json:"storage_class,omitempty" jsonschema:"description=Copy objects to other storage classes,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
+	Tags         ObjectTags       `json:"tag,omitempty" jsonschema:"description=Tags to set on the copied object as key=value pairs. If omitted the source tags are kept"`
+	// If omitted, REPLACE is used when any of the headers or metadata is given, COPY otherwise
+	MetadataDirective          string           `json:"metadata_directive,omitempty" jsonschema_description:"COPY keeps the headers and metadata of the source object. REPLACE uses only the ones given. If omitted it is REPLACE when any header or metadata is given" jsonschema:"enum=,enum=COPY,enum=REPLACE,default="`
+	ObjectHeaders              `json:",squash"` // nolint
+	EncryptionParams           `json:",squash"` // nolint
+	CopySourceEncryptionParams `json:",squash"` // nolint
+	WriteConditions            `json:",squash"` // nolint
+	CopyEndpointParams         `json:",squash"` // nolint
 }
 
 type CopyAllObjectsParams struct {
-	Source       mgcSchemaPkg.URI `json:"src" jsonschema:"description=Path of objects in a bucket to be copied,example=bucket1" mgc:"positional"`
-	Destination  mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Full destination path in the bucket,example=bucket2/dir/" mgc:"positional"`
-	StorageClass string           `json:"storage_class,omitempty" jsonschema:"description=Copy objects to other storage classes,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
-	Filters      `json:",squash"` // nolint
+	Source             mgcSchemaPkg.URI `json:"src" jsonschema:"description=Path of objects in a bucket to be copied,example=bucket1" mgc:"positional"`
+	Destination        mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Full destination path in the bucket,example=bucket2/dir/" mgc:"positional"`
+	StorageClass       string           `json:"storage_class,omitempty" jsonschema:"description=Copy objects to other storage classes,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
+	Filters            `json:",squash"` // nolint
+	TagFilters         `json:",squash"` // nolint
+	BulkParams         `json:",squash"` // nolint
+	CopyEndpointParams `json:",squash"` // nolint
+}
+
+type CopyOptions struct {
+	StorageClass string
+	// Replaces the tags of the source object, if given
+	Tags ObjectTags
+	// Used only if MetadataDirective is REPLACE
+	Headers ObjectHeaders
+	// COPY, REPLACE or empty to infer it from Headers
+	MetadataDirective string
+	// Key to encrypt the destination object with SSE-C
+	Encryption SSECustomerKey
+	// Key to read the source object, if it's encrypted with SSE-C
+	SourceEncryption SSECustomerKey
+	// Conditions on the object being replaced at the destination
+	Conditions WriteConditions
+}
+
+func (o CopyOptions) metadataDirective() (string, error) {
+	switch o.MetadataDirective {
+	case "":
+		if o.Headers.IsEmpty() {
+			return MetadataDirectiveCopy, nil
+		}
+		return MetadataDirectiveReplace, nil
+	case MetadataDirectiveCopy:
+		if !o.Headers.IsEmpty() {
+			return "", core.UsageError{Err: fmt.Errorf("headers and metadata cannot be given with metadata directive %s", MetadataDirectiveCopy)}
+		}
+		return MetadataDirectiveCopy, nil
+	case MetadataDirectiveReplace:
+		return MetadataDirectiveReplace, o.Headers.validate()
+	default:
+		return "", core.UsageError{Err: fmt.Errorf("invalid metadata directive %q", o.MetadataDirective)}
+	}
 }
 
 type copier interface {
@@ -58,18 +104,16 @@ func newCopyRequest(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, dst m
 		return nil, core.UsageError{Err: fmt.Errorf("badly specified source URI: %w", err)}
 	}
 
-	req.Header.Set("x-amz-copy-source", copySource)
-
 	if version != "" {
-		query := req.URL.Query()
-		query.Set("versionId", version)
-		req.URL.RawQuery = query.Encode()
+		copySource += "?versionId=" + url.QueryEscape(version)
 	}
+	req.Header.Set("x-amz-copy-source", copySource)
 
 	return req, nil
 }
 
-func createObjectCopyProcessor(cfg Config, params CopyAllObjectsParams, progressReporter *progress_report.UnitsReporter) pipeline.Processor[pipeline.WalkDirEntry, error] {
+// Only errors listing the source are output, the copies are accounted by run
+func createObjectCopyProcessor(endpoints CopyEndpoints, params CopyAllObjectsParams, run *BulkRun, progressReporter *progress_report.UnitsReporter) pipeline.Processor[pipeline.WalkDirEntry, error] {
 	return func(ctx context.Context, dirEntry pipeline.WalkDirEntry) (error, pipeline.ProcessStatus) {
 		bucketName := NewBucketNameFromURI(params.Source)
 		rootURI := bucketName.AsURI()
@@ -86,36 +130,48 @@ func createObjectCopyProcessor(cfg Config, params CopyAllObjectsParams, progress
 
 		_, ok := dirEntry.DirEntry().(*BucketContent)
 		if !ok {
-			err = &ObjectError{Url: mgcSchemaPkg.URI(objURI), Err: fmt.Errorf("expected object, got directory")}
-			return err, pipeline.ProcessOutput
+			run.Skip()
+			return nil, pipeline.ProcessSkip
 		}
 
+		dst := params.Destination.JoinPath(path)
+		entry := ManifestEntry{Path: path, Source: objURI.String(), Destination: dst.String()}
 		copyAllLogger().Infow("Copying object", "uri", objURI)
-		err = CopySingleFile(ctx, cfg, objURI, params.Destination.JoinPath(dirEntry.Path()), params.StorageClass)
+		err = run.Process(ctx, entry, func() error {
+			if err := copySingleFileBetween(ctx, endpoints, objURI, dst, params.StorageClass); err != nil {
+				return &ObjectError{Url: mgcSchemaPkg.URI(objURI), Err: err}
+			}
+			return nil
+		})
 		if err != nil {
+			// The operation was cancelled with it by run
 			return err, pipeline.ProcessAbort
 		}
-
-		if err != nil {
-			err = &ObjectError{Url: mgcSchemaPkg.URI(objURI), Err: err}
-			return err, pipeline.ProcessOutput
-		}
-
-		return nil, pipeline.ProcessOutput
+		return nil, pipeline.ProcessSkip
 	}
 }
 
 func CopyMultipleFiles(ctx context.Context, cfg Config, params CopyAllObjectsParams) error {
+	_, err := CopyMultipleFilesWithSummary(ctx, cfg, params)
+	return err
+}
+
+// Same as CopyMultipleFiles(), also returning how many objects were copied, skipped or failed
+func CopyMultipleFilesWithSummary(ctx context.Context, cfg Config, params CopyAllObjectsParams) (BulkSummary, error) {
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(nil)
 
-	listParams := ListObjectsParams{
-		Destination: params.Source,
-		Recursive:   true,
-		PaginationParams: PaginationParams{
-			MaxItems: math.MaxInt64,
-		},
+	cfg = cfg.WithBandwidthLimiter()
+	endpoints, err := params.Endpoints(ctx, cfg)
+	if err != nil {
+		return BulkSummary{}, err
+	}
+
+	run, err := NewBulkRun("copy-all", params.BulkParams, cancel)
+	if err != nil {
+		return BulkSummary{}, err
 	}
+
 	progressReportMsg := fmt.Sprintf("Copying objects from %q to %q", params.Source, params.Destination)
 	progressReporter := progress_report.NewUnitsReporter(ctx, progressReportMsg, 0)
 	progressReporter.Start()
@@ -125,23 +181,27 @@ func CopyMultipleFiles(ctx context.Context, cfg Config, params CopyAllObjectsPar
 		progressReporter.Report(0, objCount, nil)
 	}
 
-	objs := ListGenerator(ctx, listParams, cfg, onNewPage)
-	objs = ApplyFilters(ctx, objs, params.FilterParams, cancel)
+	objs, err := ListBulkObjects(ctx, endpoints.Source, "copy-all", params.BulkParams, params.Source, params.FilterParams, cancel, onNewPage)
+	if err != nil {
+		return BulkSummary{}, err
+	}
+	if params.FromManifest == "" {
+		objs = ApplyTagFilters(ctx, endpoints.Source, NewBucketNameFromURI(params.Source), objs, params.FilterTags, cancel)
+	}
 
-	copyObjectsErrorChan := pipeline.ParallelProcess(ctx, cfg.Workers, objs, createObjectCopyProcessor(cfg, params, progressReporter), nil)
+	copyObjectsErrorChan := pipeline.ParallelProcess(ctx, cfg.Workers, objs, createObjectCopyProcessor(endpoints, params, run, progressReporter), nil)
 	copyObjectsErrorChan = pipeline.Filter(ctx, copyObjectsErrorChan, pipeline.FilterNonNil[error]{})
 
 	objErr, err := pipeline.SliceItemConsumer[utils.MultiError](ctx, copyObjectsErrorChan)
+	if err == nil && len(objErr) > 0 {
+		err = objErr
+	}
+
+	summary, err := run.Finish(err)
 	if err != nil {
 		progressReporter.Report(0, 0, err)
-		return err
 	}
-	if len(objErr) > 0 {
-		progressReporter.Report(0, 0, objErr)
-		return objErr
-	}
-
-	return nil
+	return summary, err
 }
 
 func CopySingleFile(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, dst mgcSchemaPkg.URI, storageClass string) error {
@@ -166,8 +226,16 @@ func CopySingleFile(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, dst m
 	return ExtractErr(resp, req)
 }
 
-func NewCopier(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, dst mgcSchemaPkg.URI, version string, storageClass string) (copier, error) {
-	metadata, err := HeadFile(ctx, cfg, src, version)
+func NewCopier(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, dst mgcSchemaPkg.URI, version string, opts CopyOptions) (copier, error) {
+	directive, err := opts.metadataDirective()
+	if err != nil {
+		return nil, err
+	}
+	if err = opts.Conditions.validate(); err != nil {
+		return nil, err
+	}
+
+	metadata, err := HeadFile(ctx, cfg, src, version, opts.SourceEncryption)
 	if err != nil {
 		return nil, err
 	}
@@ -175,20 +243,45 @@ func NewCopier(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, dst mgcSch
 	totalCopyParts := int(math.Ceil(float64(metadata.ContentLength) / float64(cfg.chunkSizeInBytes())))
 
 	if totalCopyParts > 1 {
+		// Multipart copies never carry the source headers, so they are copied explicitly
+		headers := opts.Headers
+		if directive == MetadataDirectiveCopy {
+			headers = metadata.Headers()
+		}
+		// Neither are the tags
+		tags := opts.Tags
+		if len(tags) == 0 {
+			if tags, err = getObjectTagsMap(ctx, cfg, src, version); err != nil {
+				return nil, err
+			}
+		}
 		return &bigFileCopier{
-			cfg:          cfg,
-			src:          src,
-			dst:          dst,
-			fileSize:     metadata.ContentLength,
-			totalParts:   totalCopyParts,
-			storageClass: storageClass,
+			cfg:              cfg,
+			src:              src,
+			dst:              dst,
+			fileSize:         metadata.ContentLength,
+			totalParts:       totalCopyParts,
+			version:          version,
+			storageClass:     opts.StorageClass,
+			tags:             tags,
+			headers:          headers,
+			encryption:       opts.Encryption,
+			sourceEncryption: opts.SourceEncryption,
+			conditions:       opts.Conditions,
 		}, nil
 	} else {
 		return &smallFileCopier{
-			cfg:          cfg,
-			src:          src,
-			dst:          dst,
-			storageClass: storageClass,
+			cfg:              cfg,
+			src:              src,
+			dst:              dst,
+			version:          version,
+			storageClass:     opts.StorageClass,
+			tags:             opts.Tags,
+			directive:        directive,
+			headers:          opts.Headers,
+			encryption:       opts.Encryption,
+			sourceEncryption: opts.SourceEncryption,
+			conditions:       opts.Conditions,
 		}, nil
 	}
 }
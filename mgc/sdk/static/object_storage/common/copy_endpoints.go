@@ -0,0 +1,159 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/config"
+	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+)
+
+type CopyEndpointParams struct {
+	SourceRegion      string `json:"src_region,omitempty" jsonschema_description:"Region of the source bucket. Defaults to the configured region. When the buckets are in different regions, the content is streamed through the client instead of being copied by the server" jsonschema:"example=br-ne1"`
+	DestinationRegion string `json:"dst_region,omitempty" jsonschema_description:"Region of the destination bucket. Defaults to the configured region, see src_region" jsonschema:"example=br-se1"`
+	SourceKeyPair     string `json:"src_key_pair,omitempty" jsonschema_description:"Name of a stored key pair to read the source with, when it belongs to another tenant. Defaults to the current credentials. The content is then streamed through the client as well"`
+}
+
+// Source and destination of a copy. Copies between different endpoints can't be done by the
+// server, so the content is downloaded from the source and uploaded to the destination
+type CopyEndpoints struct {
+	Source      Config
+	Destination Config
+}
+
+func (p CopyEndpointParams) Endpoints(ctx context.Context, cfg Config) (CopyEndpoints, error) {
+	endpoints := CopyEndpoints{Source: cfg, Destination: cfg}
+	if p.SourceRegion != "" {
+		endpoints.Source.Region = p.SourceRegion
+	}
+	if p.DestinationRegion != "" {
+		endpoints.Destination.Region = p.DestinationRegion
+	}
+
+	if p.SourceKeyPair != "" {
+		mgcConfig := config.FromContext(ctx)
+		if mgcConfig == nil {
+			return CopyEndpoints{}, fmt.Errorf("unable to retrieve key pair %q, no configuration in context", p.SourceKeyPair)
+		}
+		keyPair := mgcConfig.GetTempKeyPair(p.SourceKeyPair)
+		if keyPair == nil || keyPair.KeyID == "" {
+			return CopyEndpoints{}, core.UsageError{Err: fmt.Errorf("key pair %q not found", p.SourceKeyPair)}
+		}
+		endpoints.Source.keyPair = *keyPair
+	}
+	return endpoints, nil
+}
+
+// Whether the destination can read the source, so the server copies the content
+func (e CopyEndpoints) serverSide() bool {
+	return e.Source.Region == e.Destination.Region &&
+		BuildHost(e.Source) == BuildHost(e.Destination) &&
+		e.Source.keyPair == e.Destination.keyPair
+}
+
+// Copies the content through the client, without temporary files. The source is read as
+// it's uploaded, keeping at most the chunks of the stream uploader in memory
+type streamCopier struct {
+	endpoints CopyEndpoints
+	src       mgcSchemaPkg.URI
+	dst       mgcSchemaPkg.URI
+	version   string
+	headers   ObjectHeaders
+	opts      CopyOptions
+}
+
+var _ copier = (*streamCopier)(nil)
+
+func (c *streamCopier) Copy(ctx context.Context) error {
+	tags := c.opts.Tags
+	if len(tags) == 0 {
+		var err error
+		tags, err = getObjectTagsMap(ctx, c.endpoints.Source, c.src, c.version)
+		// Sources of other tenants may be readable while their tags aren't, they're copied without them
+		var httpErr *mgcHttpPkg.HttpError
+		if errors.As(err, &httpErr) && httpErr.Code == http.StatusForbidden && httpErr.Slug == "AccessDenied" {
+			logger().Warnw("not allowed to read the source tags, copying without them", "src", c.src, "error", err)
+			tags, err = nil, nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading source tags: %w", err)
+		}
+	}
+
+	body, err := OpenObject(ctx, c.endpoints.Source, c.src, c.version, c.opts.SourceEncryption, "")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// The download is already limited, limiting the upload as well would halve the bandwidth
+	dstCfg := c.endpoints.Destination
+	dstCfg.BandwidthLimit = ""
+	dstCfg.limiter = nil
+	uploader, err := NewStreamUploader(dstCfg, body, c.dst, UploadOptions{
+		StorageClass: c.opts.StorageClass,
+		Tags:         tags,
+		Headers:      c.headers,
+		Encryption:   c.opts.Encryption,
+		Conditions:   c.opts.Conditions,
+	})
+	if err != nil {
+		return err
+	}
+	return uploader.Upload(ctx)
+}
+
+// Same as NewCopier(), but the source is read from endpoints.Source
+func NewCopierBetween(ctx context.Context, endpoints CopyEndpoints, src mgcSchemaPkg.URI, dst mgcSchemaPkg.URI, version string, opts CopyOptions) (copier, error) {
+	if endpoints.serverSide() {
+		return NewCopier(ctx, endpoints.Destination, src, dst, version, opts)
+	}
+
+	if err := endpoints.Source.prepareBandwidthLimit(); err != nil {
+		return nil, err
+	}
+	directive, err := opts.metadataDirective()
+	if err != nil {
+		return nil, err
+	}
+	if err = opts.Conditions.validate(); err != nil {
+		return nil, err
+	}
+
+	headers := opts.Headers
+	if directive == MetadataDirectiveCopy {
+		metadata, err := HeadFile(ctx, endpoints.Source, src, version, opts.SourceEncryption)
+		if err != nil {
+			return nil, err
+		}
+		headers = metadata.Headers()
+	}
+
+	return &streamCopier{
+		endpoints: endpoints,
+		src:       src,
+		dst:       dst,
+		version:   version,
+		headers:   headers,
+		opts:      opts,
+	}, nil
+}
+
+func copySingleFileBetween(ctx context.Context, endpoints CopyEndpoints, src mgcSchemaPkg.URI, dst mgcSchemaPkg.URI, storageClass string) error {
+	if endpoints.serverSide() {
+		return CopySingleFile(ctx, endpoints.Destination, src, dst, storageClass)
+	}
+
+	if dst.IsRoot() {
+		dst = dst.JoinPath(src.Filename())
+	}
+	copier, err := NewCopierBetween(ctx, endpoints, src, dst, "", CopyOptions{StorageClass: storageClass})
+	if err != nil {
+		return err
+	}
+	return copier.Copy(ctx)
+}
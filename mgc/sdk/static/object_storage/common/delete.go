@@ -5,8 +5,8 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
-	"math"
 	"net/http"
+	"strings"
 
 	"github.com/MagaluCloud/magalu/mgc/core"
 	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
@@ -28,6 +28,7 @@ func deleteLogger() *zap.SugaredLogger {
 type DeleteObjectParams struct {
 	Destination mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the object to be deleted,example=bucket1/file.txt" mgc:"positional"`
 	Version     string           `json:"objVersion,omitempty" jsonschema:"description=Version of the object to be deleted"`
+	IfMatch     string           `json:"if_match,omitempty" jsonschema:"description=Only delete if the ETag of the object is this one"`
 }
 
 type DeleteBucketParams struct {
@@ -45,6 +46,8 @@ type DeleteAllObjectsInBucketParams struct {
 	BucketName BucketName       `json:"bucket" jsonschema:"description=Name of the bucket to delete objects from" mgc:"positional"`
 	BatchSize  int              `json:"batch_size,omitempty" jsonschema:"description=Limit of items per batch to delete,default=1000,minimum=1,maximum=1000,required" example:"1000"`
 	Filters    `json:",squash"` // nolint
+	TagFilters `json:",squash"` // nolint
+	BulkParams `json:",squash"` // nolint
 }
 
 func newDeleteRequest(ctx context.Context, cfg Config, params DeleteBucketParams) (*http.Request, error) {
@@ -75,6 +78,35 @@ type deleteBatchRequestBody struct {
 	Objects []objectIdentifier `xml:"Object"`
 }
 
+type deleteBatchError struct {
+	Key       string `xml:"Key"`
+	VersionId string `xml:"VersionId"`
+	Code      string `xml:"Code"`
+	Message   string `xml:"Message"`
+}
+
+func (e deleteBatchError) Error() string {
+	if e.VersionId != "" {
+		return fmt.Sprintf("%s (version %s): %s: %s", e.Key, e.VersionId, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Key, e.Code, e.Message)
+}
+
+type deleteBatchErrors []deleteBatchError
+
+func (e deleteBatchErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("failed to delete %d objects: %s", len(e), strings.Join(messages, "; "))
+}
+
+type deleteBatchResponse struct {
+	XMLName xml.Name          `xml:"DeleteResult"`
+	Errors  deleteBatchErrors `xml:"Error"`
+}
+
 func DeleteSingle(ctx context.Context, params DeleteObjectParams, cfg Config) error {
 	objectKey := params.Destination.AsFilePath().String()
 	versionID := params.Version
@@ -83,6 +115,8 @@ func DeleteSingle(ctx context.Context, params DeleteObjectParams, cfg Config) er
 	if err != nil {
 		return err
 	}
+	conditions := WriteConditions{IfMatch: params.IfMatch}
+	conditions.setHeaders(req)
 
 	resp, err := SendRequest(ctx, req, cfg)
 	if err != nil {
@@ -91,7 +125,7 @@ func DeleteSingle(ctx context.Context, params DeleteObjectParams, cfg Config) er
 
 	err = ExtractErr(resp, req)
 	if err != nil {
-		return err
+		return conditions.checkError(params.Destination, err)
 	}
 
 	return nil
@@ -157,14 +191,16 @@ func createObjectDeletionProcessor(cfg Config, bucketName BucketName, progressRe
 				return &ObjectError{Err: err}, pipeline.ProcessAbort
 			}
 
-			obj, ok := dirEntry.DirEntry().(*BucketContent)
-			if !ok {
+			switch obj := dirEntry.DirEntry().(type) {
+			case *BucketContent:
+				objIdentifiers = append(objIdentifiers, objectIdentifier{Key: obj.Key})
+			case *ObjectVersionEntry:
+				objIdentifiers = append(objIdentifiers, objectIdentifier{Key: obj.Key, VersionId: obj.VersionId})
+			default:
 				err = fmt.Errorf("expected object, got directory")
 				progressReporter.Report(0, 0, err)
 				return &ObjectError{Err: err}, pipeline.ProcessAbort
 			}
-
-			objIdentifiers = append(objIdentifiers, objectIdentifier{Key: obj.Key})
 		}
 
 		defer func() { progressReporter.Report(uint64(len(dirEntries)), 0, err) }()
@@ -179,27 +215,128 @@ func createObjectDeletionProcessor(cfg Config, bucketName BucketName, progressRe
 			return &ObjectError{Url: mgcSchemaPkg.URI(bucketName), Err: err}, pipeline.ProcessOutput
 		}
 
-		err = ExtractErr(resp, req)
+		result, err := UnwrapResponse[deleteBatchResponse](resp, req)
 		if err != nil {
 			return &ObjectError{Err: err}, pipeline.ProcessAbort
 		}
+		if len(result.Errors) > 0 {
+			// The batch is partially deleted, so keep going with the others
+			err = result.Errors
+			return &ObjectError{Url: mgcSchemaPkg.URI(bucketName), Err: err}, pipeline.ProcessOutput
+		}
 
 		deleteLogger().Infow("Deleted objects", "uri", URIPrefix+bucketName)
 		return nil, pipeline.ProcessOutput
 	}
 }
 
+// Deletes the objects in a single request, returning the error of each object not deleted
+func deleteBatch(ctx context.Context, cfg Config, bucketName BucketName, objKeys []objectIdentifier) map[string]error {
+	failAll := func(err error) map[string]error {
+		failed := make(map[string]error, len(objKeys))
+		for _, id := range objKeys {
+			failed[id.Key] = err
+		}
+		return failed
+	}
+
+	req, err := newDeleteBatchRequest(ctx, cfg, bucketName, objKeys)
+	if err != nil {
+		return failAll(err)
+	}
+	resp, err := SendRequest(ctx, req, cfg)
+	if err != nil {
+		return failAll(err)
+	}
+	result, err := UnwrapResponse[deleteBatchResponse](resp, req)
+	if err != nil {
+		return failAll(err)
+	}
+
+	failed := make(map[string]error, len(result.Errors))
+	for _, batchErr := range result.Errors {
+		failed[batchErr.Key] = batchErr
+	}
+	return failed
+}
+
+// Like createObjectDeletionProcessor(), but the objects that fail are retried on their own and
+// accounted by run. Only errors listing the objects are output
+func createBulkDeletionProcessor(cfg Config, bucketName BucketName, run *BulkRun, progressReporter *progress_report.UnitsReporter) pipeline.Processor[[]pipeline.WalkDirEntry, error] {
+	return func(ctx context.Context, dirEntries []pipeline.WalkDirEntry) (error, pipeline.ProcessStatus) {
+		progressReporter.Report(0, uint64(len(dirEntries)), nil)
+		defer func() { progressReporter.Report(uint64(len(dirEntries)), 0, nil) }()
+
+		var objKeys []objectIdentifier
+		for _, dirEntry := range dirEntries {
+			if err := dirEntry.Err(); err != nil {
+				err = &ObjectError{Err: err}
+				if !run.ContinueOnError() {
+					// The objects left to delete are unknown, so stop right away
+					run.cancel(err)
+					return err, pipeline.ProcessAbort
+				}
+				return err, pipeline.ProcessOutput
+			}
+			obj, ok := dirEntry.DirEntry().(*BucketContent)
+			if !ok {
+				run.Skip()
+				continue
+			}
+			objKeys = append(objKeys, objectIdentifier{Key: obj.Key})
+		}
+		if len(objKeys) == 0 {
+			return nil, pipeline.ProcessSkip
+		}
+
+		total := len(objKeys)
+		var failed map[string]error
+		attempts, _ := run.retry(ctx, func() error {
+			failed = deleteBatch(ctx, cfg, bucketName, objKeys)
+			// Only the failed objects are retried
+			pending := objKeys[:0]
+			for _, id := range objKeys {
+				if _, ok := failed[id.Key]; ok {
+					pending = append(pending, id)
+				}
+			}
+			objKeys = pending
+			if len(objKeys) > 0 {
+				return failed[objKeys[0].Key]
+			}
+			return nil
+		})
+		run.Succeed(total - len(objKeys))
+
+		rootURI := bucketName.AsURI()
+		for _, id := range objKeys {
+			objURI := rootURI.JoinPath(id.Key)
+			entry := ManifestEntry{Path: id.Key, Source: objURI.String(), Attempts: attempts}
+			if err := run.Fail(ctx, entry, &ObjectError{Url: objURI, Err: failed[id.Key]}); err != nil {
+				break
+			}
+		}
+		deleteLogger().Infow("Deleted objects", "uri", rootURI, "count", total-len(objKeys))
+		return nil, pipeline.ProcessSkip
+	}
+}
+
 func DeleteAllObjectsInBucket(ctx context.Context, params DeleteAllObjectsInBucketParams, cfg Config) error {
+	_, err := DeleteAllObjectsInBucketWithSummary(ctx, params, cfg)
+	return err
+}
+
+// Same as DeleteAllObjectsInBucket(), also returning how many objects were deleted, skipped or failed
+func DeleteAllObjectsInBucketWithSummary(ctx context.Context, params DeleteAllObjectsInBucketParams, cfg Config) (BulkSummary, error) {
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(nil)
 
-	dst := params.BucketName.AsURI()
-	listParams := ListObjectsParams{
-		Destination: dst,
-		Recursive:   true,
-		PaginationParams: PaginationParams{
-			MaxItems: math.MaxInt64,
-		},
+	if params.BatchSize < MinBatchSize || params.BatchSize > MaxBatchSize {
+		return BulkSummary{}, core.UsageError{Err: fmt.Errorf("invalid item limit per request BatchSize, must not be lower than %d and must not be higher than %d: %d", MinBatchSize, MaxBatchSize, params.BatchSize)}
+	}
+	run, err := NewBulkRun("delete-all", params.BulkParams, cancel)
+	if err != nil {
+		return BulkSummary{}, err
 	}
 
 	progressReportMsg := fmt.Sprintf("Deleting objects from %q", params.BucketName)
@@ -211,26 +348,24 @@ func DeleteAllObjectsInBucket(ctx context.Context, params DeleteAllObjectsInBuck
 		progressReporter.Report(0, objCount, nil)
 	}
 
-	objs := ListGenerator(ctx, listParams, cfg, onNewPage)
-	objs = ApplyFilters(ctx, objs, params.FilterParams, cancel)
-
-	if params.BatchSize < MinBatchSize || params.BatchSize > MaxBatchSize {
-		return core.UsageError{Err: fmt.Errorf("invalid item limit per request BatchSize, must not be lower than %d and must not be higher than %d: %d", MinBatchSize, MaxBatchSize, params.BatchSize)}
+	objs, err := ListBulkObjects(ctx, cfg, "delete-all", params.BulkParams, params.BucketName.AsURI(), params.FilterParams, cancel, onNewPage)
+	if err != nil {
+		return BulkSummary{}, err
+	}
+	if params.FromManifest == "" {
+		objs = ApplyTagFilters(ctx, cfg, params.BucketName, objs, params.FilterTags, cancel)
 	}
 
 	objsBatch := pipeline.Batch(ctx, objs, params.BatchSize)
-	deleteObjectsErrorChan := pipeline.ParallelProcess(ctx, cfg.Workers, objsBatch, createObjectDeletionProcessor(cfg, params.BucketName, progressReporter), nil)
+	deleteObjectsErrorChan := pipeline.ParallelProcess(ctx, cfg.Workers, objsBatch, createBulkDeletionProcessor(cfg, params.BucketName, run, progressReporter), nil)
 	deleteObjectsErrorChan = pipeline.Filter(ctx, deleteObjectsErrorChan, pipeline.FilterNonNil[error]{})
 
 	objErr, err := pipeline.SliceItemConsumer[utils.MultiError](ctx, deleteObjectsErrorChan)
-	if err != nil {
-		return err
-	}
-	if len(objErr) > 0 {
-		return objErr
+	if err == nil && len(objErr) > 0 {
+		err = objErr
 	}
 
-	return nil
+	return run.Finish(err)
 }
 
 func DeleteBucket(ctx context.Context, params DeleteBucketParams, cfg Config) error {
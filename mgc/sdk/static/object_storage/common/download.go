@@ -2,12 +2,14 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
 	"os"
 	"path"
+	"path/filepath"
 
 	"github.com/MagaluCloud/magalu/mgc/core"
 	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
@@ -15,15 +17,58 @@ import (
 )
 
 type DownloadObjectParams struct {
-	Source      mgcSchemaPkg.URI      `json:"src" jsonschema:"description=Path of the object to be downloaded,example=bucket1/file.txt" mgc:"positional"`
-	Destination mgcSchemaPkg.FilePath `json:"dst,omitempty" jsonschema:"description=Path and file name to be saved (relative or absolute).If not specified it defaults to the current working directory,example=file.txt" mgc:"positional"`
-	Version     string                `json:"obj_version,omitempty" jsonschema:"description=Version of the object to be downloaded"`
+	Source                  mgcSchemaPkg.URI      `json:"src" jsonschema:"description=Path of the object to be downloaded,example=bucket1/file.txt" mgc:"positional"`
+	Destination             mgcSchemaPkg.FilePath `json:"dst,omitempty" jsonschema:"description=Path and file name to be saved (relative or absolute).If not specified it defaults to the current working directory,example=file.txt" mgc:"positional"`
+	Version                 string                `json:"obj_version,omitempty" jsonschema:"description=Version of the object to be downloaded"`
+	EncryptionParams        `json:",squash"`      // nolint
+	ChecksumParams          `json:",squash"`      // nolint
+	RestoreAttributesParams `json:",squash"`      // nolint
+}
+
+type DownloadOptions struct {
+	Encryption SSECustomerKey
+	// Verify the downloaded file against the checksum stored by the server
+	Checksum ChecksumAlgorithm
+	// Restore the file attributes stored as metadata, see FileAttributesFromMetadata()
+	RestoreAttributes bool
+	// Recreate links stored as links, only if RestoreAttributes is set
+	RestoreSymlinks bool
+	// Restore the owner and group, only if RestoreAttributes is set
+	RestoreOwner bool
+	// Local directory the restored links must stay within and that is never written through a link,
+	// the directory of the destination if empty
+	Root mgcSchemaPkg.FilePath
 }
 
 type downloader interface {
 	Download(context.Context) error
 }
 
+type checksumDownloader struct {
+	downloader
+	cfg      Config
+	src      mgcSchemaPkg.URI
+	dst      mgcSchemaPkg.FilePath
+	version  string
+	key      SSECustomerKey
+	checksum ChecksumAlgorithm
+}
+
+func (d *checksumDownloader) Download(ctx context.Context) error {
+	if err := d.downloader.Download(ctx); err != nil {
+		return err
+	}
+	err := verifyDownloadedFile(ctx, d.cfg, d.src, d.version, d.key, d.dst, d.checksum)
+	// Corrupt content must not be left behind as if it was the object
+	var mismatch *ChecksumMismatchError
+	if errors.As(err, &mismatch) {
+		if removeErr := os.Remove(d.dst.String()); removeErr != nil {
+			return utils.MultiError{err, fmt.Errorf("unable to remove the corrupt file %s: %w", d.dst, removeErr)}
+		}
+	}
+	return err
+}
+
 func NewDownloadRequest(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, version string) (*http.Request, error) {
 	host, err := BuildBucketHostWithPath(cfg, NewBucketNameFromURI(src), src.Path())
 	if err != nil {
@@ -60,29 +105,78 @@ func WriteToFile(ctx context.Context, reader io.ReadCloser, fileSize int64, outF
 	return nil
 }
 
-func NewDownloader(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, dst mgcSchemaPkg.FilePath, version string) (downloader, error) {
-	metadata, err := HeadFile(ctx, cfg, src, version)
+func NewDownloader(ctx context.Context, cfg Config, src mgcSchemaPkg.URI, dst mgcSchemaPkg.FilePath, version string, opts DownloadOptions) (downloader, error) {
+	if err := cfg.prepareBandwidthLimit(); err != nil {
+		return nil, err
+	}
+	if err := opts.Checksum.validate(); err != nil {
+		return nil, err
+	}
+
+	metadata, err := HeadFile(ctx, cfg, src, version, opts.Encryption)
 	if err != nil {
 		return nil, err
 	}
 
+	var attrs FileAttributes
+	restore := false
+	if opts.RestoreAttributes {
+		attrs, restore = FileAttributesFromMetadata(metadata.Metadata)
+	}
+	if opts.RestoreSymlinks {
+		root := opts.Root
+		if root == "" {
+			root = mgcSchemaPkg.FilePath(filepath.Dir(dst.String()))
+		}
+		if err = checkNoSymlinkParents(root, dst); err != nil {
+			return nil, err
+		}
+		if restore && attrs.IsSymlink() {
+			return &symlinkDownloader{dst: dst, root: root, attrs: attrs, owner: opts.RestoreOwner}, nil
+		}
+	}
+	// Without restoring links, the empty object stored for a link is downloaded as an empty file
+	attrs.SymlinkTarget = ""
+
+	d := newDownloader(cfg, src, dst, version, opts, metadata)
+	if opts.Checksum != ChecksumNone {
+		d = &checksumDownloader{
+			downloader: d,
+			cfg:        cfg,
+			src:        src,
+			dst:        dst,
+			version:    version,
+			key:        opts.Encryption,
+			checksum:   opts.Checksum,
+		}
+	}
+	if restore {
+		d = &attributesDownloader{downloader: d, dst: dst, attrs: attrs, owner: opts.RestoreOwner}
+	}
+	return d, nil
+}
+
+func newDownloader(cfg Config, src mgcSchemaPkg.URI, dst mgcSchemaPkg.FilePath, version string, opts DownloadOptions, metadata HeadObjectResponse) downloader {
+
 	totalDownloadParts := int(math.Ceil(float64(metadata.ContentLength) / float64(cfg.chunkSizeInBytes())))
 
 	if totalDownloadParts > 1 {
 		return &bigFileDownloader{
-			cfg:      cfg,
-			src:      src,
-			dst:      dst,
-			fileSize: metadata.ContentLength,
-			version:  version,
-		}, nil
+			cfg:        cfg,
+			src:        src,
+			dst:        dst,
+			fileSize:   metadata.ContentLength,
+			version:    version,
+			encryption: opts.Encryption,
+		}
 	} else {
 		return &smallFileDownloader{
-			cfg:     cfg,
-			src:     src,
-			dst:     dst,
-			version: version,
-		}, nil
+			cfg:        cfg,
+			src:        src,
+			dst:        dst,
+			version:    version,
+			encryption: opts.Encryption,
+		}
 	}
 }
 
@@ -0,0 +1,8 @@
+package common
+
+// Sets the smallest chunk of multipart transfers until the returned function is called
+func SetMinChunkSize(size uint64) (restore func()) {
+	previous := minChunkSize
+	minChunkSize = size
+	return func() { minChunkSize = previous }
+}
@@ -0,0 +1,280 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+)
+
+// How symbolic links are uploaded
+const (
+	// Upload the file or directory the link points to
+	SymlinksFollow = "follow"
+	// Don't upload links at all
+	SymlinksSkip = "skip"
+	// Upload an empty object with the link target as metadata, downloaded back as a link
+	SymlinksStore = "store"
+)
+
+// User metadata keys of the file attributes, as sent in x-amz-meta-* headers
+const (
+	attributeModTimeKey = "mtime"
+	attributeModeKey    = "mode"
+	attributeUidKey     = "uid"
+	attributeGidKey     = "gid"
+	attributeSymlinkKey = "symlink-target"
+)
+
+type FileAttributesParams struct {
+	PreserveAttributes *bool  `json:"preserve_attributes,omitempty" jsonschema_description:"Store the modification time, permissions, owner and group of the files as object metadata" jsonschema:"default=true"`
+	Symlinks           string `json:"symlinks,omitempty" jsonschema:"description=How symbolic links are uploaded: following them or skipping them or storing them as links,enum=follow,enum=skip,enum=store,default=follow"`
+}
+
+type RestoreAttributesParams struct {
+	RestoreAttributes *bool `json:"restore_attributes,omitempty" jsonschema_description:"Restore the modification time and permissions stored as object metadata" jsonschema:"default=true"`
+	RestoreSymlinks   bool  `json:"restore_symlinks,omitempty" jsonschema_description:"Recreate the objects stored as symbolic links as links, as long as they point within the destination. Otherwise they are downloaded as empty files" jsonschema:"default=false"`
+	RestoreOwner      bool  `json:"restore_owner,omitempty" jsonschema_description:"Restore the owner and group stored as object metadata. Only applies when running as root" jsonschema:"default=false"`
+}
+
+func (p FileAttributesParams) Preserve() bool {
+	return p.PreserveAttributes == nil || *p.PreserveAttributes
+}
+
+func (p RestoreAttributesParams) Restore() bool {
+	return p.RestoreAttributes == nil || *p.RestoreAttributes
+}
+
+// Download options restoring what was asked, root is the local directory the restored links must stay within
+func (p RestoreAttributesParams) DownloadOptions(root mgcSchemaPkg.FilePath) DownloadOptions {
+	return DownloadOptions{
+		RestoreAttributes: p.Restore(),
+		RestoreSymlinks:   p.Restore() && p.RestoreSymlinks,
+		RestoreOwner:      p.Restore() && p.RestoreOwner,
+		Root:              root,
+	}
+}
+
+// POSIX attributes of a local file, stored as user metadata of its object
+type FileAttributes struct {
+	ModTime time.Time
+	// Permission bits only
+	Mode fs.FileMode
+	// -1 if unknown, such as on Windows
+	Uid int
+	Gid int
+	// Set only for symbolic links stored as links
+	SymlinkTarget string
+}
+
+func (a FileAttributes) IsSymlink() bool {
+	return a.SymlinkTarget != ""
+}
+
+// Reads the attributes of the local file. skip is true if it's a symbolic link that must not be uploaded.
+//
+// With SymlinksFollow, the attributes are those of the file the link points to
+func ReadFileAttributes(path string, symlinks string) (attrs FileAttributes, skip bool, err error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return attrs, false, err
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		switch symlinks {
+		case SymlinksSkip:
+			return attrs, true, nil
+		case SymlinksStore:
+			if attrs.SymlinkTarget, err = os.Readlink(path); err != nil {
+				return attrs, false, err
+			}
+		default:
+			if info, err = os.Stat(path); err != nil {
+				return attrs, false, err
+			}
+		}
+	}
+
+	attrs.ModTime = info.ModTime()
+	attrs.Mode = info.Mode().Perm()
+	attrs.Uid, attrs.Gid = fileOwner(info)
+	return attrs, false, nil
+}
+
+func ValidateSymlinks(symlinks string) error {
+	switch symlinks {
+	case "", SymlinksFollow, SymlinksSkip, SymlinksStore:
+		return nil
+	default:
+		return fmt.Errorf("invalid symlinks %q, expected %s, %s or %s", symlinks, SymlinksFollow, SymlinksSkip, SymlinksStore)
+	}
+}
+
+// Adds the attributes to the given user metadata. Keys already present are kept
+func (a FileAttributes) AddToMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	add := func(key, value string) {
+		if _, ok := metadata[key]; !ok {
+			metadata[key] = value
+		}
+	}
+
+	if !a.ModTime.IsZero() {
+		add(attributeModTimeKey, a.ModTime.UTC().Format(time.RFC3339Nano))
+	}
+	if a.Mode != 0 {
+		add(attributeModeKey, fmt.Sprintf("%04o", uint32(a.Mode)))
+	}
+	if a.Uid >= 0 {
+		add(attributeUidKey, strconv.Itoa(a.Uid))
+	}
+	if a.Gid >= 0 {
+		add(attributeGidKey, strconv.Itoa(a.Gid))
+	}
+	if a.SymlinkTarget != "" {
+		add(attributeSymlinkKey, a.SymlinkTarget)
+	}
+	return metadata
+}
+
+// Parses the attributes stored by AddToMetadata(). ok is false if there are none.
+// Invalid values are ignored, as the metadata may have been edited by others
+func FileAttributesFromMetadata(metadata map[string]string) (attrs FileAttributes, ok bool) {
+	attrs.Uid, attrs.Gid = -1, -1
+
+	if value, found := metadata[attributeModTimeKey]; found {
+		if modTime, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			attrs.ModTime, ok = modTime, true
+		}
+	}
+	if value, found := metadata[attributeModeKey]; found {
+		if mode, err := strconv.ParseUint(value, 8, 32); err == nil {
+			attrs.Mode, ok = fs.FileMode(mode).Perm(), true
+		}
+	}
+	if value, found := metadata[attributeUidKey]; found {
+		if uid, err := strconv.Atoi(value); err == nil {
+			attrs.Uid, ok = uid, true
+		}
+	}
+	if value, found := metadata[attributeGidKey]; found {
+		if gid, err := strconv.Atoi(value); err == nil {
+			attrs.Gid, ok = gid, true
+		}
+	}
+	if value, found := metadata[attributeSymlinkKey]; found && value != "" {
+		attrs.SymlinkTarget, ok = value, true
+	}
+	return attrs, ok
+}
+
+// Applies the attributes to the local file. The owner is only changed if asked and when running
+// as root, as others can't give files away
+func (a FileAttributes) apply(path string, owner bool) error {
+	if owner && (a.Uid >= 0 || a.Gid >= 0) && os.Geteuid() == 0 {
+		if err := os.Lchown(path, a.Uid, a.Gid); err != nil {
+			return err
+		}
+	}
+	if a.IsSymlink() {
+		// Links have no permissions of their own and os.Chtimes() would follow them
+		return nil
+	}
+	if a.Mode != 0 {
+		if err := os.Chmod(path, a.Mode); err != nil {
+			return err
+		}
+	}
+	if !a.ModTime.IsZero() {
+		return os.Chtimes(path, time.Time{}, a.ModTime)
+	}
+	return nil
+}
+
+// Applies the attributes once the download is done
+type attributesDownloader struct {
+	downloader
+	dst   mgcSchemaPkg.FilePath
+	attrs FileAttributes
+	owner bool
+}
+
+func (d *attributesDownloader) Download(ctx context.Context) error {
+	if err := d.downloader.Download(ctx); err != nil {
+		return err
+	}
+	return d.attrs.apply(d.dst.String(), d.owner)
+}
+
+// Creates a symbolic link instead of downloading the empty object stored for it
+type symlinkDownloader struct {
+	dst   mgcSchemaPkg.FilePath
+	root  mgcSchemaPkg.FilePath
+	attrs FileAttributes
+	owner bool
+}
+
+func (d *symlinkDownloader) Download(ctx context.Context) error {
+	path := d.dst.String()
+	if err := d.validateTarget(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), utils.DIR_PERMISSION); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Symlink(d.attrs.SymlinkTarget, path); err != nil {
+		return err
+	}
+	return d.attrs.apply(path, d.owner)
+}
+
+// The target comes from the object metadata, which anyone able to write to the bucket controls,
+// so the link must not point outside of the destination
+func (d *symlinkDownloader) validateTarget() error {
+	target := d.attrs.SymlinkTarget
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("refusing to restore %s as a link to the absolute path %q", d.dst, target)
+	}
+	resolved := filepath.Join(filepath.Dir(d.dst.String()), target)
+	if rel, err := filepath.Rel(d.root.String(), resolved); err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("refusing to restore %s as a link to %q, outside of %s", d.dst, target, d.root)
+	}
+	return nil
+}
+
+// Returns an error if any directory between root and path is a symbolic link, so that files
+// are never written through links restored by earlier downloads
+func checkNoSymlinkParents(root mgcSchemaPkg.FilePath, path mgcSchemaPkg.FilePath) error {
+	rel, err := filepath.Rel(root.String(), filepath.Dir(path.String()))
+	if err != nil || rel == "." {
+		return err
+	}
+	dir := root.String()
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		dir = filepath.Join(dir, name)
+		info, err := os.Lstat(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to write %s through the symbolic link %s", path, dir)
+		}
+	}
+	return nil
+}
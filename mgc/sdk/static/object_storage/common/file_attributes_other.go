@@ -0,0 +1,10 @@
+//go:build !unix
+
+package common
+
+import "io/fs"
+
+// Files have no numeric owner outside of Unix
+func fileOwner(info fs.FileInfo) (uid, gid int) {
+	return -1, -1
+}
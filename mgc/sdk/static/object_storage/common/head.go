@@ -10,15 +10,32 @@ import (
 )
 
 type HeadObjectResponse struct {
-	AcceptRanges  string
-	LastModified  string
-	ContentLength int64
-	ETag          string
-	ContentType   string
-	StorageClass  string
+	AcceptRanges       string
+	LastModified       string
+	ContentLength      int64
+	ETag               string
+	ContentType        string
+	StorageClass       string
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	// PENDING, COMPLETED or FAILED for objects being replicated, REPLICA for replicas
+	ReplicationStatus string `json:",omitempty"`
+	// User defined metadata, sent as x-amz-meta-* headers
+	Metadata map[string]string `json:",omitempty"`
 }
 
-func newHeadRequest(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, version string) (*http.Request, error) {
+func (m HeadObjectResponse) Headers() ObjectHeaders {
+	return ObjectHeaders{
+		ContentType:        m.ContentType,
+		CacheControl:       m.CacheControl,
+		ContentDisposition: m.ContentDisposition,
+		ContentEncoding:    m.ContentEncoding,
+		Metadata:           m.Metadata,
+	}
+}
+
+func newHeadRequest(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, version string, key SSECustomerKey) (*http.Request, error) {
 	host, err := BuildBucketHostWithPath(cfg, NewBucketNameFromURI(dst), dst.Path())
 	if err != nil {
 		return nil, core.UsageError{Err: err}
@@ -34,12 +51,14 @@ func newHeadRequest(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, versi
 		query.Set("versionId", version)
 		req.URL.RawQuery = query.Encode()
 	}
+	key.setHeaders(req)
 
 	return req, nil
 }
 
-func HeadFile(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, version string) (metadata HeadObjectResponse, err error) {
-	req, err := newHeadRequest(ctx, cfg, dst, version)
+// key is required to read the metadata of objects encrypted with SSE-C, it may be nil
+func HeadFile(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, version string, key SSECustomerKey) (metadata HeadObjectResponse, err error) {
+	req, err := newHeadRequest(ctx, cfg, dst, version, key)
 	if err != nil {
 		return
 	}
@@ -76,13 +95,19 @@ func getMetadataFromResponse(resp *http.Response) (HeadObjectResponse, error) {
 		return HeadObjectResponse{}, err
 	}
 
+	headers := getObjectHeadersFromResponse(resp)
 	metadata := HeadObjectResponse{
-		AcceptRanges:  resp.Header.Get("Accept-Ranges"),
-		LastModified:  resp.Header.Get("Last-Modified"),
-		ContentLength: contentLength,
-		ETag:          resp.Header.Get("ETag"),
-		ContentType:   resp.Header.Get("Content-Type"),
-		StorageClass:  resp.Header.Get("x-amz-storage-class"),
+		AcceptRanges:       resp.Header.Get("Accept-Ranges"),
+		LastModified:       resp.Header.Get("Last-Modified"),
+		ContentLength:      contentLength,
+		ETag:               resp.Header.Get("ETag"),
+		ContentType:        headers.ContentType,
+		StorageClass:       resp.Header.Get("x-amz-storage-class"),
+		ReplicationStatus:  resp.Header.Get("x-amz-replication-status"),
+		CacheControl:       headers.CacheControl,
+		ContentDisposition: headers.ContentDisposition,
+		ContentEncoding:    headers.ContentEncoding,
+		Metadata:           headers.Metadata,
 	}
 
 	return metadata, nil
@@ -0,0 +1,82 @@
+package common_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+// Cancels the context when the second part is sent, as if the user interrupted the transfer
+type interruptingTransport struct {
+	cancel context.CancelFunc
+}
+
+func (t *interruptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPut && req.URL.Query().Get("partNumber") == "2" {
+		t.cancel()
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func interruptibleContext(server *s3test.Server) context.Context {
+	ctx, cancel := context.WithCancel(server.Context(context.Background()))
+	return mgcHttpPkg.NewClientContext(ctx, mgcHttpPkg.NewClient(&interruptingTransport{cancel}))
+}
+
+func TestInterruptedUploadIsAborted(t *testing.T) {
+	defer common.SetMinChunkSize(testChunkSize)()
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	cfg := server.Config()
+	src := writeTempFile(t, randomData(t, 3*testChunkSize+1))
+
+	for _, tc := range []struct {
+		name            string
+		keepOnInterrupt bool
+		uploads         int
+	}{
+		{name: "default", uploads: 0},
+		{name: "keep", keepOnInterrupt: true, uploads: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			uploader, err := common.NewUploader(cfg, src, mgcSchemaPkg.URI("s3://bucket/"+tc.name), common.UploadOptions{Resume: true, KeepOnInterrupt: tc.keepOnInterrupt})
+			if err != nil {
+				t.Fatalf("NewUploader() failed: %s", err)
+			}
+			if err = uploader.Upload(interruptibleContext(server)); err == nil {
+				t.Fatal("expected the interrupted upload to fail")
+			}
+			if n := server.UploadCount("bucket"); n != tc.uploads {
+				t.Errorf("expected %d uploads left, got %d", tc.uploads, n)
+			}
+		})
+	}
+}
+
+func TestInterruptedCopyIsAborted(t *testing.T) {
+	defer common.SetMinChunkSize(testChunkSize)()
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	cfg := server.Config()
+	server.PutObject("bucket", "big", randomData(t, 3*testChunkSize+1))
+
+	ctx := interruptibleContext(server)
+	copier, err := common.NewCopier(ctx, cfg, "s3://bucket/big", "s3://bucket/copy", "", common.CopyOptions{})
+	if err != nil {
+		t.Fatalf("NewCopier() failed: %s", err)
+	}
+	if err = copier.Copy(ctx); err == nil {
+		t.Fatal("expected the interrupted copy to fail")
+	}
+	if n := server.CountRequests(http.MethodDelete, "uploadId"); n != 1 {
+		t.Errorf("expected the copy upload to be aborted, got %d aborts", n)
+	}
+	if n := server.UploadCount("bucket"); n != 0 {
+		t.Errorf("expected no uploads left, got %d", n)
+	}
+}
@@ -0,0 +1,220 @@
+package common
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+)
+
+type UploadPart struct {
+	PartNumber   int    `xml:"PartNumber" json:"part_number"`
+	ETag         string `xml:"ETag" json:"etag"`
+	Size         int64  `xml:"Size" json:"size"`
+	LastModified string `xml:"LastModified" json:"last_modified"`
+}
+
+type listPartsResponse struct {
+	XMLName              xml.Name     `xml:"ListPartsResult"`
+	Bucket               string       `xml:"Bucket"`
+	Key                  string       `xml:"Key"`
+	UploadId             string       `xml:"UploadId"`
+	NextPartNumberMarker int          `xml:"NextPartNumberMarker"`
+	IsTruncated          bool         `xml:"IsTruncated"`
+	Parts                []UploadPart `xml:"Part"`
+}
+
+func newListPartsRequest(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, uploadId string, partNumberMarker int) (*http.Request, error) {
+	host, err := BuildBucketHostWithPath(cfg, NewBucketNameFromURI(dst), dst.Path())
+	if err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, string(host), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Set("uploadId", uploadId)
+	if partNumberMarker > 0 {
+		q.Set("part-number-marker", fmt.Sprint(partNumberMarker))
+	}
+	req.URL.RawQuery = q.Encode()
+
+	return req, nil
+}
+
+// List all the parts already uploaded to the given multipart upload, following the pages
+func ListParts(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, uploadId string) (parts []UploadPart, err error) {
+	marker := 0
+	for {
+		req, err := newListPartsRequest(ctx, cfg, dst, uploadId, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := SendRequest(ctx, req, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := UnwrapResponse[listPartsResponse](resp, req)
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, result.Parts...)
+		if !result.IsTruncated || result.NextPartNumberMarker <= marker {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+type MultipartUpload struct {
+	Key          string `xml:"Key" json:"key"`
+	UploadId     string `xml:"UploadId" json:"upload_id"`
+	Initiated    string `xml:"Initiated" json:"initiated"`
+	StorageClass string `xml:"StorageClass" json:"storage_class"`
+}
+
+func (u *MultipartUpload) InitiatedTime() (time.Time, error) {
+	return time.Parse(time.RFC3339, u.Initiated)
+}
+
+type listMultipartUploadsResponse struct {
+	XMLName            xml.Name          `xml:"ListMultipartUploadsResult"`
+	Bucket             string            `xml:"Bucket"`
+	NextKeyMarker      string            `xml:"NextKeyMarker"`
+	NextUploadIdMarker string            `xml:"NextUploadIdMarker"`
+	IsTruncated        bool              `xml:"IsTruncated"`
+	Uploads            []MultipartUpload `xml:"Upload"`
+}
+
+func newListMultipartUploadsRequest(ctx context.Context, cfg Config, bucketName BucketName, prefix, keyMarker, uploadIdMarker string) (*http.Request, error) {
+	url, err := BuildBucketHostURL(cfg, bucketName)
+	if err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+
+	q := url.Query()
+	q.Set("uploads", "")
+	if prefix != "" {
+		q.Set("prefix", prefix)
+	}
+	if keyMarker != "" {
+		q.Set("key-marker", keyMarker)
+	}
+	if uploadIdMarker != "" {
+		q.Set("upload-id-marker", uploadIdMarker)
+	}
+	url.RawQuery = q.Encode()
+
+	return http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+}
+
+// List all the incomplete multipart uploads in the bucket, optionally filtered by key prefix, following the pages
+func ListMultipartUploads(ctx context.Context, cfg Config, bucketName BucketName, prefix string) (uploads []MultipartUpload, err error) {
+	keyMarker, uploadIdMarker := "", ""
+	for {
+		req, err := newListMultipartUploadsRequest(ctx, cfg, bucketName, prefix, keyMarker, uploadIdMarker)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := SendRequest(ctx, req, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := UnwrapResponse[listMultipartUploadsResponse](resp, req)
+		if err != nil {
+			return nil, err
+		}
+
+		uploads = append(uploads, result.Uploads...)
+		if !result.IsTruncated || (result.NextKeyMarker == keyMarker && result.NextUploadIdMarker == uploadIdMarker) {
+			return uploads, nil
+		}
+		keyMarker, uploadIdMarker = result.NextKeyMarker, result.NextUploadIdMarker
+	}
+}
+
+func newAbortMultipartUploadRequest(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, uploadId string) (*http.Request, error) {
+	host, err := BuildBucketHostWithPath(cfg, NewBucketNameFromURI(dst), dst.Path())
+	if err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, string(host), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Set("uploadId", uploadId)
+	req.URL.RawQuery = q.Encode()
+
+	return req, nil
+}
+
+// Abort the multipart upload, the server discards all the parts already uploaded
+func AbortMultipartUpload(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, uploadId string) error {
+	req, err := newAbortMultipartUploadRequest(ctx, cfg, dst, uploadId)
+	if err != nil {
+		return err
+	}
+
+	resp, err := SendRequest(ctx, req, cfg)
+	if err != nil {
+		return err
+	}
+
+	return ExtractErr(resp, req)
+}
+
+func newCreateMultipartUploadRequest(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, storageClass string) (*http.Request, error) {
+	host, err := BuildBucketHostWithPath(cfg, NewBucketNameFromURI(dst), dst.Path())
+	if err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, string(host), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if storageClass != "" {
+		req.Header.Set("X-Amz-Storage-Class", storageClass)
+	}
+
+	q := req.URL.Query()
+	q.Set("uploads", "")
+	req.URL.RawQuery = q.Encode()
+
+	return req, nil
+}
+
+// Start a multipart upload whose parts are sent by someone else, such as with presigned URLs
+func CreateMultipartUpload(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, storageClass string) (uploadId string, err error) {
+	req, err := newCreateMultipartUploadRequest(ctx, cfg, dst, storageClass)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := SendRequest(ctx, req, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := UnwrapResponse[preparationResponse](resp, req)
+	if err != nil {
+		return "", err
+	}
+	return result.UploadId, nil
+}
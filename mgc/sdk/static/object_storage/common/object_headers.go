@@ -0,0 +1,109 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+)
+
+const (
+	userMetadataHeaderPrefix = "X-Amz-Meta-"
+	metadataDirectiveHeader  = "X-Amz-Metadata-Directive"
+
+	MetadataDirectiveCopy    = "COPY"
+	MetadataDirectiveReplace = "REPLACE"
+
+	// http.DetectContentType considers at most the first 512 bytes
+	contentSniffLen = 512
+)
+
+// Standard HTTP headers and user metadata (x-amz-meta-*) stored with the object
+type ObjectHeaders struct {
+	ContentType        string            `json:"content_type,omitempty" jsonschema:"description=Content-Type of the object,example=text/plain"`
+	CacheControl       string            `json:"cache_control,omitempty" jsonschema:"description=Cache-Control of the object,example=max-age=3600"`
+	ContentDisposition string            `json:"content_disposition,omitempty" jsonschema:"description=Content-Disposition of the object,example=attachment"`
+	ContentEncoding    string            `json:"content_encoding,omitempty" jsonschema:"description=Content-Encoding of the object,example=gzip"`
+	Metadata           map[string]string `json:"metadata,omitempty" jsonschema:"description=Custom metadata as key=value pairs. Sent as x-amz-meta-* headers"`
+}
+
+func (h ObjectHeaders) IsEmpty() bool {
+	return h.ContentType == "" &&
+		h.CacheControl == "" &&
+		h.ContentDisposition == "" &&
+		h.ContentEncoding == "" &&
+		len(h.Metadata) == 0
+}
+
+func (h ObjectHeaders) validate() error {
+	for k := range h.Metadata {
+		if k == "" || strings.ContainsAny(k, " \t\r\n:") {
+			return core.UsageError{Err: fmt.Errorf("invalid metadata key %q", k)}
+		}
+	}
+	return nil
+}
+
+func (h ObjectHeaders) setHeaders(req *http.Request) {
+	if h.ContentType != "" {
+		req.Header.Set("Content-Type", h.ContentType)
+	}
+	if h.CacheControl != "" {
+		req.Header.Set("Cache-Control", h.CacheControl)
+	}
+	if h.ContentDisposition != "" {
+		req.Header.Set("Content-Disposition", h.ContentDisposition)
+	}
+	if h.ContentEncoding != "" {
+		req.Header.Set("Content-Encoding", h.ContentEncoding)
+	}
+	for k, v := range h.Metadata {
+		req.Header.Set(userMetadataHeaderPrefix+k, v)
+	}
+}
+
+func getObjectHeadersFromResponse(resp *http.Response) ObjectHeaders {
+	headers := ObjectHeaders{
+		ContentType:        resp.Header.Get("Content-Type"),
+		CacheControl:       resp.Header.Get("Cache-Control"),
+		ContentDisposition: resp.Header.Get("Content-Disposition"),
+		ContentEncoding:    resp.Header.Get("Content-Encoding"),
+	}
+
+	for k, v := range resp.Header {
+		// resp.Header keys are canonical, user metadata keys are lowercase as S3 stores them
+		if len(v) == 0 || !strings.HasPrefix(k, userMetadataHeaderPrefix) {
+			continue
+		}
+		if headers.Metadata == nil {
+			headers.Metadata = map[string]string{}
+		}
+		headers.Metadata[strings.ToLower(strings.TrimPrefix(k, userMetadataHeaderPrefix))] = v[0]
+	}
+
+	return headers
+}
+
+// Detects the content type from the file extension, falling back to sniffing the first bytes of the content
+func detectContentType(name string, newReader func() (io.ReadCloser, error)) string {
+	if mimeType := mime.TypeByExtension(filepath.Ext(name)); mimeType != "" {
+		return mimeType
+	}
+
+	reader, err := newReader()
+	if err != nil {
+		return ""
+	}
+	defer reader.Close()
+
+	buf := make([]byte, contentSniffLen)
+	n, _ := io.ReadFull(reader, buf)
+	if n == 0 {
+		return ""
+	}
+	return http.DetectContentType(buf[:n])
+}
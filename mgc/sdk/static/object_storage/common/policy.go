@@ -0,0 +1,229 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+)
+
+const (
+	PolicyEffectAllow = "Allow"
+	PolicyEffectDeny  = "Deny"
+)
+
+// Bucket policy document, in the JSON format sent to and returned by the server
+type PolicyDocument struct {
+	Version   string            `json:"Version,omitempty"`
+	Id        string            `json:"Id,omitempty"`
+	Statement []PolicyStatement `json:"Statement"`
+}
+
+// Gets the policy of the bucket, as accepted by the server. If it has none, the error is the
+// one of the server, with the NoSuchBucketPolicy code
+func GetBucketPolicy(ctx context.Context, cfg Config, name BucketName) (*PolicyDocument, error) {
+	url, err := BuildBucketHostURL(cfg, name)
+	if err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+	query := url.Query()
+	query.Add("policy", "")
+	url.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := SendRequest(ctx, req, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err = ExtractErr(res, req); err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	document := &PolicyDocument{}
+	if err = json.Unmarshal(data, document); err != nil {
+		return nil, fmt.Errorf("invalid policy document of bucket %s: %w", name, err)
+	}
+	return document, nil
+}
+
+// A single statement is accepted without the list around it
+func (d *PolicyDocument) UnmarshalJSON(data []byte) error {
+	type document PolicyDocument
+	var raw struct {
+		document
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*d = PolicyDocument(raw.document)
+	d.Statement = nil
+	if len(raw.Statement) == 0 {
+		return nil
+	}
+	if raw.Statement[0] == '{' {
+		var statement PolicyStatement
+		if err := json.Unmarshal(raw.Statement, &statement); err != nil {
+			return err
+		}
+		d.Statement = []PolicyStatement{statement}
+		return nil
+	}
+	return json.Unmarshal(raw.Statement, &d.Statement)
+}
+
+type PolicyStatement struct {
+	Sid       string                             `json:"Sid,omitempty"`
+	Effect    string                             `json:"Effect"`
+	Principal *PolicyPrincipal                   `json:"Principal,omitempty"`
+	Action    StringOrList                       `json:"Action,omitempty"`
+	NotAction StringOrList                       `json:"NotAction,omitempty"`
+	Resource  StringOrList                       `json:"Resource,omitempty"`
+	Condition map[string]map[string]StringOrList `json:"Condition,omitempty"`
+}
+
+// Whether the statement applies to the action, such as s3:GetObject, regardless of the principal
+// and resource. Actions are matched ignoring case, as the server does
+func (s PolicyStatement) MatchesAction(action string) bool {
+	action = strings.ToLower(action)
+	matches := func(patterns StringOrList) bool {
+		for _, pattern := range patterns {
+			if matchPolicyPattern(strings.ToLower(pattern), action) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(s.NotAction) > 0 {
+		return !matches(s.NotAction)
+	}
+	return matches(s.Action)
+}
+
+// Who a statement applies to: "*" for everyone, or the IDs by kind, such as {"AWS": ["..."]}
+type PolicyPrincipal struct {
+	Everyone bool
+	IDs      map[string]StringOrList
+}
+
+// Whether anyone, even without credentials, is a principal
+func (p PolicyPrincipal) IsPublic() bool {
+	if p.Everyone {
+		return true
+	}
+	for _, ids := range p.IDs {
+		for _, id := range ids {
+			if id == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p PolicyPrincipal) MarshalJSON() ([]byte, error) {
+	if p.Everyone {
+		return json.Marshal("*")
+	}
+	return json.Marshal(p.IDs)
+}
+
+func (p *PolicyPrincipal) UnmarshalJSON(data []byte) error {
+	var everyone string
+	if err := json.Unmarshal(data, &everyone); err == nil {
+		if everyone != "*" {
+			return fmt.Errorf("invalid principal %q, expected \"*\" or an object", everyone)
+		}
+		*p = PolicyPrincipal{Everyone: true}
+		return nil
+	}
+	*p = PolicyPrincipal{}
+	return json.Unmarshal(data, &p.IDs)
+}
+
+// A list of strings, which is also accepted as a single string. Numbers and booleans, as used
+// in condition values, are kept as their JSON text. Marshaled as a string if it has a single
+// item, as most documents are written
+type StringOrList []string
+
+func (l StringOrList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]string(l))
+}
+
+func (l *StringOrList) UnmarshalJSON(data []byte) error {
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		list = []json.RawMessage{data}
+	}
+
+	*l = make(StringOrList, 0, len(list))
+	for _, item := range list {
+		var s string
+		if err := json.Unmarshal(item, &s); err == nil {
+			*l = append(*l, s)
+			continue
+		}
+		var scalar any
+		if err := json.Unmarshal(item, &scalar); err != nil {
+			return err
+		}
+		switch scalar.(type) {
+		case bool, float64:
+			*l = append(*l, strings.TrimSpace(string(item)))
+		default:
+			return fmt.Errorf("expected a string or a list of strings, got %s", item)
+		}
+	}
+	return nil
+}
+
+// Whether any of the patterns, which may have * and ? wildcards, matches value
+func (l StringOrList) MatchesAny(value string) bool {
+	for _, pattern := range l {
+		if matchPolicyPattern(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// * matches any sequence of characters, including '/', and ? matches a single one
+func matchPolicyPattern(pattern, value string) bool {
+	// Position of the last * and of the value when it was reached, to backtrack to
+	star, starValue := -1, 0
+	p, v := 0, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			star, starValue = p, v
+			p++
+		case star >= 0:
+			starValue++
+			p, v = star+1, starValue
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
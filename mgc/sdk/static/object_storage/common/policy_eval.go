@@ -0,0 +1,153 @@
+package common
+
+import (
+	"strings"
+)
+
+const (
+	PolicyDecisionAllow        = "allow"
+	PolicyDecisionDeny         = "deny"
+	PolicyDecisionImplicitDeny = "implicit_deny"
+)
+
+// A request checked against a policy. An empty Principal is an anonymous request, and Context
+// has the values of condition keys, such as aws:SourceIp
+type PolicyRequest struct {
+	Principal string
+	Action    string
+	Bucket    string
+	Key       string
+	Context   map[string]string
+}
+
+// The resource of the request, without the arn prefix: the bucket for bucket actions and
+// bucket/key otherwise
+func (r PolicyRequest) Resource() string {
+	if IsBucketPolicyAction(r.Action) {
+		return r.Bucket
+	}
+	return r.Bucket + "/" + r.Key
+}
+
+type PolicyDecision struct {
+	Allowed bool
+	// One of PolicyDecisionAllow, PolicyDecisionDeny or PolicyDecisionImplicitDeny
+	Decision string
+	// Indexes of the statements that decided, the denying ones if denied
+	Statements []int
+}
+
+// Evaluates the request as the server would: an explicit Deny wins over any Allow, and
+// without statements that apply the request is denied
+func (d PolicyDocument) Evaluate(req PolicyRequest) PolicyDecision {
+	var allows, denies []int
+	for i, statement := range d.Statement {
+		if !statement.appliesTo(req) {
+			continue
+		}
+		if statement.Effect == PolicyEffectDeny {
+			denies = append(denies, i)
+		} else if statement.Effect == PolicyEffectAllow {
+			allows = append(allows, i)
+		}
+	}
+
+	switch {
+	case len(denies) > 0:
+		return PolicyDecision{Decision: PolicyDecisionDeny, Statements: denies}
+	case len(allows) > 0:
+		return PolicyDecision{Allowed: true, Decision: PolicyDecisionAllow, Statements: allows}
+	default:
+		return PolicyDecision{Decision: PolicyDecisionImplicitDeny}
+	}
+}
+
+func (s PolicyStatement) appliesTo(req PolicyRequest) bool {
+	return s.matchesPrincipal(req.Principal) &&
+		s.MatchesAction(req.Action) &&
+		s.matchesResource(req.Resource()) &&
+		s.matchesConditions(req.Context)
+}
+
+// Anonymous requests only match statements that apply to everyone
+func (s PolicyStatement) matchesPrincipal(principal string) bool {
+	if s.Principal == nil {
+		return false
+	}
+	if s.Principal.IsPublic() {
+		return true
+	}
+	if principal == "" {
+		return false
+	}
+	for _, ids := range s.Principal.IDs {
+		for _, id := range ids {
+			if id == principal {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s PolicyStatement) matchesResource(resource string) bool {
+	for _, pattern := range s.Resource {
+		if matchPolicyPattern(strings.TrimPrefix(pattern, BucketARNPrefix), resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// All the operators and keys of the condition must match
+func (s PolicyStatement) matchesConditions(context map[string]string) bool {
+	for name, keys := range s.Condition {
+		operator, err := parseConditionOperator(name)
+		if err != nil {
+			return false
+		}
+		for key, expected := range keys {
+			if !operator.matches(context, key, expected) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (o conditionOperator) matches(context map[string]string, key string, expected StringOrList) bool {
+	actual, ok := lookupConditionKey(context, key)
+	if o.kind == conditionNull {
+		// Null is true when the key must be missing
+		for _, value := range expected {
+			if (value == "true") == !ok {
+				return true
+			}
+		}
+		return false
+	}
+	// A missing key doesn't match any value, so negated operators are true without it
+	if !ok {
+		return o.negated || o.ifExists
+	}
+
+	for _, value := range expected {
+		if o.compare(actual, value) {
+			return !o.negated
+		}
+	}
+	return o.negated
+}
+
+// Condition keys are matched ignoring case, as the server does
+func lookupConditionKey(context map[string]string, key string) (string, bool) {
+	if value, ok := context[key]; ok {
+		return value, true
+	}
+	for k, value := range context {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,138 @@
+package common_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+func TestParsePolicyErrors(t *testing.T) {
+	data := []byte(`{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "Read",
+      "Effect": "Allow",
+      "Principal": "*",
+      "Action": ["s3:GetObject", "s3:Get/Object"],
+      "Resource": "other-bucket/*"
+    },
+    {
+      "Sid": "Read",
+      "Effect": "Permit",
+      "Principal": {"MGC": []},
+      "Action": "s3:*",
+      "Resource": "my-bucket/*",
+      "Condition": {"IpAddress": {"aws:SourceIp": "10.0.0.0/33"}}
+    }
+  ]
+}`)
+
+	_, err := common.ParsePolicy(data, "my-bucket")
+	var errs common.PolicyErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected policy errors, got %v", err)
+	}
+
+	expected := []common.PolicyError{
+		{Path: "Statement[0].Action[1]", Line: 8, Column: 34},
+		{Path: "Statement[0].Resource", Line: 9, Column: 19},
+		{Path: "Statement[1].Sid", Line: 12, Column: 14},
+		{Path: "Statement[1].Effect", Line: 13, Column: 17},
+		{Path: "Statement[1].Principal.MGC", Line: 14, Column: 28},
+		{Path: "Statement[1].Condition.IpAddress.aws:SourceIp", Line: 17, Column: 51},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %s", len(expected), len(errs), err)
+	}
+	for i, e := range expected {
+		if errs[i].Path != e.Path || errs[i].Line != e.Line || errs[i].Column != e.Column {
+			t.Errorf("error %d: expected %s at %d:%d, got %s", i, e.Path, e.Line, e.Column, errs[i].Error())
+		}
+	}
+}
+
+func TestParsePolicySyntax(t *testing.T) {
+	for name, data := range map[string]string{
+		"syntax":    "{\n  \"Statement\": [\n    {\"Effect\": \"Allow\",}\n  ]\n}",
+		"duplicate": "{\n  \"Statement\": [],\n  \"Statement\": []\n}",
+		"truncated": "{\n  \"Statement\": [",
+	} {
+		_, err := common.ParsePolicy([]byte(data), "")
+		var errs common.PolicyErrors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line < 2 {
+			t.Errorf("%s: expected a located error, got %v", name, err)
+		}
+	}
+
+	_, err := common.ParsePolicyValue(map[string]any{
+		"Statement": map[string]any{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "b/*", "NotPrincipal": "*"},
+	}, "")
+	if err == nil || !strings.Contains(err.Error(), "Statement.NotPrincipal") {
+		t.Errorf("unknown fields must be reported by path: %v", err)
+	}
+}
+
+func TestCheckPolicyWarnings(t *testing.T) {
+	data := []byte(`{
+  "Statement": {
+    "Effect": "Allow",
+    "Principal": "*",
+    "Action": ["s3:GetObjectLegalHold", "s3:GetEncryptionConfiguration", "s3:GetObjet", "s3:Put*"],
+    "Resource": "my-bucket/*"
+  }
+}`)
+
+	document, warnings, err := common.CheckPolicy(data, "my-bucket")
+	if err != nil || document == nil {
+		t.Fatalf("unknown actions must not fail the validation: %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].Path != "Statement.Action[2]" || warnings[0].Line != 5 {
+		t.Errorf("expected a warning for s3:GetObjet only, got %v", warnings)
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	document, err := common.ParsePolicy([]byte(`{
+		"Version": "2012-10-17",
+		"Statement": [
+			{"Sid": "PublicRead", "Effect": "Allow", "Principal": "*", "Action": ["s3:GetObject", "s3:ListBucket"],
+			 "Resource": ["arn:aws:s3:::site", "arn:aws:s3:::site/*"]},
+			{"Sid": "NoSecrets", "Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": "site/secret/*"},
+			{"Sid": "Office", "Effect": "Allow", "Principal": {"MGC": ["partner"]}, "Action": "s3:PutObject", "Resource": "site/*",
+			 "Condition": {"IpAddress": {"aws:SourceIp": ["10.0.0.0/8", "192.168.1.1"]}, "Bool": {"aws:SecureTransport": true}}}
+		]
+	}`), "site")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	office := map[string]string{"aws:SourceIp": "10.1.2.3", "aws:SecureTransport": "true"}
+	for _, test := range []struct {
+		name       string
+		req        common.PolicyRequest
+		decision   string
+		statements []int
+	}{
+		{"public read", common.PolicyRequest{Action: "s3:GetObject", Bucket: "site", Key: "index.html"}, common.PolicyDecisionAllow, []int{0}},
+		{"bucket action", common.PolicyRequest{Action: "s3:ListBucket", Bucket: "site"}, common.PolicyDecisionAllow, []int{0}},
+		{"explicit deny", common.PolicyRequest{Action: "s3:GetObject", Bucket: "site", Key: "secret/key"}, common.PolicyDecisionDeny, []int{1}},
+		{"anonymous write", common.PolicyRequest{Action: "s3:PutObject", Bucket: "site", Key: "a"}, common.PolicyDecisionImplicitDeny, nil},
+		{"partner write", common.PolicyRequest{Principal: "partner", Action: "s3:PutObject", Bucket: "site", Key: "a", Context: office}, common.PolicyDecisionAllow, []int{2}},
+		{"outside network", common.PolicyRequest{Principal: "partner", Action: "s3:PutObject", Bucket: "site", Key: "a",
+			Context: map[string]string{"aws:SourceIp": "8.8.8.8", "aws:SecureTransport": "true"}}, common.PolicyDecisionImplicitDeny, nil},
+		{"missing condition key", common.PolicyRequest{Principal: "partner", Action: "s3:PutObject", Bucket: "site", Key: "a",
+			Context: map[string]string{"aws:SourceIp": "192.168.1.1"}}, common.PolicyDecisionImplicitDeny, nil},
+		{"other bucket", common.PolicyRequest{Action: "s3:GetObject", Bucket: "other", Key: "a"}, common.PolicyDecisionImplicitDeny, nil},
+	} {
+		decision := document.Evaluate(test.req)
+		if decision.Decision != test.decision || decision.Allowed != (test.decision == common.PolicyDecisionAllow) {
+			t.Errorf("%s: expected %s, got %+v", test.name, test.decision, decision)
+		}
+		if len(decision.Statements) != len(test.statements) || (len(test.statements) > 0 && decision.Statements[0] != test.statements[0]) {
+			t.Errorf("%s: expected statements %v, got %v", test.name, test.statements, decision.Statements)
+		}
+	}
+}
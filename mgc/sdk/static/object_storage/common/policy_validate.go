@@ -0,0 +1,731 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var policyVersions = []string{"2012-10-17", "2008-10-17"}
+
+// Actions known to be accepted in policies, with the s3: prefix. Others are only warned about,
+// as the server may support more than listed here
+var policyActions = []string{
+	"s3:AbortMultipartUpload",
+	"s3:BypassGovernanceRetention",
+	"s3:DeleteBucket",
+	"s3:DeleteBucketPolicy",
+	"s3:DeleteBucketWebsite",
+	"s3:DeleteObject",
+	"s3:DeleteObjectTagging",
+	"s3:DeleteObjectVersion",
+	"s3:DeleteObjectVersionTagging",
+	"s3:GetBucketAcl",
+	"s3:GetBucketCORS",
+	"s3:GetBucketLocation",
+	"s3:GetBucketObjectLockConfiguration",
+	"s3:GetBucketPolicy",
+	"s3:GetBucketPublicAccessBlock",
+	"s3:GetBucketTagging",
+	"s3:GetBucketVersioning",
+	"s3:GetBucketWebsite",
+	"s3:GetEncryptionConfiguration",
+	"s3:GetLifecycleConfiguration",
+	"s3:GetObject",
+	"s3:GetObjectAcl",
+	"s3:GetObjectLegalHold",
+	"s3:GetObjectRetention",
+	"s3:GetObjectTagging",
+	"s3:GetObjectVersion",
+	"s3:GetObjectVersionAcl",
+	"s3:GetObjectVersionTagging",
+	"s3:GetReplicationConfiguration",
+	"s3:ListAllMyBuckets",
+	"s3:ListBucket",
+	"s3:ListBucketMultipartUploads",
+	"s3:ListBucketVersions",
+	"s3:ListMultipartUploadParts",
+	"s3:PutBucketAcl",
+	"s3:PutBucketCORS",
+	"s3:PutBucketObjectLockConfiguration",
+	"s3:PutBucketPolicy",
+	"s3:PutBucketTagging",
+	"s3:PutBucketVersioning",
+	"s3:PutBucketWebsite",
+	"s3:PutLifecycleConfiguration",
+	"s3:PutObject",
+	"s3:PutObjectAcl",
+	"s3:PutObjectLegalHold",
+	"s3:PutObjectRetention",
+	"s3:PutObjectTagging",
+	"s3:PutObjectVersionAcl",
+	"s3:PutObjectVersionTagging",
+	"s3:PutReplicationConfiguration",
+	"s3:ReplicateDelete",
+	"s3:ReplicateObject",
+	"s3:RestoreObject",
+}
+
+// Whether the action applies to the bucket itself, instead of its objects, so its resource
+// is the bucket name
+func IsBucketPolicyAction(action string) bool {
+	name := strings.TrimPrefix(strings.ToLower(action), "s3:")
+	return strings.Contains(name, "bucket") ||
+		strings.HasSuffix(name, "lifecycleconfiguration") ||
+		strings.HasSuffix(name, "replicationconfiguration") ||
+		name == "listallmybuckets"
+}
+
+var policySidRegex = regexp.MustCompile(`^[A-Za-z0-9]*$`)
+
+// Name of an action after the s3: prefix, with wildcards
+var policyActionRegex = regexp.MustCompile(`^[A-Za-z*?]+$`)
+
+// A problem found in a policy document. Path locates the value, such as Statement[1].Action[0].
+// Line and Column are 0 if the document was not parsed from text
+type PolicyError struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e PolicyError) Error() string {
+	location := e.Path
+	if e.Line > 0 {
+		location = fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+		if e.Path != "" {
+			location += " (" + e.Path + ")"
+		}
+	}
+	if location == "" {
+		return e.Message
+	}
+	return location + ": " + e.Message
+}
+
+// All the problems found in a policy document, in the order they appear
+type PolicyErrors []PolicyError
+
+// One problem per line if there are many
+func (e PolicyErrors) Error() string {
+	if len(e) == 1 {
+		return "invalid policy: " + e[0].Error()
+	}
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, "  "+err.Error())
+	}
+	return "invalid policy:\n" + strings.Join(messages, "\n")
+}
+
+// Parses and validates the policy document. If bucket isn't empty, the resources must be in
+// it. Problems are returned as PolicyErrors, located by line and column. Warnings are logged
+func ParsePolicy(data []byte, bucket string) (*PolicyDocument, error) {
+	document, warnings, err := CheckPolicy(data, bucket)
+	logPolicyWarnings(warnings)
+	return document, err
+}
+
+// Same as ParsePolicy(), also returning what is valid but likely a mistake, such as unknown actions
+func CheckPolicy(data []byte, bucket string) (*PolicyDocument, PolicyErrors, error) {
+	p := &policyParser{data: data, offsets: map[string]int64{}}
+	tree, err := p.parse()
+	if err != nil {
+		return nil, nil, err
+	}
+	return decodePolicy(tree, bucket, p)
+}
+
+// Validates a policy document already decoded from JSON, as given to buckets policy set.
+// Problems are returned as PolicyErrors, located only by their path
+func ParsePolicyValue(value any, bucket string) (*PolicyDocument, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	p := &policyParser{data: data}
+	tree, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	document, warnings, err := decodePolicy(tree, bucket, p)
+	logPolicyWarnings(warnings)
+	return document, err
+}
+
+func decodePolicy(tree any, bucket string, p *policyParser) (*PolicyDocument, PolicyErrors, error) {
+	v := &policyValidator{bucket: bucket}
+	v.document(tree)
+	if len(v.errors) > 0 {
+		return nil, nil, p.locate(v.errors)
+	}
+
+	document := &PolicyDocument{}
+	if err := json.Unmarshal(p.data, document); err != nil {
+		return nil, nil, PolicyErrors{{Message: err.Error()}}
+	}
+	var warnings PolicyErrors
+	if len(v.warnings) > 0 {
+		warnings = p.locate(v.warnings)
+	}
+	return document, warnings, nil
+}
+
+func logPolicyWarnings(warnings PolicyErrors) {
+	for _, warning := range warnings {
+		logger().Warnw("policy warning", "warning", warning.Error())
+	}
+}
+
+// Builds the generic JSON tree, remembering where each value starts when offsets isn't nil
+type policyParser struct {
+	data    []byte
+	decoder *json.Decoder
+	offsets map[string]int64
+}
+
+func (p *policyParser) parse() (any, error) {
+	p.decoder = json.NewDecoder(bytes.NewReader(p.data))
+	p.decoder.UseNumber()
+	tree, err := p.value("")
+	if err == nil {
+		if _, extraErr := p.decoder.Token(); extraErr != io.EOF {
+			err = p.syntaxError(p.valueStart(), "unexpected data after the policy document")
+		}
+	}
+	return tree, err
+}
+
+// Offset of the next value, skipping what separates it from the previous token
+func (p *policyParser) valueStart() int64 {
+	offset := p.decoder.InputOffset()
+	for offset < int64(len(p.data)) && strings.ContainsRune(" \t\r\n,:", rune(p.data[offset])) {
+		offset++
+	}
+	return offset
+}
+
+func (p *policyParser) position(offset int64) (line, column int) {
+	before := p.data[:min(offset, int64(len(p.data)))]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+func (p *policyParser) syntaxError(offset int64, message string) error {
+	err := PolicyError{Message: message}
+	if p.offsets != nil {
+		err.Line, err.Column = p.position(offset)
+	}
+	return PolicyErrors{err}
+}
+
+func (p *policyParser) tokenError(err error) error {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return p.syntaxError(syntaxErr.Offset, syntaxErr.Error())
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return p.syntaxError(int64(len(p.data)), "unexpected end of the policy document")
+	default:
+		return PolicyErrors{{Message: err.Error()}}
+	}
+}
+
+func (p *policyParser) value(path string) (any, error) {
+	start := p.valueStart()
+	if p.offsets != nil {
+		p.offsets[path] = start
+	}
+
+	token, err := p.decoder.Token()
+	if err != nil {
+		return nil, p.tokenError(err)
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := map[string]any{}
+		for p.decoder.More() {
+			keyStart := p.valueStart()
+			token, err := p.decoder.Token()
+			if err != nil {
+				return nil, p.tokenError(err)
+			}
+			key := token.(string)
+			if _, ok := object[key]; ok {
+				return nil, p.syntaxError(keyStart, fmt.Sprintf("duplicate field %q", key))
+			}
+			if object[key], err = p.value(joinPolicyPath(path, key)); err != nil {
+				return nil, err
+			}
+		}
+		if _, err = p.decoder.Token(); err != nil {
+			return nil, p.tokenError(err)
+		}
+		return object, nil
+	case json.Delim('['):
+		list := []any{}
+		for p.decoder.More() {
+			item, err := p.value(fmt.Sprintf("%s[%d]", path, len(list)))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		if _, err = p.decoder.Token(); err != nil {
+			return nil, p.tokenError(err)
+		}
+		return list, nil
+	default:
+		return token, nil
+	}
+}
+
+// Adds the line and column of the paths, sorting the errors by them
+func (p *policyParser) locate(errs PolicyErrors) PolicyErrors {
+	if p.offsets == nil {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+		return errs
+	}
+	offsets := make(map[string]int64, len(errs))
+	for i, err := range errs {
+		offsets[err.Path] = p.offsets[err.Path]
+		errs[i].Line, errs[i].Column = p.position(offsets[err.Path])
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return offsets[errs[i].Path] < offsets[errs[j].Path] })
+	return errs
+}
+
+func joinPolicyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+type policyValidator struct {
+	bucket   string
+	errors   PolicyErrors
+	warnings PolicyErrors
+}
+
+func (v *policyValidator) fail(path string, format string, args ...any) {
+	v.errors = append(v.errors, PolicyError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *policyValidator) warn(path string, format string, args ...any) {
+	v.warnings = append(v.warnings, PolicyError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Returns the object and true if value is one, checking that it has only the allowed fields
+func (v *policyValidator) object(path string, value any, allowed ...string) (map[string]any, bool) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		v.fail(path, "expected an object")
+		return nil, false
+	}
+	for key := range object {
+		if !slices.Contains(allowed, key) {
+			v.fail(joinPolicyPath(path, key), "unknown field %q, expected one of %s", key, strings.Join(allowed, ", "))
+		}
+	}
+	return object, true
+}
+
+// Calls check with each string of a string or non-empty list of strings
+func (v *policyValidator) strings(path string, value any, check func(path string, s string)) {
+	switch value := value.(type) {
+	case string:
+		check(path, value)
+	case []any:
+		if len(value) == 0 {
+			v.fail(path, "expected at least one value")
+		}
+		for i, item := range value {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if s, ok := item.(string); ok {
+				check(itemPath, s)
+			} else {
+				v.fail(itemPath, "expected a string")
+			}
+		}
+	default:
+		v.fail(path, "expected a string or a list of strings")
+	}
+}
+
+func (v *policyValidator) document(tree any) {
+	document, ok := v.object("", tree, "Version", "Id", "Statement")
+	if !ok {
+		return
+	}
+
+	if version, ok := document["Version"]; ok {
+		if s, _ := version.(string); !slices.Contains(policyVersions, s) {
+			v.fail("Version", "unsupported version %v, expected %s", version, strings.Join(policyVersions, " or "))
+		}
+	}
+	if id, ok := document["Id"]; ok {
+		if _, ok := id.(string); !ok {
+			v.fail("Id", "expected a string")
+		}
+	}
+
+	switch statements := document["Statement"].(type) {
+	case nil:
+		v.fail("", "missing field \"Statement\"")
+	case map[string]any:
+		v.statement("Statement", statements, map[string]bool{})
+	case []any:
+		if len(statements) == 0 {
+			v.fail("Statement", "expected at least one statement")
+		}
+		sids := map[string]bool{}
+		for i, statement := range statements {
+			v.statement(fmt.Sprintf("Statement[%d]", i), statement, sids)
+		}
+	default:
+		v.fail("Statement", "expected a statement or a list of statements")
+	}
+}
+
+func (v *policyValidator) statement(path string, value any, sids map[string]bool) {
+	statement, ok := v.object(path, value, "Sid", "Effect", "Principal", "Action", "NotAction", "Resource", "Condition")
+	if !ok {
+		return
+	}
+
+	if sid, ok := statement["Sid"]; ok {
+		s, isString := sid.(string)
+		switch {
+		case !isString:
+			v.fail(path+".Sid", "expected a string")
+		case !policySidRegex.MatchString(s):
+			v.fail(path+".Sid", "invalid Sid %q, only letters and digits are allowed", s)
+		case s != "" && sids[s]:
+			v.fail(path+".Sid", "duplicate Sid %q", s)
+		default:
+			sids[s] = true
+		}
+	}
+
+	switch effect := statement["Effect"]; effect {
+	case nil:
+		v.fail(path, "missing field \"Effect\"")
+	case PolicyEffectAllow, PolicyEffectDeny:
+	default:
+		v.fail(path+".Effect", "invalid effect %v, expected %s or %s", effect, PolicyEffectAllow, PolicyEffectDeny)
+	}
+
+	if principal, ok := statement["Principal"]; ok {
+		v.principal(path+".Principal", principal)
+	} else {
+		v.fail(path, "missing field \"Principal\"")
+	}
+
+	action, hasAction := statement["Action"]
+	notAction, hasNotAction := statement["NotAction"]
+	switch {
+	case hasAction && hasNotAction:
+		v.fail(path+".NotAction", "Action and NotAction cannot be used together")
+	case hasAction:
+		v.strings(path+".Action", action, v.action)
+	case hasNotAction:
+		v.strings(path+".NotAction", notAction, v.action)
+	default:
+		v.fail(path, "missing field \"Action\"")
+	}
+
+	if resource, ok := statement["Resource"]; ok {
+		v.strings(path+".Resource", resource, v.resource)
+	} else {
+		v.fail(path, "missing field \"Resource\"")
+	}
+
+	if condition, ok := statement["Condition"]; ok {
+		v.condition(path+".Condition", condition)
+	}
+}
+
+func (v *policyValidator) principal(path string, value any) {
+	if value == "*" {
+		return
+	}
+	principal, ok := value.(map[string]any)
+	if !ok {
+		v.fail(path, "expected \"*\" or an object with the IDs of the principals")
+		return
+	}
+	if len(principal) == 0 {
+		v.fail(path, "expected at least one principal")
+	}
+	for kind, ids := range principal {
+		v.strings(joinPolicyPath(path, kind), ids, func(path string, id string) {
+			if strings.TrimSpace(id) == "" {
+				v.fail(path, "empty principal ID")
+			}
+		})
+	}
+}
+
+func (v *policyValidator) action(path string, action string) {
+	if action == "*" {
+		return
+	}
+	if !strings.HasPrefix(strings.ToLower(action), "s3:") {
+		v.fail(path, "invalid action %q, expected the s3: prefix, such as s3:GetObject", action)
+		return
+	}
+	if !policyActionRegex.MatchString(action[len("s3:"):]) {
+		v.fail(path, "invalid action %q, expected a name of letters and wildcards after s3:", action)
+		return
+	}
+	statement := PolicyStatement{Action: StringOrList{action}}
+	for _, known := range policyActions {
+		if statement.MatchesAction(known) {
+			return
+		}
+	}
+	v.warn(path, "unknown action %q, check its spelling", action)
+}
+
+func (v *policyValidator) resource(path string, resource string) {
+	name := strings.TrimPrefix(resource, BucketARNPrefix)
+	if strings.HasPrefix(name, "arn:") {
+		v.fail(path, "invalid resource %q, expected %sbucket/key or bucket/key", resource, BucketARNPrefix)
+		return
+	}
+	bucket, _, _ := strings.Cut(name, "/")
+	if bucket == "" {
+		v.fail(path, "invalid resource %q, the bucket is missing", resource)
+		return
+	}
+	if v.bucket != "" && !matchPolicyPattern(bucket, v.bucket) {
+		v.fail(path, "resource %q is not in bucket %s", resource, v.bucket)
+	}
+}
+
+func (v *policyValidator) condition(path string, value any) {
+	condition, ok := value.(map[string]any)
+	if !ok {
+		v.fail(path, "expected an object with the condition operators")
+		return
+	}
+	for name, keys := range condition {
+		operatorPath := joinPolicyPath(path, name)
+		operator, err := parseConditionOperator(name)
+		if err != nil {
+			v.fail(operatorPath, "%s", err)
+			continue
+		}
+		keyValues, ok := keys.(map[string]any)
+		if !ok || len(keyValues) == 0 {
+			v.fail(operatorPath, "expected an object with the condition keys and their values")
+			continue
+		}
+		for key, values := range keyValues {
+			keyPath := joinPolicyPath(operatorPath, key)
+			if !strings.Contains(key, ":") {
+				v.fail(keyPath, "invalid condition key %q, expected a prefix such as aws: or s3:", key)
+			}
+			v.conditionValues(keyPath, operator, values)
+		}
+	}
+}
+
+func (v *policyValidator) conditionValues(path string, operator conditionOperator, value any) {
+	values, isList := value.([]any)
+	if !isList {
+		values = []any{value}
+	} else if len(values) == 0 {
+		v.fail(path, "expected at least one value")
+	}
+
+	for i, item := range values {
+		itemPath := path
+		if isList {
+			itemPath = fmt.Sprintf("%s[%d]", path, i)
+		}
+		var s string
+		switch item := item.(type) {
+		case string:
+			s = item
+		case json.Number:
+			s = item.String()
+		case bool:
+			s = strconv.FormatBool(item)
+		default:
+			v.fail(itemPath, "expected a string, number or boolean")
+			continue
+		}
+		if err := operator.kind.validate(s); err != nil {
+			v.fail(itemPath, "invalid value for %s: %s", operator.name, err)
+		}
+	}
+}
+
+// How condition values of an operator are compared
+type conditionKind int
+
+const (
+	conditionString conditionKind = iota
+	conditionNumeric
+	conditionDate
+	conditionBool
+	conditionIP
+	conditionNull
+)
+
+func (k conditionKind) validate(value string) error {
+	switch k {
+	case conditionNumeric:
+		_, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+	case conditionDate:
+		if _, err := parseConditionDate(value); err != nil {
+			return err
+		}
+	case conditionBool, conditionNull:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+	case conditionIP:
+		if _, err := parseConditionIP(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseConditionDate(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return date, fmt.Errorf("%q is not a date such as 2024-12-31T00:00:00Z", value)
+	}
+	return date, nil
+}
+
+// Single addresses are taken as networks with only them
+func parseConditionIP(value string) (*net.IPNet, error) {
+	if ip := net.ParseIP(value); ip != nil {
+		bits := 8 * len(ip.To16())
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an IP address or CIDR block", value)
+	}
+	return network, nil
+}
+
+type conditionOperator struct {
+	name string
+	kind conditionKind
+	// The values that match, compared with those of the request
+	compare func(actual, expected string) bool
+	// Matches if no value compares true, as in StringNotEquals
+	negated bool
+	// Matches if the key is missing from the request
+	ifExists bool
+}
+
+var conditionOperators = map[string]conditionOperator{}
+
+func addConditionOperator(name string, kind conditionKind, compare func(actual, expected string) bool) {
+	conditionOperators[name] = conditionOperator{name: name, kind: kind, compare: compare}
+}
+
+func addNegatedConditionOperator(name string, positive string) {
+	operator := conditionOperators[positive]
+	operator.name = name
+	operator.negated = true
+	conditionOperators[name] = operator
+}
+
+func compareConditionNumbers(compare func(a, b float64) bool) func(actual, expected string) bool {
+	return func(actual, expected string) bool {
+		a, errA := strconv.ParseFloat(actual, 64)
+		b, errB := strconv.ParseFloat(expected, 64)
+		return errA == nil && errB == nil && compare(a, b)
+	}
+}
+
+func compareConditionDates(compare func(a, b time.Time) bool) func(actual, expected string) bool {
+	return func(actual, expected string) bool {
+		a, errA := parseConditionDate(actual)
+		b, errB := parseConditionDate(expected)
+		return errA == nil && errB == nil && compare(a, b)
+	}
+}
+
+func init() {
+	addConditionOperator("StringEquals", conditionString, func(a, e string) bool { return a == e })
+	addConditionOperator("StringEqualsIgnoreCase", conditionString, strings.EqualFold)
+	addConditionOperator("StringLike", conditionString, func(a, e string) bool { return matchPolicyPattern(e, a) })
+	addNegatedConditionOperator("StringNotEquals", "StringEquals")
+	addNegatedConditionOperator("StringNotEqualsIgnoreCase", "StringEqualsIgnoreCase")
+	addNegatedConditionOperator("StringNotLike", "StringLike")
+
+	addConditionOperator("NumericEquals", conditionNumeric, compareConditionNumbers(func(a, b float64) bool { return a == b }))
+	addConditionOperator("NumericLessThan", conditionNumeric, compareConditionNumbers(func(a, b float64) bool { return a < b }))
+	addConditionOperator("NumericLessThanEquals", conditionNumeric, compareConditionNumbers(func(a, b float64) bool { return a <= b }))
+	addConditionOperator("NumericGreaterThan", conditionNumeric, compareConditionNumbers(func(a, b float64) bool { return a > b }))
+	addConditionOperator("NumericGreaterThanEquals", conditionNumeric, compareConditionNumbers(func(a, b float64) bool { return a >= b }))
+	addNegatedConditionOperator("NumericNotEquals", "NumericEquals")
+
+	addConditionOperator("DateEquals", conditionDate, compareConditionDates(time.Time.Equal))
+	addConditionOperator("DateLessThan", conditionDate, compareConditionDates(time.Time.Before))
+	addConditionOperator("DateLessThanEquals", conditionDate, compareConditionDates(func(a, b time.Time) bool { return !a.After(b) }))
+	addConditionOperator("DateGreaterThan", conditionDate, compareConditionDates(time.Time.After))
+	addConditionOperator("DateGreaterThanEquals", conditionDate, compareConditionDates(func(a, b time.Time) bool { return !a.Before(b) }))
+	addNegatedConditionOperator("DateNotEquals", "DateEquals")
+
+	addConditionOperator("Bool", conditionBool, strings.EqualFold)
+
+	addConditionOperator("IpAddress", conditionIP, func(a, e string) bool {
+		network, err := parseConditionIP(e)
+		ip := net.ParseIP(a)
+		return err == nil && ip != nil && network.Contains(ip)
+	})
+	addNegatedConditionOperator("NotIpAddress", "IpAddress")
+
+	// Compared against whether the key is missing, see conditionMatches()
+	addConditionOperator("Null", conditionNull, nil)
+}
+
+// Operators may have the ForAnyValue: or ForAllValues: prefixes and the IfExists suffix. Since
+// requests have a single value per key, the prefixes don't change how they are evaluated
+func parseConditionOperator(name string) (conditionOperator, error) {
+	base := name
+	for _, prefix := range []string{"ForAnyValue:", "ForAllValues:"} {
+		base = strings.TrimPrefix(base, prefix)
+	}
+	base, ifExists := strings.CutSuffix(base, "IfExists")
+
+	operator, ok := conditionOperators[base]
+	if !ok || (ifExists && operator.kind == conditionNull) {
+		return operator, fmt.Errorf("unknown condition operator %q", name)
+	}
+	operator.name = name
+	operator.ifExists = ifExists
+	return operator, nil
+}
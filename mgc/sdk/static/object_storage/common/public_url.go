@@ -2,12 +2,20 @@ package common
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"strings"
 
+	"github.com/MagaluCloud/magalu/mgc/core"
 	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
 )
 
 type PublicUrlResult struct {
 	URL mgcSchemaPkg.URI `json:"url"`
+	// Set if the bucket has static website hosting enabled
+	WebsiteEnabled bool `json:"website_enabled,omitempty"`
+	// Only set if the bucket has static website hosting enabled and its endpoint is known
+	WebsiteURL mgcSchemaPkg.URI `json:"website_url,omitempty"`
 }
 
 func PublicUrl(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI) (url *PublicUrlResult, err error) {
@@ -20,3 +28,23 @@ func PublicUrl(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI) (url *Publ
 		URL: mgcSchemaPkg.URI(resourceUrl),
 	}, nil
 }
+
+// Endpoint serving the bucket as a static website, built from cfg.WebsiteEndpoint or from the
+// region, as BuildHost() does. Empty if only cfg.ServerUrl is set, as the endpoint can't be
+// derived from a custom server
+func WebsiteUrl(cfg Config, dst mgcSchemaPkg.URI) (mgcSchemaPkg.URI, error) {
+	template := cfg.WebsiteEndpoint
+	if template == "" {
+		if cfg.ServerUrl != "" {
+			return "", nil
+		}
+		template = strings.ReplaceAll(websiteTemplateUrl, "{{region}}", cfg.Region)
+	}
+
+	endpoint := strings.ReplaceAll(template, "{bucket}", string(NewBucketNameFromURI(dst)))
+	websiteUrl, err := url.Parse(endpoint)
+	if err != nil || websiteUrl.Scheme == "" || websiteUrl.Host == "" {
+		return "", core.UsageError{Err: fmt.Errorf("invalid website endpoint %q, expected something like https://{bucket}.example.com", template)}
+	}
+	return mgcSchemaPkg.URI(websiteUrl.JoinPath(dst.Path()).String()), nil
+}
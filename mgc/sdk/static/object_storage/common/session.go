@@ -8,7 +8,6 @@ import (
 	"net/url"
 	"strings"
 
-	"github.com/MagaluCloud/magalu/mgc/core/auth"
 	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
 )
 
@@ -110,7 +109,7 @@ func SendRequestWithIgnoredHeaders(ctx context.Context, req *http.Request, cfg C
 		unsignedPayload = true
 	}
 
-	accesskeyId, accessSecretKey := auth.FromContext(ctx).AccessKeyPair()
+	accesskeyId, accessSecretKey := cfg.accessKeyPair(ctx)
 	if accesskeyId == "" || accessSecretKey == "" {
 		err = fmt.Errorf("api-key not set, see how to set it with \"mgc object-storage api-key -h\"")
 		return
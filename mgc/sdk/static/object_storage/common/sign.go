@@ -2,6 +2,7 @@ package common
 
 import (
 	"bytes"
+	"crypto/hmac"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
@@ -9,6 +10,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -364,3 +366,96 @@ func SignedUrl(req *http.Request, accessKey, secretKey, region string, expiratio
     url.RawQuery = q.Encode()
     return url, nil
 }
+
+// Parsed "Credential=" value: <access-key>/<date>/<region>/<service>/<pre-defined-suffix>
+func parseCredential(credential string) (accessKey, shortDate, region string, err error) {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[3] != signingService || parts[4] != requestSuffix {
+		return "", "", "", fmt.Errorf("malformed credential %q", credential)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// VerifySignature checks the signature of a request signed by SendRequest() or a URL
+// signed by SignedUrl(), the way the server does. It's meant for servers standing in
+// for the service in tests.
+//
+// Only the signature is verified: checking that the payload matches X-Amz-Content-Sha256
+// is up to the caller, as it requires consuming the body.
+func VerifySignature(req *http.Request, accessKey, secretKey string) error {
+	var credential, date, signedHeaders, signature, payloadHash string
+	canonicalReq := req.Clone(req.Context())
+	canonicalReq.Header.Set("Host", req.Host)
+
+	if query := req.URL.Query(); query.Get("X-Amz-Signature") != "" {
+		if algorithm := query.Get("X-Amz-Algorithm"); algorithm != signingAlgorithm {
+			return fmt.Errorf("unsupported signing algorithm %q", algorithm)
+		}
+		credential = query.Get("X-Amz-Credential")
+		date = query.Get("X-Amz-Date")
+		signedHeaders = query.Get("X-Amz-SignedHeaders")
+		signature = query.Get("X-Amz-Signature")
+		payloadHash = unsignedPayloadHeader
+
+		signingTime, err := time.Parse(longTimeFormat, date)
+		if err != nil {
+			return fmt.Errorf("malformed date %q: %w", date, err)
+		}
+		expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if err != nil {
+			return fmt.Errorf("malformed expiration %q: %w", query.Get("X-Amz-Expires"), err)
+		}
+		if time.Now().After(signingTime.Add(time.Duration(expires) * time.Second)) {
+			return fmt.Errorf("signed URL expired")
+		}
+
+		query.Del("X-Amz-Signature")
+		canonicalReq.URL.RawQuery = query.Encode()
+	} else {
+		authorization, found := strings.CutPrefix(req.Header.Get(authorizationHeaderKey), signingAlgorithm+" ")
+		if !found {
+			return fmt.Errorf("missing or unsupported authorization")
+		}
+		for _, field := range strings.Split(authorization, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch name {
+			case "Credential":
+				credential = value
+			case "SignedHeaders":
+				signedHeaders = value
+			case "Signature":
+				signature = value
+			}
+		}
+		date = req.Header.Get(headerDateKey)
+		payloadHash = req.Header.Get(contentSHAKey)
+	}
+
+	keyId, _, region, err := parseCredential(credential)
+	if err != nil {
+		return err
+	}
+	if keyId != accessKey {
+		return fmt.Errorf("unknown access key %q", keyId)
+	}
+
+	signingTime, err := time.Parse(longTimeFormat, date)
+	if err != nil {
+		return fmt.Errorf("malformed date %q: %w", date, err)
+	}
+
+	params := NewSignatureParameters(keyId, signingTime, payloadHash, strings.Split(signedHeaders, ";"), region)
+	if params.Credential != credential {
+		return fmt.Errorf("credential scope %q doesn't match the request date", credential)
+	}
+
+	ctx := NewSignatureContext(params, canonicalReq)
+	if err = sign(ctx, secretKey, region); err != nil {
+		return err
+	}
+
+	if !hmac.Equal([]byte(ctx.Signature), []byte(signature)) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
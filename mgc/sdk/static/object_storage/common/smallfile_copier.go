@@ -7,11 +7,17 @@ import (
 )
 
 type smallFileCopier struct {
-	cfg          Config
-	src          mgcSchemaPkg.URI
-	dst          mgcSchemaPkg.URI
-	version      string
-	storageClass string
+	cfg              Config
+	src              mgcSchemaPkg.URI
+	dst              mgcSchemaPkg.URI
+	version          string
+	storageClass     string
+	tags             ObjectTags
+	directive        string
+	headers          ObjectHeaders
+	encryption       SSECustomerKey
+	sourceEncryption SSECustomerKey
+	conditions       WriteConditions
 }
 
 var _ copier = (*smallFileCopier)(nil)
@@ -25,11 +31,22 @@ func (u *smallFileCopier) Copy(ctx context.Context) error {
 	if u.storageClass != "" {
 		req.Header.Set("X-Amz-Storage-Class", u.storageClass)
 	}
+	if len(u.tags) > 0 {
+		setTaggingHeader(req, u.tags)
+		req.Header.Set(taggingDirectiveHeader, "REPLACE")
+	}
+	if u.directive == MetadataDirectiveReplace {
+		req.Header.Set(metadataDirectiveHeader, MetadataDirectiveReplace)
+		u.headers.setHeaders(req)
+	}
+	u.encryption.setHeaders(req)
+	u.sourceEncryption.setCopySourceHeaders(req)
+	u.conditions.setHeaders(req)
 
 	resp, err := SendRequest(ctx, req, u.cfg)
 	if err != nil {
 		return err
 	}
 
-	return ExtractErr(resp, req)
+	return u.conditions.checkError(u.dst, ExtractErr(resp, req))
 }
@@ -12,10 +12,11 @@ import (
 )
 
 type smallFileDownloader struct {
-	cfg     Config
-	src     mgcSchemaPkg.URI
-	dst     mgcSchemaPkg.FilePath
-	version string
+	cfg        Config
+	src        mgcSchemaPkg.URI
+	dst        mgcSchemaPkg.FilePath
+	version    string
+	encryption SSECustomerKey
 }
 
 var _ downloader = (*smallFileDownloader)(nil)
@@ -25,6 +26,7 @@ func (u *smallFileDownloader) Download(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	u.encryption.setHeaders(req)
 
 	resp, err := SendRequest(ctx, req, u.cfg)
 	if err != nil {
@@ -40,7 +42,7 @@ func (u *smallFileDownloader) Download(ctx context.Context) error {
 	progressReporter.Start()
 	defer progressReporter.End()
 
-	resp.Body = progress_report.NewReporterReader(resp.Body, progressReporter.Report)
+	resp.Body = progress_report.NewLimitedReporterReader(ctx, resp.Body, progressReporter.Report, u.cfg.bandwidthLimiter())
 
 	dir := path.Dir(u.dst.String())
 	if len(dir) != 0 {
@@ -4,31 +4,27 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"io/fs"
 
 	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
 )
 
 type smallFileUploader struct {
-	cfg          Config
-	dst          mgcSchemaPkg.URI
-	mimeType     string
-	fileInfo     fs.FileInfo
-	filePath     mgcSchemaPkg.FilePath
+	cfg     Config
+	dst     mgcSchemaPkg.URI
+	headers ObjectHeaders
+	// returns a new reader of the whole content on each call, so the request can be retried
+	newReader    func() (io.ReadCloser, error)
 	storageClass string
+	tags         ObjectTags
+	encryption   SSECustomerKey
+	checksum     ChecksumAlgorithm
+	conditions   WriteConditions
 }
 
 var _ uploader = (*smallFileUploader)(nil)
 
 func (u *smallFileUploader) Upload(ctx context.Context) error {
-
-	newReader := func() (io.ReadCloser, error) {
-		reader, err := readContent(u.filePath, u.fileInfo)
-		if err != nil {
-			return nil, fmt.Errorf("error reading file: %w", err)
-		}
-		return reader, nil
-	}
+	newReader := u.newReader
 
 	var err error
 	// TODO: This will only work sometimes... sometimes the error won't be nil but it won't
@@ -39,11 +35,28 @@ func (u *smallFileUploader) Upload(ctx context.Context) error {
 		return err
 	}
 
-	req.Header.Set("Content-Type", u.mimeType)
+	u.headers.setHeaders(req)
 
 	if u.storageClass != "" {
 		req.Header.Set("X-Amz-Storage-Class", u.storageClass)
 	}
+	setTaggingHeader(req, u.tags)
+	u.encryption.setHeaders(req)
+	u.conditions.setHeaders(req)
+
+	var sum []byte
+	if u.checksum != ChecksumNone {
+		reader, err := newReader()
+		if err != nil {
+			return err
+		}
+		sum, err = hashReader(u.checksum, reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("error computing checksum: %w", err)
+		}
+		u.checksum.setHeaders(req, sum)
+	}
 
 	resp, err := SendRequest(ctx, req, u.cfg)
 	if err != nil {
@@ -51,5 +64,9 @@ func (u *smallFileUploader) Upload(ctx context.Context) error {
 	}
 
 	err = ExtractErr(resp, req)
-	return err
+	if err != nil {
+		return u.conditions.checkError(u.dst, err)
+	}
+
+	return u.checksum.verifyResponse(u.dst, 0, resp, sum, u.encryption)
 }
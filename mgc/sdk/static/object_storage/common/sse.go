@@ -0,0 +1,129 @@
+package common
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
+)
+
+const (
+	sseCustomerAlgorithm = "AES256"
+	sseCustomerKeyLen    = 32
+
+	sseCustomerKeyEnvPrefix  = "env:"
+	sseCustomerKeyFilePrefix = "file:"
+
+	sseCustomerAlgorithmHeader = "X-Amz-Server-Side-Encryption-Customer-Algorithm"
+	sseCustomerKeyHeader       = "X-Amz-Server-Side-Encryption-Customer-Key"
+	sseCustomerKeyMD5Header    = "X-Amz-Server-Side-Encryption-Customer-Key-Md5"
+
+	copySourceSSECustomerAlgorithmHeader = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Algorithm"
+	copySourceSSECustomerKeyHeader       = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key"
+	copySourceSSECustomerKeyMD5Header    = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key-Md5"
+)
+
+// Resolved SSE-C key, the nil value means no encryption.
+//
+// The key is redacted when marshalled, unless sensitive logging is enabled
+type SSECustomerKey []byte
+
+func (k SSECustomerKey) setHeaders(req *http.Request) {
+	k.setHeadersWithNames(req, sseCustomerAlgorithmHeader, sseCustomerKeyHeader, sseCustomerKeyMD5Header)
+}
+
+// Headers to read the source object of a copy
+func (k SSECustomerKey) setCopySourceHeaders(req *http.Request) {
+	k.setHeadersWithNames(req, copySourceSSECustomerAlgorithmHeader, copySourceSSECustomerKeyHeader, copySourceSSECustomerKeyMD5Header)
+}
+
+func (k SSECustomerKey) setHeadersWithNames(req *http.Request, algorithmHeader, keyHeader, md5Header string) {
+	if len(k) == 0 {
+		return
+	}
+	sum := md5.Sum(k)
+	req.Header.Set(algorithmHeader, sseCustomerAlgorithm)
+	req.Header.Set(keyHeader, base64.StdEncoding.EncodeToString(k))
+	req.Header.Set(md5Header, base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+func (k SSECustomerKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mgcHttpPkg.LogSensitive(k))
+}
+
+var _ json.Marshaler = (SSECustomerKey)(nil)
+
+func parseSSECustomerKey(value string) (SSECustomerKey, error) {
+	switch {
+	case value == "":
+		return nil, nil
+
+	case strings.HasPrefix(value, sseCustomerKeyEnvPrefix):
+		name := strings.TrimPrefix(value, sseCustomerKeyEnvPrefix)
+		envValue, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %q is not set", name)
+		}
+		return decodeSSECustomerKey([]byte(envValue))
+
+	case strings.HasPrefix(value, sseCustomerKeyFilePrefix):
+		data, err := os.ReadFile(strings.TrimPrefix(value, sseCustomerKeyFilePrefix))
+		if err != nil {
+			return nil, err
+		}
+		return decodeSSECustomerKey(data)
+
+	default:
+		return decodeSSECustomerKey([]byte(value))
+	}
+}
+
+// Accepts the raw 32 bytes or their base64 encoding. Surrounding whitespace is ignored
+// for the encoded form, as files and variables often end with a new line
+func decodeSSECustomerKey(data []byte) (SSECustomerKey, error) {
+	if len(data) == sseCustomerKeyLen {
+		return SSECustomerKey(data), nil
+	}
+
+	trimmed := strings.TrimSpace(string(data))
+	if len(trimmed) == sseCustomerKeyLen {
+		return SSECustomerKey(trimmed), nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(trimmed)
+	if err != nil || len(key) != sseCustomerKeyLen {
+		return nil, fmt.Errorf("key must have %d bytes, raw or base64 encoded", sseCustomerKeyLen)
+	}
+	return SSECustomerKey(key), nil
+}
+
+type EncryptionParams struct {
+	SSECustomerKey string `json:"sse_customer_key,omitempty" jsonschema_description:"256-bit key for server-side encryption with customer-provided keys (SSE-C). Given inline as 32 raw bytes or base64, from a file as 'file:<path>' or from an environment variable as 'env:<NAME>'"`
+}
+
+func (p EncryptionParams) CustomerKey() (SSECustomerKey, error) {
+	key, err := parseSSECustomerKey(p.SSECustomerKey)
+	if err != nil {
+		return nil, core.UsageError{Err: fmt.Errorf("invalid sse_customer_key: %w", err)}
+	}
+	return key, nil
+}
+
+// Key of the source object of a copy, if it's encrypted with SSE-C
+type CopySourceEncryptionParams struct {
+	SourceSSECustomerKey string `json:"src_sse_customer_key,omitempty" jsonschema_description:"SSE-C key of the source object if it is encrypted with a customer-provided key. Accepts the same formats as sse_customer_key"`
+}
+
+func (p CopySourceEncryptionParams) SourceCustomerKey() (SSECustomerKey, error) {
+	key, err := parseSSECustomerKey(p.SourceSSECustomerKey)
+	if err != nil {
+		return nil, core.UsageError{Err: fmt.Errorf("invalid src_sse_customer_key: %w", err)}
+	}
+	return key, nil
+}
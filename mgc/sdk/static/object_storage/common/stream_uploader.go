@@ -0,0 +1,189 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"go.uber.org/zap"
+)
+
+// Upload source that reads the content from the standard input
+const StdinPath mgcSchemaPkg.FilePath = "-"
+
+var streamUploaderLogger = utils.NewLazyLoader(func() *zap.SugaredLogger {
+	return logger().Named("streamUploader")
+})
+
+// Uploads content of unknown length, such as the standard input.
+//
+// Content that fits in a single chunk is sent in a single request, otherwise it's sent
+// as a multipart upload. At most one chunk per worker plus the one being read are kept
+// in memory. As the content can't be read again, these uploads can't be resumed
+type streamUploader struct {
+	cfg    Config
+	reader *bufio.Reader
+	dst    mgcSchemaPkg.URI
+	opts   UploadOptions
+}
+
+var _ uploader = (*streamUploader)(nil)
+
+func newStreamUploader(cfg Config, reader io.Reader, dst mgcSchemaPkg.URI, opts UploadOptions) *streamUploader {
+	return &streamUploader{cfg: cfg, reader: bufio.NewReader(reader), dst: dst, opts: opts}
+}
+
+// Reads up to size bytes, last is true if the content ended
+func (u *streamUploader) readChunk(size int) (data []byte, last bool, err error) {
+	data = make([]byte, size)
+	n, err := io.ReadFull(u.reader, data)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return data[:n], true, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("error reading content: %w", err)
+	}
+
+	// Content ending exactly at the chunk boundary still fits in a single chunk
+	if _, err = u.reader.Peek(1); errors.Is(err, io.EOF) {
+		return data, true, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("error reading content: %w", err)
+	}
+	return data, false, nil
+}
+
+func newBytesReader(data []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+func (u *streamUploader) Upload(ctx context.Context) error {
+	chunkSize := int(u.cfg.chunkSizeInBytes())
+
+	first, last, err := u.readChunk(chunkSize)
+	if err != nil {
+		return err
+	}
+
+	headers := u.opts.Headers
+	if headers.ContentType == "" && u.opts.DetectContentType {
+		headers.ContentType = detectContentType(u.dst.Filename(), newBytesReader(first))
+	}
+
+	if last {
+		streamUploaderLogger().Debugw("content fits in a single request", "size", len(first))
+		small := &smallFileUploader{
+			cfg:          u.cfg,
+			dst:          u.dst,
+			headers:      headers,
+			newReader:    newBytesReader(first),
+			storageClass: u.opts.StorageClass,
+			tags:         u.opts.Tags,
+			encryption:   u.opts.Encryption,
+			checksum:     u.opts.Checksum,
+			conditions:   u.opts.Conditions,
+		}
+		return small.Upload(ctx)
+	}
+
+	big := &bigFileUploader{
+		cfg:          u.cfg,
+		dst:          u.dst,
+		headers:      headers,
+		workerN:      u.cfg.Workers,
+		storageClass: u.opts.StorageClass,
+		tags:         u.opts.Tags,
+		encryption:   u.opts.Encryption,
+		checksum:     u.opts.Checksum,
+		conditions:   u.opts.Conditions,
+	}
+	return u.uploadMultipart(ctx, big, first)
+}
+
+func (u *streamUploader) uploadMultipart(ctx context.Context, big *bigFileUploader, first []byte) (err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer func() {
+		if err == nil {
+			err = ctx.Err()
+		}
+		cancel(err)
+	}()
+
+	uploadId, err := big.getUploadId(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The content is gone once read, there's nothing to resume: always abort on failure
+	defer func() {
+		if err != nil {
+			big.abort(ctx, uploadId)
+		}
+	}()
+
+	stopCancelOnInterrupt := cancelOnInterrupt(cancel)
+	defer stopCancelOnInterrupt()
+
+	chunkChan := u.produceChunks(ctx, cancel, first)
+	partChan := pipeline.ParallelProcess(ctx, big.workerN, chunkChan, big.createPartSenderProcessor(cancel, 0, uploadId), nil)
+
+	parts, err := pipeline.SliceItemConsumer[[]completionPart](ctx, partChan)
+	if err != nil {
+		return err
+	}
+
+	if err = context.Cause(ctx); err != nil {
+		return err
+	}
+
+	return big.sendCompletionRequest(ctx, parts, uploadId)
+}
+
+// The channel is not buffered, so reading blocks while all the workers are busy
+func (u *streamUploader) produceChunks(ctx context.Context, cancel context.CancelCauseFunc, first []byte) <-chan pipeline.ReadableChunk {
+	ch := make(chan pipeline.ReadableChunk)
+	chunkSize := int(u.cfg.chunkSizeInBytes())
+
+	go func() {
+		defer close(ch)
+
+		data, last, offset := first, false, int64(0)
+		for {
+			chunk := pipeline.ReadableChunk{Reader: bytes.NewReader(data), StartOffset: offset}
+			if u.opts.Checksum != ChecksumNone {
+				chunk.Checksum, chunk.Err = hashReader(u.opts.Checksum, bytes.NewReader(data))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- chunk:
+			}
+
+			if last {
+				return
+			}
+
+			offset += int64(len(data))
+			var err error
+			data, last, err = u.readChunk(chunkSize)
+			if err != nil {
+				cancel(err)
+				return
+			}
+			if len(data) == 0 {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
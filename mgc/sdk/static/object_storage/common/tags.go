@@ -0,0 +1,264 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	taggingHeader          = "X-Amz-Tagging"
+	taggingDirectiveHeader = "X-Amz-Tagging-Directive"
+)
+
+var tagsLogger = utils.NewLazyLoader(func() *zap.SugaredLogger {
+	return logger().Named("tags")
+})
+
+type ObjectTag struct {
+	Key   string `xml:"Key" json:"key"`
+	Value string `xml:"Value" json:"value"`
+}
+
+type objectTagging struct {
+	XMLName xml.Name    `xml:"Tagging"`
+	XMLNS   string      `xml:"xmlns,attr,omitempty"`
+	Tags    []ObjectTag `xml:"TagSet>Tag"`
+}
+
+// Tags given as key=value pairs
+type ObjectTags map[string]string
+
+func (t ObjectTags) sortedKeys() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (t ObjectTags) asTagSet() []ObjectTag {
+	tags := make([]ObjectTag, 0, len(t))
+	for _, k := range t.sortedKeys() {
+		tags = append(tags, ObjectTag{Key: k, Value: t[k]})
+	}
+	return tags
+}
+
+// URL query encoded, as expected by the x-amz-tagging header
+func (t ObjectTags) headerValue() string {
+	values := url.Values{}
+	for k, v := range t {
+		values.Set(k, v)
+	}
+	return strings.ReplaceAll(values.Encode(), "+", "%20")
+}
+
+// Returns true if all the given tags are present in the tag set with the same value
+func (t ObjectTags) matches(tags []ObjectTag) bool {
+	for k, v := range t {
+		found := false
+		for _, tag := range tags {
+			if tag.Key == k && tag.Value == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func setTaggingHeader(req *http.Request, tags ObjectTags) {
+	if len(tags) == 0 {
+		return
+	}
+	req.Header.Set(taggingHeader, tags.headerValue())
+}
+
+func newObjectTaggingRequest(ctx context.Context, cfg Config, method string, dst mgcSchemaPkg.URI, version string, body []byte) (*http.Request, error) {
+	url, err := BuildBucketHostWithPathURL(cfg, NewBucketNameFromURI(dst), dst.Path())
+	if err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+
+	query := url.Query()
+	query.Set("tagging", "")
+	if version != "" {
+		query.Set("versionId", version)
+	}
+	url.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+		req.ContentLength = int64(len(body))
+	}
+
+	return req, nil
+}
+
+func GetObjectTags(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, version string) ([]ObjectTag, error) {
+	req, err := newObjectTaggingRequest(ctx, cfg, http.MethodGet, dst, version, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := SendRequest(ctx, req, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := UnwrapResponse[objectTagging](resp, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Tags == nil {
+		result.Tags = []ObjectTag{}
+	}
+	return result.Tags, nil
+}
+
+// Replaces all the tags of the object
+func SetObjectTags(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, version string, tags ObjectTags) error {
+	body, err := xml.Marshal(objectTagging{
+		XMLNS: "http://s3.amazonaws.com/doc/2006-03-01/",
+		Tags:  tags.asTagSet(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := newObjectTaggingRequest(ctx, cfg, http.MethodPut, dst, version, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := SendRequest(ctx, req, cfg)
+	if err != nil {
+		return err
+	}
+
+	return ExtractErr(resp, req)
+}
+
+// Tags of the object as key=value pairs, as expected when setting them
+func getObjectTagsMap(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, version string) (ObjectTags, error) {
+	tagSet, err := GetObjectTags(ctx, cfg, dst, version)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(ObjectTags, len(tagSet))
+	for _, tag := range tagSet {
+		tags[tag.Key] = tag.Value
+	}
+	return tags, nil
+}
+
+func DeleteObjectTags(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, version string) error {
+	req, err := newObjectTaggingRequest(ctx, cfg, http.MethodDelete, dst, version, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := SendRequest(ctx, req, cfg)
+	if err != nil {
+		return err
+	}
+
+	return ExtractErr(resp, req)
+}
+
+type TagFilters struct {
+	FilterTags ObjectTags `json:"filter_tag,omitempty" jsonschema:"description=Only include objects with all these tags as key=value pairs"`
+}
+
+// Excludes objects that don't have all the given tags, others are left as unknown.
+//
+// Tags are fetched from the server, one request per object
+type FilterObjectTags struct {
+	Cfg           Config
+	Bucket        BucketName
+	Tags          ObjectTags
+	CancelOnError func(error)
+}
+
+func (r FilterObjectTags) Filter(ctx context.Context, entry pipeline.WalkDirEntry) pipeline.FilterStatus {
+	if err := entry.Err(); err != nil {
+		if r.CancelOnError != nil {
+			r.CancelOnError(err)
+		}
+		return pipeline.FilterExclude
+	}
+
+	if _, ok := entry.DirEntry().(*BucketContent); !ok {
+		return pipeline.FilterUnknown
+	}
+
+	dst := r.Bucket.AsURI().JoinPath(entry.Path())
+	tags, err := GetObjectTags(ctx, r.Cfg, dst, "")
+	if err != nil {
+		if r.CancelOnError != nil {
+			r.CancelOnError(&ObjectError{Url: dst, Err: fmt.Errorf("unable to get tags: %w", err)})
+		}
+		return pipeline.FilterExclude
+	}
+
+	if !r.Tags.matches(tags) {
+		tagsLogger().Debugw("excluded object not matching tags", "uri", dst, "tags", tags, "filter", r.Tags)
+		return pipeline.FilterExclude
+	}
+	return pipeline.FilterUnknown
+}
+
+func (r FilterObjectTags) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"Tags": r.Tags})
+}
+
+var _ pipeline.FilterRule[pipeline.WalkDirEntry] = (*FilterObjectTags)(nil)
+var _ json.Marshaler = (*FilterObjectTags)(nil)
+
+// Excludes entries not matching the tags, fetching them with cfg.Workers parallel requests.
+// The order of the entries is not kept
+func ApplyTagFilters(ctx context.Context, cfg Config, bucketName BucketName, entries <-chan pipeline.WalkDirEntry, tags ObjectTags, cancel context.CancelCauseFunc) <-chan pipeline.WalkDirEntry {
+	if len(tags) == 0 {
+		return entries
+	}
+
+	filter := FilterObjectTags{
+		Cfg:           cfg,
+		Bucket:        bucketName,
+		Tags:          tags,
+		CancelOnError: cancel,
+	}
+	return pipeline.ParallelProcess(ctx, cfg.Workers, entries, func(ctx context.Context, entry pipeline.WalkDirEntry) (pipeline.WalkDirEntry, pipeline.ProcessStatus) {
+		if filter.Filter(ctx, entry) == pipeline.FilterExclude {
+			return nil, pipeline.ProcessSkip
+		}
+		return entry, pipeline.ProcessOutput
+	}, nil)
+}
@@ -0,0 +1,93 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func TestCopyTags(t *testing.T) {
+	defer common.SetMinChunkSize(testChunkSize)()
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	sourceTags := common.ObjectTags{"team": "storage", "env": "test"}
+	server.PutObject("bucket", "big", randomData(t, 2*testChunkSize+1))
+	server.PutObject("bucket", "small", []byte("small"))
+	for _, key := range []string{"big", "small"} {
+		if err := common.SetObjectTags(ctx, cfg, mgcSchemaPkg.URI("s3://bucket/"+key), "", sourceTags); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		tags     common.ObjectTags
+		expected common.ObjectTags
+	}{
+		{name: "kept", expected: sourceTags},
+		{name: "replaced", tags: common.ObjectTags{"env": "prod"}, expected: common.ObjectTags{"env": "prod"}},
+	} {
+		for _, key := range []string{"big", "small"} {
+			dst := mgcSchemaPkg.URI(fmt.Sprintf("s3://bucket/%s/%s", tc.name, key))
+			copier, err := common.NewCopier(ctx, cfg, mgcSchemaPkg.URI("s3://bucket/"+key), dst, "", common.CopyOptions{Tags: tc.tags})
+			if err != nil {
+				t.Fatalf("NewCopier() failed: %s", err)
+			}
+			if err = copier.Copy(ctx); err != nil {
+				t.Fatalf("Copy() failed: %s", err)
+			}
+
+			stored, _ := server.Object("bucket", tc.name+"/"+key)
+			if !reflect.DeepEqual(common.ObjectTags(stored.Tags), tc.expected) {
+				t.Errorf("%s %s: expected tags %v, got %v", tc.name, key, tc.expected, stored.Tags)
+			}
+		}
+	}
+}
+
+func TestDeleteAllFilteredByTags(t *testing.T) {
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	tagged := map[string]bool{}
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("obj-%d", i)
+		server.PutObject("bucket", key, []byte(key))
+		if i%2 == 0 {
+			if err := common.SetObjectTags(ctx, cfg, mgcSchemaPkg.URI("s3://bucket/"+key), "", common.ObjectTags{"expired": "true"}); err != nil {
+				t.Fatal(err)
+			}
+			tagged[key] = true
+		}
+	}
+
+	err := common.DeleteAllObjectsInBucket(ctx, common.DeleteAllObjectsInBucketParams{
+		BucketName: "bucket",
+		BatchSize:  1000,
+		TagFilters: common.TagFilters{FilterTags: common.ObjectTags{"expired": "true"}},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("DeleteAllObjectsInBucket() failed: %s", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("obj-%d", i)
+		if _, ok := server.Object("bucket", key); ok == tagged[key] {
+			t.Errorf("%q: expected deleted=%v", key, tagged[key])
+		}
+	}
+	if n := server.CountRequests(http.MethodGet, "tagging"); n != 10 {
+		t.Errorf("expected the tags of each object to be fetched once, got %d requests", n)
+	}
+}
@@ -0,0 +1,395 @@
+package common_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+const testChunkSize = 1024 * 1024
+
+func randomData(t *testing.T, size int) []byte {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func writeTempFile(t *testing.T, data []byte) mgcSchemaPkg.FilePath {
+	name := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(name, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return mgcSchemaPkg.FilePath(name)
+}
+
+func upload(t *testing.T, ctx context.Context, cfg common.Config, data []byte, dst mgcSchemaPkg.URI, opts common.UploadOptions) {
+	uploader, err := common.NewUploader(cfg, writeTempFile(t, data), dst, opts)
+	if err != nil {
+		t.Fatalf("NewUploader() failed: %s", err)
+	}
+	if err = uploader.Upload(ctx); err != nil {
+		t.Fatalf("Upload() failed: %s", err)
+	}
+}
+
+func download(t *testing.T, ctx context.Context, cfg common.Config, src mgcSchemaPkg.URI, opts common.DownloadOptions) []byte {
+	dst := mgcSchemaPkg.FilePath(filepath.Join(t.TempDir(), "downloaded"))
+	downloader, err := common.NewDownloader(ctx, cfg, src, dst, "", opts)
+	if err != nil {
+		t.Fatalf("NewDownloader() failed: %s", err)
+	}
+	if err = downloader.Download(ctx); err != nil {
+		t.Fatalf("Download() failed: %s", err)
+	}
+	data, err := os.ReadFile(dst.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestMultipartUploadAndDownload(t *testing.T) {
+	defer common.SetMinChunkSize(testChunkSize)()
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	tests := []struct {
+		name     string
+		checksum common.ChecksumAlgorithm
+		key      common.SSECustomerKey
+	}{
+		{name: "plain"},
+		{name: "md5", checksum: common.ChecksumMD5},
+		{name: "crc32c", checksum: common.ChecksumCRC32C},
+		{name: "sha256", checksum: common.ChecksumSHA256},
+		{name: "sse-c", key: common.SSECustomerKey(bytes.Repeat([]byte("k"), 32))},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data := randomData(t, 3*testChunkSize+123)
+			dst := mgcSchemaPkg.URI("s3://bucket/" + tc.name)
+
+			upload(t, ctx, cfg, data, dst, common.UploadOptions{Checksum: tc.checksum, Encryption: tc.key})
+
+			stored, ok := server.Object("bucket", tc.name)
+			if !ok {
+				t.Fatal("object was not created")
+			}
+			if stored.Parts != 4 {
+				t.Errorf("expected 4 parts, got %d", stored.Parts)
+			}
+			if !bytes.Equal(stored.Data, data) {
+				t.Error("stored content differs from the uploaded file")
+			}
+			if server.UploadCount("bucket") != 0 {
+				t.Error("multipart upload was not completed")
+			}
+
+			downloaded := download(t, ctx, cfg, dst, common.DownloadOptions{Checksum: tc.checksum, Encryption: tc.key})
+			if !bytes.Equal(downloaded, data) {
+				t.Error("downloaded content differs from the uploaded file")
+			}
+		})
+	}
+}
+
+func TestSmallUploadWithChecksum(t *testing.T) {
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	data := []byte("hello world")
+	upload(t, ctx, cfg, data, "s3://bucket/dir/hello.txt", common.UploadOptions{
+		Checksum: common.ChecksumSHA256,
+		Tags:     common.ObjectTags{"env": "test"},
+		Headers:  common.ObjectHeaders{ContentType: "text/plain", Metadata: map[string]string{"owner": "me"}},
+	})
+
+	stored, ok := server.Object("bucket", "dir/hello.txt")
+	if !ok {
+		t.Fatal("object was not created")
+	}
+	if !bytes.Equal(stored.Data, data) || stored.Parts != 0 {
+		t.Errorf("unexpected object: %+v", stored)
+	}
+	if stored.Header.Get("Content-Type") != "text/plain" || stored.Header.Get("X-Amz-Meta-Owner") != "me" {
+		t.Errorf("headers were not stored: %v", stored.Header)
+	}
+	if stored.Tags["env"] != "test" {
+		t.Errorf("tags were not stored: %v", stored.Tags)
+	}
+
+	downloaded := download(t, ctx, cfg, "s3://bucket/dir/hello.txt", common.DownloadOptions{Checksum: common.ChecksumSHA256})
+	if !bytes.Equal(downloaded, data) {
+		t.Errorf("expected %q, got %q", data, downloaded)
+	}
+}
+
+func TestUploadFromStdin(t *testing.T) {
+	defer common.SetMinChunkSize(testChunkSize)()
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+
+	for _, tc := range []struct {
+		name  string
+		size  int
+		parts int
+	}{
+		{name: "empty", size: 0},
+		{name: "small", size: testChunkSize - 1},
+		{name: "exact", size: testChunkSize},
+		{name: "multipart", size: 2*testChunkSize + 1, parts: 3},
+	} {
+		data := randomData(t, tc.size)
+		f, err := os.Open(writeTempFile(t, data).String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Stdin = f
+
+		uploader, err := common.NewUploader(cfg, common.StdinPath, mgcSchemaPkg.URI("s3://bucket/"+tc.name), common.UploadOptions{})
+		if err != nil {
+			t.Fatalf("NewUploader() failed: %s", err)
+		}
+		err = uploader.Upload(ctx)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: Upload() failed: %s", tc.name, err)
+		}
+
+		stored, ok := server.Object("bucket", tc.name)
+		if !ok || !bytes.Equal(stored.Data, data) {
+			t.Errorf("%s: uploaded content differs from the standard input", tc.name)
+		}
+		if stored.Parts != tc.parts {
+			t.Errorf("%s: expected %d parts, got %d", tc.name, tc.parts, stored.Parts)
+		}
+	}
+}
+
+// Flips the first byte of every object downloaded through it
+type corruptingTransport struct{}
+
+func (corruptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		data[0] ^= 0xff
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+func TestDownloadChecksumMismatchRemovesFile(t *testing.T) {
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	upload(t, ctx, cfg, []byte("hello world"), "s3://bucket/hello.txt", common.UploadOptions{Checksum: common.ChecksumSHA256})
+
+	ctx = mgcHttpPkg.NewClientContext(ctx, mgcHttpPkg.NewClient(corruptingTransport{}))
+	dst := mgcSchemaPkg.FilePath(filepath.Join(t.TempDir(), "downloaded"))
+	downloader, err := common.NewDownloader(ctx, cfg, "s3://bucket/hello.txt", dst, "", common.DownloadOptions{Checksum: common.ChecksumSHA256})
+	if err != nil {
+		t.Fatalf("NewDownloader() failed: %s", err)
+	}
+	if err = downloader.Download(ctx); !errors.As(err, new(*common.ChecksumMismatchError)) {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if _, err = os.Stat(dst.String()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("corrupt file must be removed, got %v", err)
+	}
+}
+
+func TestMultipartCopy(t *testing.T) {
+	defer common.SetMinChunkSize(testChunkSize)()
+	server := s3test.NewServer(t)
+	server.CreateBucket("src")
+	server.CreateBucket("dst")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	data := randomData(t, 2*testChunkSize+1)
+	server.PutObject("src", "big", data)
+	server.PutObject("src", "small", []byte("small"))
+
+	for _, key := range []string{"big", "small"} {
+		copier, err := common.NewCopier(ctx, cfg, mgcSchemaPkg.URI("s3://src/"+key), mgcSchemaPkg.URI("s3://dst/copy/"+key), "", common.CopyOptions{
+			Headers: common.ObjectHeaders{ContentType: "application/x-test"},
+		})
+		if err != nil {
+			t.Fatalf("NewCopier() failed: %s", err)
+		}
+		if err = copier.Copy(ctx); err != nil {
+			t.Fatalf("Copy() failed: %s", err)
+		}
+	}
+
+	big, ok := server.Object("dst", "copy/big")
+	if !ok || !bytes.Equal(big.Data, data) || big.Parts != 3 {
+		t.Errorf("big object was not copied in 3 parts: ok=%v parts=%d", ok, big.Parts)
+	}
+	small, ok := server.Object("dst", "copy/small")
+	if !ok || string(small.Data) != "small" || small.Parts != 0 {
+		t.Errorf("small object was not copied: %+v", small)
+	}
+	for _, obj := range []s3test.Object{big, small} {
+		if obj.Header.Get("Content-Type") != "application/x-test" {
+			t.Errorf("headers were not replaced: %v", obj.Header)
+		}
+	}
+	if n := server.CountRequests(http.MethodPut, "partNumber"); n != 3 {
+		t.Errorf("expected 3 UploadPartCopy requests, got %d", n)
+	}
+}
+
+func TestMultipartConditionalWrites(t *testing.T) {
+	defer common.SetMinChunkSize(testChunkSize)()
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	data := randomData(t, 2*testChunkSize+1)
+	server.PutObject("bucket", "existing", []byte("existing"))
+	server.PutObject("bucket", "big", data)
+	exists := common.WriteConditions{IfNoneMatch: "*"}
+
+	uploader, err := common.NewUploader(cfg, writeTempFile(t, data), "s3://bucket/existing", common.UploadOptions{Conditions: exists})
+	if err != nil {
+		t.Fatalf("NewUploader() failed: %s", err)
+	}
+	if err = uploader.Upload(ctx); !errors.As(err, new(*common.PreconditionFailedError)) {
+		t.Errorf("expected precondition error, got %v", err)
+	}
+
+	copier, err := common.NewCopier(ctx, cfg, "s3://bucket/big", "s3://bucket/existing", "", common.CopyOptions{Conditions: exists})
+	if err != nil {
+		t.Fatalf("NewCopier() failed: %s", err)
+	}
+	if err = copier.Copy(ctx); !errors.As(err, new(*common.PreconditionFailedError)) {
+		t.Errorf("expected precondition error, got %v", err)
+	}
+
+	if stored, _ := server.Object("bucket", "existing"); string(stored.Data) != "existing" {
+		t.Error("object must not be replaced")
+	}
+	if n := server.UploadCount("bucket"); n != 0 {
+		t.Errorf("rejected multipart uploads must be aborted, %d left", n)
+	}
+
+	upload(t, ctx, cfg, data, "s3://bucket/new", common.UploadOptions{Conditions: exists})
+	if _, ok := server.Object("bucket", "new"); !ok {
+		t.Error("object must be uploaded when it doesn't exist")
+	}
+}
+
+func TestDeleteObjectsInBatches(t *testing.T) {
+	server := s3test.NewServer(t)
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	for _, key := range []string{"a", "b", "c", "dir/d", "dir/e"} {
+		server.PutObject("bucket", key, []byte(key))
+	}
+
+	err := common.DeleteAllObjectsInBucket(ctx, common.DeleteAllObjectsInBucketParams{BucketName: "bucket", BatchSize: 2}, cfg)
+	if err != nil {
+		t.Fatalf("DeleteAllObjectsInBucket() failed: %s", err)
+	}
+
+	for _, key := range []string{"a", "b", "c", "dir/d", "dir/e"} {
+		if _, ok := server.Object("bucket", key); ok {
+			t.Errorf("%q was not deleted", key)
+		}
+		if n := server.VersionCount("bucket", key); n != 2 {
+			t.Errorf("expected the version and a delete marker for %q, got %d versions", key, n)
+		}
+	}
+	if n := server.CountRequests(http.MethodPost, "delete"); n != 3 {
+		t.Errorf("expected 3 batches, got %d", n)
+	}
+}
+
+func TestRejectsInvalidSignature(t *testing.T) {
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/bucket", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s3test.AccessKeyID+"/20240101/br-se1/s3/aws4_request, SignedHeaders=host, Signature=00")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected %d, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	cfg := server.Config()
+	ctx := server.Context(context.Background())
+	if _, err = common.HeadFile(ctx, cfg, "s3://bucket/missing", "", nil); err == nil {
+		t.Error("expected an error for a missing object")
+	}
+	if n := server.CountRequests(http.MethodHead, ""); n != 1 {
+		t.Errorf("signed request was not accepted, got %d HEAD requests", n)
+	}
+}
+
+func TestServerSideCopyIgnoresBandwidthLimit(t *testing.T) {
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	cfg := server.Config()
+	cfg.BandwidthLimit = "1KiB/s"
+
+	// At the limit, the content would take a minute to go through the client
+	data := randomData(t, 60*1024)
+	server.PutObject("bucket", "src", data)
+
+	ctx, cancel := context.WithTimeout(server.Context(context.Background()), 5*time.Second)
+	defer cancel()
+	copier, err := common.NewCopier(ctx, cfg, mgcSchemaPkg.URI("s3://bucket/src"), mgcSchemaPkg.URI("s3://bucket/dst"), "", common.CopyOptions{})
+	if err != nil {
+		t.Fatalf("NewCopier() failed: %s", err)
+	}
+	if err = copier.Copy(ctx); err != nil {
+		t.Fatalf("Copy() failed: %s", err)
+	}
+	if obj, ok := server.Object("bucket", "dst"); !ok || !bytes.Equal(obj.Data, data) {
+		t.Errorf("object was not copied")
+	}
+}
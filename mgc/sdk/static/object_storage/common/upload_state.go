@@ -0,0 +1,190 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core/profile_manager"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/invopop/yaml"
+	"go.uber.org/zap"
+)
+
+const uploadStateDir = "object_storage/uploads"
+
+// Rewriting the whole journal for every part would be quadratic on the number of parts, so
+// parts are persisted at most once per interval and on flush(). Parts missing from the
+// journal are just sent again when resuming
+const uploadJournalSaveInterval = 2 * time.Second
+
+var uploadJournalLogger = utils.NewLazyLoader(func() *zap.SugaredLogger {
+	return logger().Named("uploadJournal")
+})
+
+// Everything needed to resume an interrupted multipart upload
+type uploadState struct {
+	Bucket    string         `json:"bucket"`
+	Key       string         `json:"key"`
+	UploadId  string         `json:"uploadId"`
+	ChunkSize uint64         `json:"chunkSize"`
+	Source    string         `json:"source"`
+	FileSize  int64          `json:"fileSize"`
+	ModTime   time.Time      `json:"modTime"`
+	Parts     map[int]string `json:"parts"`
+}
+
+// Persists the uploadState in the current profile directory, so it survives the process.
+//
+// All methods are safe to be called concurrently and on nil journals (no-op)
+type uploadJournal struct {
+	profile *profile_manager.Profile
+	name    string
+	mu      sync.Mutex
+	state   uploadState
+	savedAt time.Time
+	// parts added since the last save
+	dirty bool
+}
+
+func newUploadJournal(ctx context.Context, src mgcSchemaPkg.FilePath, fileInfo fs.FileInfo, dst mgcSchemaPkg.URI, chunkSize uint64) *uploadJournal {
+	pm := profile_manager.FromContext(ctx)
+	if pm == nil {
+		return nil
+	}
+
+	source, err := filepath.Abs(src.String())
+	if err != nil {
+		source = src.String()
+	}
+
+	state := uploadState{
+		Bucket:    NewBucketNameFromURI(dst).String(),
+		Key:       dst.Path(),
+		ChunkSize: chunkSize,
+		Source:    source,
+		FileSize:  fileInfo.Size(),
+		ModTime:   fileInfo.ModTime().UTC(),
+		Parts:     map[int]string{},
+	}
+
+	sum := sha256.Sum256([]byte(state.Source + "\x00" + state.Bucket + "\x00" + state.Key))
+	return &uploadJournal{
+		profile: pm.Current(),
+		name:    path.Join(uploadStateDir, hex.EncodeToString(sum[:])+".yaml"),
+		state:   state,
+	}
+}
+
+// Loads a previously persisted state. Returns false if there is none or if it doesn't
+// match the current source file (changed size, mtime or chunk size)
+func (j *uploadJournal) load() (state uploadState, ok bool) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	data, err := j.profile.Read(j.name)
+	if err != nil {
+		return
+	}
+
+	if err = yaml.Unmarshal(data, &state); err != nil {
+		uploadJournalLogger().Warnw("ignored invalid upload journal", "name", j.name, "error", err)
+		return
+	}
+
+	if state.UploadId == "" ||
+		state.Bucket != j.state.Bucket ||
+		state.Key != j.state.Key ||
+		state.Source != j.state.Source ||
+		state.ChunkSize != j.state.ChunkSize ||
+		state.FileSize != j.state.FileSize ||
+		!state.ModTime.Equal(j.state.ModTime) {
+		uploadJournalLogger().Debugw("upload journal does not match source", "name", j.name, "journal", state, "current", j.state)
+		return uploadState{}, false
+	}
+
+	if state.Parts == nil {
+		state.Parts = map[int]string{}
+	}
+	return state, true
+}
+
+func (j *uploadJournal) start(uploadId string, parts map[int]string) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.state.UploadId = uploadId
+	j.state.Parts = map[int]string{}
+	for partNumber, etag := range parts {
+		j.state.Parts[partNumber] = etag
+	}
+	return j.save()
+}
+
+func (j *uploadJournal) addPart(partNumber int, etag string) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.state.Parts[partNumber] = etag
+	j.dirty = true
+	if time.Since(j.savedAt) < uploadJournalSaveInterval {
+		return nil
+	}
+	return j.save()
+}
+
+// Persists the parts added since the last save
+func (j *uploadJournal) flush() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.dirty {
+		return nil
+	}
+	return j.save()
+}
+
+func (j *uploadJournal) remove() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.dirty = false
+	if err := j.profile.Delete(j.name); err != nil {
+		uploadJournalLogger().Debugw("failed to remove upload journal", "name", j.name, "error", err)
+	}
+}
+
+// must be called with the lock held
+func (j *uploadJournal) save() error {
+	data, err := yaml.Marshal(j.state)
+	if err != nil {
+		return err
+	}
+	if err = j.profile.Write(j.name, data); err != nil {
+		return err
+	}
+	j.savedAt = time.Now()
+	j.dirty = false
+	return nil
+}
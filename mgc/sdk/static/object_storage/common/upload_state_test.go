@@ -0,0 +1,102 @@
+package common_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+// Uploads src and fails the completion, leaving the multipart upload and its journal behind
+func uploadWithFailedCompletion(t *testing.T, server *s3test.Server, ctx context.Context, cfg common.Config, src mgcSchemaPkg.FilePath) {
+	server.FailRequestsWithParam(http.MethodPost, "uploadId", "bucket", "big", -1)
+	defer server.FailRequestsWithParam(http.MethodPost, "uploadId", "bucket", "big", 0)
+
+	uploader, err := common.NewUploader(cfg, src, "s3://bucket/big", common.UploadOptions{Resume: true})
+	if err != nil {
+		t.Fatalf("NewUploader() failed: %s", err)
+	}
+	if err = uploader.Upload(ctx); err == nil {
+		t.Fatal("expected the completion to fail")
+	}
+	if n := server.UploadCount("bucket"); n != 1 {
+		t.Fatalf("failed upload must be kept to be resumed, got %d uploads", n)
+	}
+}
+
+func resumeUpload(t *testing.T, ctx context.Context, cfg common.Config, src mgcSchemaPkg.FilePath) {
+	uploader, err := common.NewUploader(cfg, src, "s3://bucket/big", common.UploadOptions{Resume: true})
+	if err != nil {
+		t.Fatalf("NewUploader() failed: %s", err)
+	}
+	if err = uploader.Upload(ctx); err != nil {
+		t.Fatalf("Upload() failed: %s", err)
+	}
+}
+
+func TestResumeMultipartUpload(t *testing.T) {
+	defer common.SetMinChunkSize(testChunkSize)()
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	data := randomData(t, 3*testChunkSize+123)
+	src := writeTempFile(t, data)
+	uploadWithFailedCompletion(t, server, ctx, cfg, src)
+
+	sentParts := server.CountRequests(http.MethodPut, "partNumber")
+	resumeUpload(t, ctx, cfg, src)
+
+	if n := server.CountRequests(http.MethodPut, "partNumber") - sentParts; n != 0 {
+		t.Errorf("parts in the journal must not be sent again, sent %d", n)
+	}
+	if n := server.CountRequests(http.MethodPost, "uploads"); n != 1 {
+		t.Errorf("the journaled upload must be resumed, got %d uploads created", n)
+	}
+	if stored, ok := server.Object("bucket", "big"); !ok || !bytes.Equal(stored.Data, data) {
+		t.Error("resumed upload differs from the file")
+	}
+	if n := server.UploadCount("bucket"); n != 0 {
+		t.Errorf("multipart upload was not completed, %d left", n)
+	}
+
+	// The journal is removed once completed, so the next upload starts over
+	resumeUpload(t, ctx, cfg, src)
+	if n := server.CountRequests(http.MethodPost, "uploads"); n != 2 {
+		t.Errorf("expected a new upload after completion, got %d uploads created", n)
+	}
+}
+
+func TestResumeAbortsUnknownUpload(t *testing.T) {
+	defer common.SetMinChunkSize(testChunkSize)()
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	ctx := server.Context(context.Background())
+	cfg := server.Config()
+
+	data := randomData(t, 2*testChunkSize+1)
+	src := writeTempFile(t, data)
+	uploadWithFailedCompletion(t, server, ctx, cfg, src)
+
+	// Listing the parts of the journaled upload fails, so it starts over
+	server.FailRequestsWithParam(http.MethodGet, "uploadId", "bucket", "big", 1)
+	resumeUpload(t, ctx, cfg, src)
+
+	if n := server.CountRequests(http.MethodPost, "uploads"); n != 2 {
+		t.Errorf("expected a new upload, got %d uploads created", n)
+	}
+	if n := server.CountRequests(http.MethodDelete, "uploadId"); n != 1 {
+		t.Errorf("the previous upload must be aborted, got %d aborts", n)
+	}
+	if n := server.UploadCount("bucket"); n != 0 {
+		t.Errorf("no upload must be left behind, %d left", n)
+	}
+	if stored, ok := server.Object("bucket", "big"); !ok || !bytes.Equal(stored.Data, data) {
+		t.Error("uploaded content differs from the file")
+	}
+}
@@ -6,12 +6,13 @@ import (
 	"io"
 	"io/fs"
 	"math"
-	"mime"
 	"net/http"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
+	"github.com/MagaluCloud/magalu/mgc/core/progress_report"
 	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
 )
 
@@ -19,7 +20,53 @@ type uploader interface {
 	Upload(context.Context) error
 }
 
-func NewUploader(cfg Config, src mgcSchemaPkg.FilePath, dst mgcSchemaPkg.URI, storageClass string) (uploader, error) {
+func (o UploadOptions) validate() error {
+	if err := o.Headers.validate(); err != nil {
+		return err
+	}
+	if err := o.Conditions.validate(); err != nil {
+		return err
+	}
+	return o.Checksum.validate()
+}
+
+type UploadOptions struct {
+	StorageClass string
+	Tags         ObjectTags
+	Headers      ObjectHeaders
+	// Detect the Content-Type from the file when Headers.ContentType is empty
+	DetectContentType bool
+	Encryption        SSECustomerKey
+	Checksum          ChecksumAlgorithm
+	// Resume a previous interrupted multipart upload of the same source to the same destination
+	Resume bool
+	// Keep the multipart upload when the user interrupts it, so it can be resumed. Otherwise
+	// it's aborted, leaving no orphaned parts behind
+	KeepOnInterrupt bool
+	// Conditions on the object being replaced, checked when the upload completes
+	Conditions WriteConditions
+	// Upload the source, a symbolic link, as an empty object. The link target must be in the
+	// metadata, see FileAttributes.AddToMetadata()
+	Symlink bool
+}
+
+// If src is StdinPath, the content is read from the standard input, see newStreamUploader()
+func NewUploader(cfg Config, src mgcSchemaPkg.FilePath, dst mgcSchemaPkg.URI, opts UploadOptions) (uploader, error) {
+	if err := cfg.prepareBandwidthLimit(); err != nil {
+		return nil, err
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	if src == StdinPath {
+		return newStreamUploader(cfg, os.Stdin, dst, opts), nil
+	}
+	if opts.Symlink {
+		opts.DetectContentType = false
+		return newStreamUploader(cfg, strings.NewReader(""), dst, opts), nil
+	}
+
 	fileInfo, err := os.Stat(src.String())
 	if err != nil {
 		return nil, fmt.Errorf("error reading object: %w", err)
@@ -29,32 +76,64 @@ func NewUploader(cfg Config, src mgcSchemaPkg.FilePath, dst mgcSchemaPkg.URI, st
 	}
 
 	size := fileInfo.Size()
-	mimeType := mime.TypeByExtension(filepath.Ext(fileInfo.Name()))
+	headers := opts.Headers
+	if headers.ContentType == "" && opts.DetectContentType {
+		headers.ContentType = detectContentType(fileInfo.Name(), func() (io.ReadCloser, error) {
+			return readContent(src, fileInfo)
+		})
+	}
 
 	chunkN := int(math.Ceil(float64(size) / float64(cfg.chunkSizeInBytes())))
 
 	if chunkN > 1 {
 		return &bigFileUploader{
-			cfg:          cfg,
-			dst:          dst,
-			mimeType:     mimeType,
-			fileInfo:     fileInfo,
-			filePath:     src,
-			workerN:      cfg.Workers,
-			storageClass: storageClass,
+			cfg:             cfg,
+			dst:             dst,
+			headers:         headers,
+			fileInfo:        fileInfo,
+			filePath:        src,
+			workerN:         cfg.Workers,
+			storageClass:    opts.StorageClass,
+			tags:            opts.Tags,
+			encryption:      opts.Encryption,
+			checksum:        opts.Checksum,
+			resume:          opts.Resume,
+			keepOnInterrupt: opts.KeepOnInterrupt,
+			conditions:      opts.Conditions,
 		}, nil
 	} else {
 		return &smallFileUploader{
-			cfg:          cfg,
-			dst:          dst,
-			mimeType:     mimeType,
-			fileInfo:     fileInfo,
-			filePath:     src,
-			storageClass: storageClass,
+			cfg:     cfg,
+			dst:     dst,
+			headers: headers,
+			newReader: func() (io.ReadCloser, error) {
+				reader, err := readContent(src, fileInfo)
+				if err != nil {
+					return nil, fmt.Errorf("error reading file: %w", err)
+				}
+				return reader, nil
+			},
+			storageClass: opts.StorageClass,
+			tags:         opts.Tags,
+			encryption:   opts.Encryption,
+			checksum:     opts.Checksum,
+			conditions:   opts.Conditions,
 		}, nil
 	}
 }
 
+// Uploads the content read from reader until EOF, such as an archive being generated.
+// See newStreamUploader()
+func NewStreamUploader(cfg Config, reader io.Reader, dst mgcSchemaPkg.URI, opts UploadOptions) (uploader, error) {
+	if err := cfg.prepareBandwidthLimit(); err != nil {
+		return nil, err
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return newStreamUploader(cfg, reader, dst, opts), nil
+}
+
 func newUploadRequest(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, newReader func() (io.ReadCloser, error)) (*http.Request, error) {
 	host, err := BuildBucketHostWithPath(cfg, NewBucketNameFromURI(dst), dst.Path())
 	if err != nil {
@@ -68,6 +147,12 @@ func newUploadRequest(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, new
 		if err != nil {
 			return nil, err
 		}
+		// Limited as it's sent, after being buffered for retries, see NewRequestBodyWrapperContext()
+		if limiter := cfg.bandwidthLimiter(); limiter != nil {
+			ctx = mgcHttpPkg.NewRequestBodyWrapperContext(ctx, func(ctx context.Context, body io.Reader) io.Reader {
+				return progress_report.NewLimitedReporterReader(ctx, body, nil, limiter)
+			})
+		}
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, string(host), body)
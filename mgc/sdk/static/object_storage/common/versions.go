@@ -0,0 +1,168 @@
+package common
+
+import (
+	"context"
+	"encoding/xml"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"go.uber.org/zap"
+)
+
+const deleteMarkerElement = "DeleteMarker"
+
+var listVersionsLogger = utils.NewLazyLoader(func() *zap.SugaredLogger {
+	return logger().Named("versions")
+})
+
+// A version of an object or a delete marker, as listed by ListVersionsGenerator().
+// Versions of the same key are listed together, latest first
+type ObjectVersionEntry struct {
+	XMLName      xml.Name
+	Key          string `xml:"Key"`
+	VersionId    string `xml:"VersionId"`
+	IsLatest     bool   `xml:"IsLatest"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	ContentSize  int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type listVersionsPageResponse struct {
+	XMLName             xml.Name `xml:"ListVersionsResult"`
+	IsTruncated         bool     `xml:"IsTruncated"`
+	NextKeyMarker       string   `xml:"NextKeyMarker"`
+	NextVersionIdMarker string   `xml:"NextVersionIdMarker"`
+	// Versions and delete markers, in the order they were listed
+	Entries []*ObjectVersionEntry `xml:",any"`
+}
+
+func (v *ObjectVersionEntry) IsDeleteMarker() bool {
+	return v.XMLName.Local == deleteMarkerElement
+}
+
+func (v *ObjectVersionEntry) ModTime() time.Time {
+	modTime, err := time.Parse(time.RFC3339, v.LastModified)
+	if err != nil {
+		listVersionsLogger().Named("ObjectVersionEntry.ModTime()").Errorw("failed to parse time", "err", err, "key", v.Key, "lastModified", v.LastModified)
+		modTime = time.Time{}
+	}
+	return modTime
+}
+
+func (v *ObjectVersionEntry) Mode() fs.FileMode {
+	return utils.FILE_PERMISSION
+}
+
+func (v *ObjectVersionEntry) Size() int64 {
+	return v.ContentSize
+}
+
+func (v *ObjectVersionEntry) Sys() any {
+	return nil
+}
+
+func (v *ObjectVersionEntry) Info() (fs.FileInfo, error) {
+	return v, nil
+}
+
+func (v *ObjectVersionEntry) IsDir() bool {
+	return false
+}
+
+func (v *ObjectVersionEntry) Name() string {
+	return path.Base(v.Key)
+}
+
+func (v *ObjectVersionEntry) Type() fs.FileMode {
+	return utils.FILE_PERMISSION
+}
+
+var _ fs.DirEntry = (*ObjectVersionEntry)(nil)
+var _ fs.FileInfo = (*ObjectVersionEntry)(nil)
+
+func newListVersionsRequest(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, keyMarker, versionIdMarker string) (*http.Request, error) {
+	u, err := BuildBucketHostURL(cfg, NewBucketNameFromURI(dst))
+	if err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+
+	query := u.Query()
+	query.Set("versions", "")
+	query.Set("max-keys", strconv.Itoa(ApiLimitMaxItems))
+	if prefix := dst.Path(); prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if keyMarker != "" {
+		query.Set("key-marker", keyMarker)
+		query.Set("version-id-marker", versionIdMarker)
+	}
+	u.RawQuery = query.Encode()
+
+	return http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+}
+
+// Lists the versions and delete markers of every key starting with the destination path,
+// following the pages. Entries are *ObjectVersionEntry
+func ListVersionsGenerator(ctx context.Context, cfg Config, dst mgcSchemaPkg.URI, onNewPage func(count uint64)) <-chan pipeline.WalkDirEntry {
+	ch := make(chan pipeline.WalkDirEntry)
+	logger := listVersionsLogger().Named("ListVersionsGenerator").With("dst", dst)
+
+	go func() {
+		defer close(ch)
+
+		var keyMarker, versionIdMarker string
+		for {
+			req, err := newListVersionsRequest(ctx, cfg, dst, keyMarker, versionIdMarker)
+			var result listVersionsPageResponse
+			if err == nil {
+				var resp *http.Response
+				if resp, err = SendRequest(ctx, req, cfg); err == nil {
+					result, err = UnwrapResponse[listVersionsPageResponse](resp, req)
+				}
+			}
+			if err != nil {
+				logger.Warnw("list versions request failed", "err", err)
+				select {
+				case <-ctx.Done():
+				case ch <- pipeline.NewSimpleWalkDirEntry[*ObjectVersionEntry](dst.Path(), nil, err):
+				}
+				return
+			}
+
+			entries := make([]*ObjectVersionEntry, 0, len(result.Entries))
+			for _, entry := range result.Entries {
+				if entry.XMLName.Local == "Version" || entry.IsDeleteMarker() {
+					entries = append(entries, entry)
+				}
+			}
+			if onNewPage != nil {
+				onNewPage(uint64(len(entries)))
+			}
+
+			for _, entry := range entries {
+				select {
+				case <-ctx.Done():
+					logger.Debugw("context.Done()", "err", ctx.Err())
+					return
+				case ch <- pipeline.NewSimpleWalkDirEntry(entry.Key, entry, nil):
+				}
+			}
+
+			if !result.IsTruncated || (result.NextKeyMarker == keyMarker && result.NextVersionIdMarker == versionIdMarker) {
+				logger.Info("finished reading versions")
+				return
+			}
+			keyMarker, versionIdMarker = result.NextKeyMarker, result.NextVersionIdMarker
+		}
+	}()
+
+	return ch
+}
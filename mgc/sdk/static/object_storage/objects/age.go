@@ -0,0 +1,29 @@
+package objects
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats accepted by parseAge(), for the descriptions of the commands filtering by age
+const ageFormatsDescription = "Ages are given in days (30d) or as Go durations (12h, 90m), dates as 2024-01-31 or RFC3339."
+
+// Parses ages such as "30d" or "12h" relative to now, or absolute dates such as "2024-01-31"
+func parseAge(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.ParseUint(days, 10, 32); err == nil {
+			return now.AddDate(0, 0, -int(n)), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid age or date %q, expected something like 30d, 12h or 2024-01-31", s)
+}
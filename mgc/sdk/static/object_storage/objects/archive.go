@@ -0,0 +1,138 @@
+package objects
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+)
+
+const (
+	archiveTar   = "tar"
+	archiveTarGz = "tar.gz"
+	archiveZip   = "zip"
+)
+
+var archiveContentTypes = map[string]string{
+	archiveTar:   "application/x-tar",
+	archiveTarGz: "application/gzip",
+	archiveZip:   "application/zip",
+}
+
+// Writes files, one after the other, to an archive stream
+type archiveWriter interface {
+	WriteFile(name string, size int64, mode fs.FileMode, modTime time.Time, reader io.Reader) error
+	WriteSymlink(name string, target string, modTime time.Time) error
+	// Finishes the archive, the underlying writer is not closed
+	Close() error
+}
+
+func validateArchiveFormat(format string) error {
+	if _, ok := archiveContentTypes[format]; !ok {
+		return core.UsageError{Err: fmt.Errorf("invalid archive format %q, expected %s, %s or %s", format, archiveTar, archiveTarGz, archiveZip)}
+	}
+	return nil
+}
+
+func newArchiveWriter(w io.Writer, format string) (archiveWriter, error) {
+	switch format {
+	case archiveTar:
+		return &tarArchiveWriter{tw: tar.NewWriter(w)}, nil
+	case archiveTarGz:
+		gz := gzip.NewWriter(w)
+		return &tarArchiveWriter{tw: tar.NewWriter(gz), gz: gz}, nil
+	case archiveZip:
+		return &zipArchiveWriter{zw: zip.NewWriter(w)}, nil
+	default:
+		return nil, validateArchiveFormat(format)
+	}
+}
+
+type tarArchiveWriter struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+}
+
+func (a *tarArchiveWriter) WriteFile(name string, size int64, mode fs.FileMode, modTime time.Time, reader io.Reader) error {
+	err := a.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     int64(mode.Perm()),
+		ModTime:  modTime,
+		Format:   tar.FormatPAX,
+	})
+	if err != nil {
+		return fmt.Errorf("error writing %q to archive: %w", name, err)
+	}
+	// The size was announced in the header, so the content must match it
+	if _, err = io.CopyN(a.tw, reader, size); err != nil {
+		return fmt.Errorf("error writing %q to archive: %w", name, err)
+	}
+	return nil
+}
+
+func (a *tarArchiveWriter) WriteSymlink(name string, target string, modTime time.Time) error {
+	err := a.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     name,
+		Linkname: target,
+		Mode:     int64(fs.ModePerm),
+		ModTime:  modTime,
+		Format:   tar.FormatPAX,
+	})
+	if err != nil {
+		return fmt.Errorf("error writing %q to archive: %w", name, err)
+	}
+	return nil
+}
+
+func (a *tarArchiveWriter) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	if a.gz != nil {
+		return a.gz.Close()
+	}
+	return nil
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchiveWriter) WriteFile(name string, size int64, mode fs.FileMode, modTime time.Time, reader io.Reader) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime}
+	header.SetMode(mode.Perm())
+	w, err := a.zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("error writing %q to archive: %w", name, err)
+	}
+	if _, err = io.Copy(w, reader); err != nil {
+		return fmt.Errorf("error writing %q to archive: %w", name, err)
+	}
+	return nil
+}
+
+// Zip links are entries with the link mode whose content is the target, as written by Info-ZIP
+func (a *zipArchiveWriter) WriteSymlink(name string, target string, modTime time.Time) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Store, Modified: modTime}
+	header.SetMode(fs.ModeSymlink | fs.ModePerm)
+	w, err := a.zw.CreateHeader(header)
+	if err == nil {
+		_, err = io.WriteString(w, target)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %q to archive: %w", name, err)
+	}
+	return nil
+}
+
+func (a *zipArchiveWriter) Close() error {
+	return a.zw.Close()
+}
@@ -0,0 +1,155 @@
+package objects
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+func readTarGz(t *testing.T, data []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("invalid gzip: %s", err)
+	}
+	return readTar(t, gz)
+}
+
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	files := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatalf("invalid tar: %s", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("invalid tar: %s", err)
+		}
+		files[header.Name] = string(content)
+	}
+}
+
+func readZip(t *testing.T, path string) map[string]string {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("invalid zip: %s", err)
+	}
+	defer zr.Close()
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("invalid zip: %s", err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("invalid zip: %s", err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestUploadDirArchive(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	dir := filepath.Join(t.TempDir(), "site")
+	writeFiles(t, dir, map[string]string{
+		"index.html":     "<html></html>",
+		"css/style.css":  "body {}",
+		"notes/todo.txt": "skip me",
+	})
+
+	result, err := uploadDir(ctx, uploadDirParams{
+		Source:      mgcSchemaPkg.DirPath(dir),
+		Destination: "bucket/backups/",
+		Archive:     archiveTarGz,
+		Filters:     common.Filters{FilterParams: []common.FilterParams{{Exclude: "*.txt"}}},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("uploadDir() failed: %s", err)
+	}
+	if result.URI != "bucket/backups/site.tar.gz" || result.Files != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	stored, ok := server.Object("bucket", "backups/site.tar.gz")
+	if !ok {
+		t.Fatal("archive was not uploaded")
+	}
+	if contentType := stored.Header.Get("Content-Type"); contentType != "application/gzip" {
+		t.Errorf("unexpected Content-Type %q", contentType)
+	}
+	expected := map[string]string{"index.html": "<html></html>", "css/style.css": "body {}"}
+	if files := readTarGz(t, stored.Data); !reflect.DeepEqual(files, expected) {
+		t.Errorf("unexpected archive content: %v", files)
+	}
+
+	if _, err = uploadDir(ctx, uploadDirParams{Source: mgcSchemaPkg.DirPath(dir), Destination: "bucket/site.tar", Archive: archiveTar, Shallow: true}, cfg); err != nil {
+		t.Fatalf("uploadDir() failed: %s", err)
+	}
+	stored, _ = server.Object("bucket", "site.tar")
+	if files := readTar(t, bytes.NewReader(stored.Data)); !reflect.DeepEqual(files, map[string]string{"index.html": "<html></html>"}) {
+		t.Errorf("shallow archives must not include subdirectories, got %v", files)
+	}
+
+	_, err = uploadDir(ctx, uploadDirParams{Source: mgcSchemaPkg.DirPath(dir), Destination: "bucket/", Archive: "rar"}, cfg)
+	if !errors.As(err, new(core.UsageError)) {
+		t.Errorf("expected usage error for an invalid format, got %v", err)
+	}
+}
+
+func TestDownloadAllArchive(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "logs/a.log", []byte("a"))
+	server.PutObject("bucket", "logs/2024/b.log", []byte("b"))
+	server.PutObject("bucket", "logs/c.txt", []byte("c"))
+	server.PutObject("bucket", "other.log", []byte("other"))
+	dir := t.TempDir()
+
+	result, err := downloadAll(ctx, downloadAllObjectsParams{
+		Source:      "bucket/logs",
+		Destination: mgcSchemaPkg.FilePath(dir),
+		Archive:     archiveZip,
+		Filters:     common.Filters{FilterParams: []common.FilterParams{{Exclude: "*.txt"}}},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("downloadAll() failed: %s", err)
+	}
+	zipPath := filepath.Join(dir, "logs.zip")
+	if result.Destination.String() != zipPath {
+		t.Errorf("unexpected destination %q", result.Destination)
+	}
+	expected := map[string]string{"logs/a.log": "a", "logs/2024/b.log": "b"}
+	if files := readZip(t, zipPath); !reflect.DeepEqual(files, expected) {
+		t.Errorf("unexpected archive content: %v", files)
+	}
+
+	tarPath := mgcSchemaPkg.FilePath(filepath.Join(dir, "all.tar.gz"))
+	if _, err = downloadAll(ctx, downloadAllObjectsParams{Source: "bucket", Destination: tarPath, Archive: archiveTarGz}, cfg); err != nil {
+		t.Fatalf("downloadAll() failed: %s", err)
+	}
+	data, err := os.ReadFile(tarPath.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files := readTarGz(t, data); len(files) != 4 || files["other.log"] != "other" {
+		t.Errorf("unexpected archive content: %v", files)
+	}
+}
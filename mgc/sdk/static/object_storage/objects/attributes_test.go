@@ -0,0 +1,240 @@
+//go:build unix
+
+package objects
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+func TestUploadDownloadAttributes(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"build.sh": "make"})
+	src := filepath.Join(dir, "build.sh")
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chmod(src, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(src, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := upload(ctx, uploadParams{Source: mgcSchemaPkg.FilePath(src), Destination: "bucket/build.sh"}, cfg); err != nil {
+		t.Fatalf("upload() failed: %s", err)
+	}
+	stored, _ := server.Object("bucket", "build.sh")
+	if stored.Header.Get("X-Amz-Meta-Mode") != "0750" || stored.Header.Get("X-Amz-Meta-Mtime") != "2020-01-02T03:04:05Z" {
+		t.Errorf("attributes were not stored: %v", stored.Header)
+	}
+	if stored.Header.Get("X-Amz-Meta-Uid") != "" && stored.Header.Get("X-Amz-Meta-Uid") != strconv.Itoa(os.Getuid()) {
+		t.Errorf("unexpected uid %q", stored.Header.Get("X-Amz-Meta-Uid"))
+	}
+
+	dst := filepath.Join(dir, "restored.sh")
+	if _, err := download(ctx, common.DownloadObjectParams{Source: "bucket/build.sh", Destination: mgcSchemaPkg.FilePath(dst)}, cfg); err != nil {
+		t.Fatalf("download() failed: %s", err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 || !info.ModTime().Equal(modTime) {
+		t.Errorf("attributes were not restored: %s %s", info.Mode(), info.ModTime())
+	}
+
+	restore := false
+	plain := filepath.Join(dir, "plain.sh")
+	_, err = download(ctx, common.DownloadObjectParams{
+		Source:                  "bucket/build.sh",
+		Destination:             mgcSchemaPkg.FilePath(plain),
+		RestoreAttributesParams: common.RestoreAttributesParams{RestoreAttributes: &restore},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("download() failed: %s", err)
+	}
+	if info, _ = os.Stat(plain); info.ModTime().Equal(modTime) {
+		t.Error("attributes must not be restored when disabled")
+	}
+
+	preserve := false
+	_, err = upload(ctx, uploadParams{
+		Source:               mgcSchemaPkg.FilePath(src),
+		Destination:          "bucket/plain.sh",
+		FileAttributesParams: common.FileAttributesParams{PreserveAttributes: &preserve},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("upload() failed: %s", err)
+	}
+	if stored, _ = server.Object("bucket", "plain.sh"); stored.Header.Get("X-Amz-Meta-Mtime") != "" {
+		t.Errorf("attributes must not be stored when disabled: %v", stored.Header)
+	}
+}
+
+func TestUploadDirSymlinks(t *testing.T) {
+	for _, symlinks := range []string{common.SymlinksFollow, common.SymlinksSkip, common.SymlinksStore} {
+		t.Run(symlinks, func(t *testing.T) {
+			server, ctx, cfg := newTestServer(t)
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"real.txt": "real", "sub/inner.txt": "inner"})
+			if err := os.Symlink("real.txt", filepath.Join(dir, "link.txt")); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink("sub", filepath.Join(dir, "linkdir")); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := uploadDir(ctx, uploadDirParams{
+				Source:               mgcSchemaPkg.DirPath(dir),
+				Destination:          "bucket/",
+				FileAttributesParams: common.FileAttributesParams{Symlinks: symlinks},
+			}, cfg)
+			if err != nil {
+				t.Fatalf("uploadDir() failed: %s", err)
+			}
+
+			link, uploaded := server.Object("bucket", "link.txt")
+			_, dirUploaded := server.Object("bucket", "linkdir")
+			switch symlinks {
+			case common.SymlinksFollow:
+				if !uploaded || string(link.Data) != "real" || dirUploaded {
+					t.Errorf("links to files must be followed, got %q", link.Data)
+				}
+			case common.SymlinksSkip:
+				if uploaded || dirUploaded {
+					t.Error("links must be skipped")
+				}
+			case common.SymlinksStore:
+				if !uploaded || len(link.Data) != 0 || link.Header.Get("X-Amz-Meta-Symlink-Target") != "real.txt" {
+					t.Errorf("link must be stored as an empty object with its target: %v", link.Header)
+				}
+				if linkDir, _ := server.Object("bucket", "linkdir"); linkDir.Header.Get("X-Amz-Meta-Symlink-Target") != "sub" {
+					t.Error("links to directories must be stored as links")
+				}
+
+				out := t.TempDir()
+				if _, err = downloadAll(ctx, downloadAllObjectsParams{Source: "bucket", Destination: mgcSchemaPkg.FilePath(out)}, cfg); err != nil {
+					t.Fatalf("downloadAll() failed: %s", err)
+				}
+				if info, err := os.Lstat(filepath.Join(out, "link.txt")); err != nil || !info.Mode().IsRegular() || info.Size() != 0 {
+					t.Errorf("links must be downloaded as empty files unless asked: %v", err)
+				}
+
+				out = t.TempDir()
+				_, err = downloadAll(ctx, downloadAllObjectsParams{
+					Source:                  "bucket",
+					Destination:             mgcSchemaPkg.FilePath(out),
+					RestoreAttributesParams: common.RestoreAttributesParams{RestoreSymlinks: true},
+				}, cfg)
+				if err != nil {
+					t.Fatalf("downloadAll() failed: %s", err)
+				}
+				if target, err := os.Readlink(filepath.Join(out, "link.txt")); err != nil || target != "real.txt" {
+					t.Errorf("link was not restored: %q %v", target, err)
+				}
+				if data, err := os.ReadFile(filepath.Join(out, "link.txt")); err != nil || string(data) != "real" {
+					t.Errorf("restored link must point to the restored file: %q %v", data, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncBucketToLocalKeepsAttributes(t *testing.T) {
+	_, ctx, cfg := newTestServer(t)
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		if err := os.Chtimes(filepath.Join(src, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sync(ctx, syncParams{Source: mgcSchemaPkg.URI(src), Destination: "s3://bucket/data"}, cfg); err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+
+	dst := t.TempDir()
+	params := syncParams{Source: "s3://bucket/data", Destination: mgcSchemaPkg.URI(dst)}
+	if _, err := sync(ctx, params, cfg); err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "sub", "b.txt")); err != nil || !info.ModTime().Equal(modTime) {
+		t.Fatalf("modification time was not restored: %v", err)
+	}
+
+	// The restored times are older than the objects, but nothing changed
+	value, err := sync(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	if result := value.(syncResult); result.FilesDownloaded != 0 || result.FilesSkipped != 2 {
+		t.Errorf("expected everything to be skipped: %+v", result)
+	}
+}
+
+func TestDownloadSymlinksStayWithinDestination(t *testing.T) {
+	_, ctx, cfg := newTestServer(t)
+	dir := t.TempDir()
+	links := map[string]string{
+		"absolute":   "/etc",
+		"escape":     "../outside",
+		"deep/up":    "..",
+		"deep/up/ok": "inner",
+		"deep/x/out": "../../..",
+	}
+	// deep/up must be created before the link inside of it
+	for _, name := range []string{"absolute", "escape", "deep/up", "deep/up/ok", "deep/x/out"} {
+		target := links[name]
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+		_, err := upload(ctx, uploadParams{
+			Source:               mgcSchemaPkg.FilePath(path),
+			Destination:          mgcSchemaPkg.URI("bucket/" + name),
+			FileAttributesParams: common.FileAttributesParams{Symlinks: common.SymlinksStore},
+		}, cfg)
+		if err != nil {
+			t.Fatalf("upload() failed: %s", err)
+		}
+	}
+
+	out := t.TempDir()
+	restore := common.RestoreAttributesParams{RestoreSymlinks: true}
+	for _, name := range []string{"absolute", "escape", "deep/x/out"} {
+		dst := mgcSchemaPkg.FilePath(filepath.Join(out, filepath.FromSlash(name)))
+		_, err := download(ctx, common.DownloadObjectParams{Source: mgcSchemaPkg.URI("bucket/" + name), Destination: dst, RestoreAttributesParams: restore}, cfg)
+		if err == nil {
+			t.Errorf("%s: expected the link to %q to be refused", name, links[name])
+		}
+		if _, err := os.Lstat(dst.String()); err == nil {
+			t.Errorf("%s: the link must not be created", name)
+		}
+	}
+
+	// deep/up points back to the destination, writing deep/up/ok would go through it.
+	// One worker, so that the link is restored first
+	cfg.Workers = 1
+	_, err := downloadAll(ctx, downloadAllObjectsParams{
+		Source:                  "bucket/deep/",
+		Destination:             mgcSchemaPkg.FilePath(filepath.Join(out, "deep")),
+		RestoreAttributesParams: restore,
+	}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "through the symbolic link") {
+		t.Errorf("expected writing through a restored link to be refused, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(out, "deep", "ok")); err == nil {
+		t.Error("a file was written through a restored link")
+	}
+}
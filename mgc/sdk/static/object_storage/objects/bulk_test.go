@@ -0,0 +1,131 @@
+package objects
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func readManifest(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("manifest was not written: %s", err)
+	}
+	return string(data)
+}
+
+func TestCopyAllContinueOnError(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.CreateBucket("dst")
+	for _, key := range []string{"a.txt", "b.txt", "c.txt"} {
+		server.PutObject("bucket", key, []byte(key))
+	}
+	server.FailRequests("PUT", "dst", "b.txt", -1)
+
+	_, err := copyAll(ctx, common.CopyAllObjectsParams{Source: "bucket", Destination: "dst"}, cfg)
+	if err == nil || errors.As(err, new(*common.BulkError)) {
+		t.Fatalf("expected the first failure to stop the copy, got %v", err)
+	}
+
+	manifest := filepath.Join(t.TempDir(), "failures.jsonl")
+	params := common.CopyAllObjectsParams{
+		Source:      "bucket",
+		Destination: "dst",
+		BulkParams: common.BulkParams{
+			ContinueOnError: true,
+			Retries:         intPtr(0),
+			Manifest:        mgcSchemaPkg.FilePath(manifest),
+		},
+	}
+	_, err = copyAll(ctx, params, cfg)
+	var bulkErr *common.BulkError
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("expected *BulkError, got %v", err)
+	}
+	if bulkErr.Summary.Succeeded != 2 || bulkErr.Summary.Failed != 1 || bulkErr.Summary.Manifest != manifest {
+		t.Errorf("unexpected summary: %+v", bulkErr.Summary)
+	}
+	if _, ok := server.Object("dst", "c.txt"); !ok {
+		t.Error("objects after the failure must still be copied")
+	}
+	content := readManifest(t, manifest)
+	if strings.Count(content, "\n") != 1 || !strings.Contains(content, `"path":"b.txt"`) {
+		t.Errorf("manifest must only have the failed object, got %q", content)
+	}
+
+	// Fails once, then succeeds when retried
+	server.FailRequests("PUT", "dst", "b.txt", 1)
+	params.Retries = intPtr(1)
+	params.FromManifest = mgcSchemaPkg.FilePath(manifest)
+	params.Manifest = ""
+	result, err := copyAll(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("copyAll() from the manifest failed: %s", err)
+	}
+	if result.Succeeded != 1 || result.Failed != 0 || result.Manifest != "" {
+		t.Errorf("only the failed object must be copied again: %+v", result)
+	}
+	if stored, ok := server.Object("dst", "b.txt"); !ok || string(stored.Data) != "b.txt" {
+		t.Error("failed object was not copied from the manifest")
+	}
+
+	params.Destination = "bucket"
+	params.FromManifest = mgcSchemaPkg.FilePath(manifest)
+	params.Source = "dst"
+	if _, err = copyAll(ctx, params, cfg); err == nil {
+		t.Error("expected error for a manifest of another source")
+	}
+}
+
+func TestUploadDirContinueOnError(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	server.FailRequests("PUT", "bucket", "dir/sub/b.txt", -1)
+
+	manifest := filepath.Join(t.TempDir(), "failures.jsonl")
+	params := uploadDirParams{
+		Source:      mgcSchemaPkg.DirPath(dir),
+		Destination: "bucket/dir",
+		BulkParams: common.BulkParams{
+			ContinueOnError: true,
+			Retries:         intPtr(0),
+			Manifest:        mgcSchemaPkg.FilePath(manifest),
+		},
+	}
+	_, err := uploadDir(ctx, params, cfg)
+	var bulkErr *common.BulkError
+	if !errors.As(err, &bulkErr) || bulkErr.Summary.Succeeded != 1 || bulkErr.Summary.Failed != 1 {
+		t.Fatalf("expected one failed file, got %v", err)
+	}
+	if content := readManifest(t, manifest); !strings.Contains(content, `"path":"sub/b.txt"`) {
+		t.Errorf("failed file is not in the manifest: %q", content)
+	}
+
+	server.FailRequests("PUT", "bucket", "dir/sub/b.txt", 0)
+	params.FromManifest = mgcSchemaPkg.FilePath(manifest)
+	result, err := uploadDir(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("uploadDir() from the manifest failed: %s", err)
+	}
+	if result.Succeeded != 1 {
+		t.Errorf("only the failed file must be uploaded again: %+v", result)
+	}
+	if _, ok := server.Object("bucket", "dir/sub/b.txt"); !ok {
+		t.Error("failed file was not uploaded from the manifest")
+	}
+
+	params.Archive = archiveTar
+	if _, err = uploadDir(ctx, params, cfg); err == nil {
+		t.Error("expected error for a manifest with archive")
+	}
+}
@@ -0,0 +1,34 @@
+package objects
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+var getCat = utils.NewLazyLoader[core.Executor](func() core.Executor {
+	return core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "cat",
+			Description: "Write the contents of an object to the standard output",
+		},
+		cat,
+	)
+})
+
+func cat(ctx context.Context, p common.CatObjectParams, cfg common.Config) (io.ReadCloser, error) {
+	if p.Source.Path() == "" {
+		return nil, core.UsageError{Err: fmt.Errorf("invalid source specified. Please include the object key in addition to the bucket name")}
+	}
+
+	key, err := p.CustomerKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return common.OpenObject(ctx, cfg, p.Source, p.Version, key, p.Range)
+}
@@ -25,7 +25,21 @@ var getCopy = utils.NewLazyLoader[core.Executor](func() core.Executor {
 })
 
 func copy(ctx context.Context, p common.CopyObjectParams, cfg common.Config) (result core.Value, err error) {
-	_, err = common.HeadFile(ctx, cfg, p.Source, p.Version)
+	key, err := p.CustomerKey()
+	if err != nil {
+		return nil, err
+	}
+	srcKey, err := p.SourceCustomerKey()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := p.Endpoints(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = common.HeadFile(ctx, endpoints.Source, p.Source, p.Version, srcKey)
 	if err != nil {
 		return nil, fmt.Errorf("error validating source: %w", err)
 	}
@@ -45,7 +59,15 @@ func copy(ctx context.Context, p common.CopyObjectParams, cfg common.Config) (re
 		fullDstPath = fullDstPath.JoinPath(fileName)
 	}
 
-	copier, err := common.NewCopier(ctx, cfg, p.Source, fullDstPath, p.Version, p.StorageClass)
+	copier, err := common.NewCopierBetween(ctx, endpoints, p.Source, fullDstPath, p.Version, common.CopyOptions{
+		StorageClass:      p.StorageClass,
+		Tags:              p.Tags,
+		Headers:           p.ObjectHeaders,
+		MetadataDirective: p.MetadataDirective,
+		Encryption:        key,
+		SourceEncryption:  srcKey,
+		Conditions:        p.WriteConditions,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
 	"github.com/MagaluCloud/magalu/mgc/core/utils"
 	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
 )
@@ -18,11 +19,20 @@ var getCopyAll = utils.NewLazyLoader[core.Executor](func() core.Executor {
 	)
 
 	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
-		return "template=Copied from {{.src}} to {{.dst}}\n"
+		return "template=Copied {{.succeeded}} objects from {{.src}} to {{.dst}}{{if .skipped}}, {{.skipped}} skipped{{end}}\n"
 	})
 })
 
-func copyAll(ctx context.Context, params common.CopyAllObjectsParams, cfg common.Config) (common.CopyAllObjectsParams, error) {
-	err := common.CopyMultipleFiles(ctx, cfg, params)
-	return params, err
+type copyAllResult struct {
+	Source             mgcSchemaPkg.URI `json:"src"`
+	Destination        mgcSchemaPkg.URI `json:"dst"`
+	common.BulkSummary `json:",squash"` // nolint
+}
+
+func copyAll(ctx context.Context, params common.CopyAllObjectsParams, cfg common.Config) (result copyAllResult, err error) {
+	summary, err := common.CopyMultipleFilesWithSummary(ctx, cfg, params)
+	if err != nil {
+		return result, err
+	}
+	return copyAllResult{Source: params.Source, Destination: params.Destination, BulkSummary: summary}, nil
 }
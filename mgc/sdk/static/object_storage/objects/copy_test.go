@@ -0,0 +1,124 @@
+package objects
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/config"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+// Counts the requests to the bucket, by method and signature
+func countBucketRequests(server *s3test.Server, method, bucket, accessKeyID, region string) int {
+	count := 0
+	for _, req := range server.Requests() {
+		if req.Method == method && req.Bucket == bucket && req.AccessKeyID == accessKeyID && req.Region == region {
+			count++
+		}
+	}
+	return count
+}
+
+func TestCopyBetweenRegions(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.CreateBucket("replica")
+	server.PutObject("bucket", "data.json", []byte(`{"a": 1}`))
+	if err := common.SetObjectTags(ctx, cfg, "bucket/data.json", "", common.ObjectTags{"team": "storage"}); err != nil {
+		t.Fatal(err)
+	}
+
+	params := common.CopyObjectParams{
+		Source:             "bucket/data.json",
+		Destination:        "replica/",
+		CopyEndpointParams: common.CopyEndpointParams{SourceRegion: "br-ne1"},
+	}
+	if _, err := copy(ctx, params, cfg); err != nil {
+		t.Fatalf("copy() failed: %s", err)
+	}
+
+	copied, ok := server.Object("replica", "data.json")
+	if !ok || string(copied.Data) != `{"a": 1}` {
+		t.Fatalf("object was not copied: %+v", copied)
+	}
+	if copied.Tags["team"] != "storage" {
+		t.Errorf("tags were not copied: %v", copied.Tags)
+	}
+	if n := countBucketRequests(server, "GET", "bucket", s3test.AccessKeyID, "br-ne1"); n == 0 {
+		t.Error("source must be read from its region")
+	}
+	if n := countBucketRequests(server, "PUT", "replica", s3test.AccessKeyID, "br-se1"); n != 1 {
+		t.Errorf("expected a single upload to the destination region, got %d", n)
+	}
+
+	// The server copies objects within the same region
+	if _, err := copy(ctx, common.CopyObjectParams{Source: "bucket/data.json", Destination: "replica/same.json"}, cfg); err != nil {
+		t.Fatalf("copy() failed: %s", err)
+	}
+	if n := countBucketRequests(server, "GET", "bucket", s3test.AccessKeyID, "br-se1"); n != 0 {
+		t.Errorf("copies within a region must not download the source, got %d requests", n)
+	}
+}
+
+func TestCopyWithSourceKeyPair(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.CreateBucket("replica")
+	server.PutObject("bucket", "data.json", []byte("data"))
+	server.AddKeyPair("tenant-key", "tenant-secret")
+	config.FromContext(ctx).AddTempKeyPair("tenant", "tenant-key", "tenant-secret")
+
+	params := common.CopyObjectParams{
+		Source:             "bucket/data.json",
+		Destination:        "replica/data.json",
+		CopyEndpointParams: common.CopyEndpointParams{SourceKeyPair: "tenant"},
+	}
+	if _, err := copy(ctx, params, cfg); err != nil {
+		t.Fatalf("copy() failed: %s", err)
+	}
+	if copied, ok := server.Object("replica", "data.json"); !ok || string(copied.Data) != "data" {
+		t.Fatalf("object was not copied: %+v", copied)
+	}
+	if n := countBucketRequests(server, "GET", "bucket", "tenant-key", "br-se1"); n == 0 {
+		t.Error("source must be read with the given key pair")
+	}
+	if n := countBucketRequests(server, "PUT", "replica", s3test.AccessKeyID, "br-se1"); n != 1 {
+		t.Errorf("destination must be written with the current credentials, got %d requests", n)
+	}
+
+	params.SourceKeyPair = "missing"
+	_, err := copy(ctx, params, cfg)
+	var usageErr core.UsageError
+	if !errors.As(err, &usageErr) {
+		t.Errorf("expected usage error for unknown key pair, got %v", err)
+	}
+}
+
+func TestCopyAllBetweenRegions(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.CreateBucket("replica")
+	for _, key := range []string{"a.txt", "dir/b.txt"} {
+		server.PutObject("bucket", key, []byte(key))
+	}
+
+	params := common.CopyAllObjectsParams{
+		Source:             "bucket",
+		Destination:        "replica",
+		CopyEndpointParams: common.CopyEndpointParams{DestinationRegion: "br-ne1"},
+	}
+	result, err := copyAll(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("copyAll() failed: %s", err)
+	}
+	if result.Succeeded != 2 {
+		t.Errorf("expected 2 copies, got %+v", result)
+	}
+	for _, key := range []string{"a.txt", "dir/b.txt"} {
+		if copied, ok := server.Object("replica", key); !ok || string(copied.Data) != key {
+			t.Errorf("%s was not copied: %+v", key, copied)
+		}
+	}
+	if n := countBucketRequests(server, "PUT", "replica", s3test.AccessKeyID, "br-ne1"); n != 2 {
+		t.Errorf("expected 2 uploads to the destination region, got %d", n)
+	}
+}
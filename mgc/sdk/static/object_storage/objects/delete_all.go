@@ -9,13 +9,16 @@ import (
 )
 
 var getDeleteAll = utils.NewLazyLoader[core.Executor](func() core.Executor {
-	exec := core.NewStaticExecute(
+	var exec core.Executor = core.NewStaticExecute(
 		core.DescriptorSpec{
 			Name:        "delete-all",
 			Description: "Delete all objects from a bucket",
 		},
 		deleteAll,
 	)
+	exec = core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "template=Deleted {{.succeeded}} objects{{if .skipped}}, {{.skipped}} skipped{{end}}\n"
+	})
 
 	return core.NewPromptInputExecutor(
 		exec,
@@ -27,7 +30,6 @@ Please confirm by retyping: {{.confirmationValue}}`,
 	)
 })
 
-func deleteAll(ctx context.Context, params common.DeleteAllObjectsInBucketParams, cfg common.Config) (result core.Value, err error) {
-	err = common.DeleteAllObjectsInBucket(ctx, params, cfg)
-	return
+func deleteAll(ctx context.Context, params common.DeleteAllObjectsInBucketParams, cfg common.Config) (common.BulkSummary, error) {
+	return common.DeleteAllObjectsInBucketWithSummary(ctx, params, cfg)
 }
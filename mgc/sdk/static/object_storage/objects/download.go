@@ -35,7 +35,15 @@ func download(ctx context.Context, p common.DownloadObjectParams, cfg common.Con
 		return nil, fmt.Errorf("no destination specified and could not use local dir: %w", err)
 	}
 
-	downloader, err := common.NewDownloader(ctx, cfg, p.Source, dst, p.Version)
+	key, err := p.CustomerKey()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := p.DownloadOptions("")
+	opts.Encryption = key
+	opts.Checksum = p.Checksum
+	downloader, err := common.NewDownloader(ctx, cfg, p.Source, dst, p.Version, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 
 	"github.com/MagaluCloud/magalu/mgc/core"
 	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
@@ -24,28 +26,43 @@ func downloadAllLogger() *zap.SugaredLogger {
 }
 
 type downloadAllObjectsParams struct {
-	Source         mgcSchemaPkg.URI      `json:"src" jsonschema:"description=Path of objects to be downloaded,example=mybucket" mgc:"positional"`
-	Destination    mgcSchemaPkg.FilePath `json:"dst,omitempty" jsonschema:"description=Path to save files,example=path/to/folder" mgc:"positional"`
-	common.Filters `json:",squash"`      // nolint
+	Source                         mgcSchemaPkg.URI      `json:"src" jsonschema:"description=Path of objects to be downloaded,example=mybucket" mgc:"positional"`
+	Destination                    mgcSchemaPkg.FilePath `json:"dst,omitempty" jsonschema:"description=Path to save files,example=path/to/folder" mgc:"positional"`
+	Archive                        string                `json:"archive,omitempty" jsonschema:"description=Write the objects to a single local archive of this format instead of a file per object,enum=,enum=tar,enum=tar.gz,enum=zip,default="`
+	common.Filters                 `json:",squash"`      // nolint
+	common.RestoreAttributesParams `json:",squash"`      // nolint
+	common.BulkParams              `json:",squash"`      // nolint
+}
+
+type downloadAllResult struct {
+	Source             mgcSchemaPkg.URI      `json:"src"`
+	Destination        mgcSchemaPkg.FilePath `json:"dst"`
+	common.BulkSummary `json:",squash"`      // nolint
 }
 
 var getDownloadAll = utils.NewLazyLoader[core.Executor](func() core.Executor {
 	executor := core.NewStaticExecute(
 		core.DescriptorSpec{
-			Name:        "download-all",
-			Description: "Download all objects from a bucket",
+			Name:    "download-all",
+			Summary: "Download all objects from a bucket",
+			Description: `Downloads every object under the source path as a local file.
+
+With --archive, the objects are written one after the other to a single tar, tar.gz or zip
+file instead. If the destination is a directory, the archive is named after the source.`,
 		},
 		downloadAll,
 	)
 
 	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
-		return "template=Downloaded from {{.src}} to {{.dst}}\n"
+		return "template=Downloaded {{.succeeded}} objects from {{.src}} to {{.dst}}{{if .skipped}}, {{.skipped}} skipped{{end}}\n"
 	})
 })
 
+// Only errors listing the source are output, the downloads are accounted by run
 func createObjectDownloadProcessor(
 	cfg common.Config,
 	params downloadAllObjectsParams,
+	run *common.BulkRun,
 	progressReporter *progress_report.UnitsReporter,
 ) pipeline.Processor[pipeline.WalkDirEntry, error] {
 	return func(ctx context.Context, dirEntry pipeline.WalkDirEntry) (error, pipeline.ProcessStatus) {
@@ -64,33 +81,124 @@ func createObjectDownloadProcessor(
 
 		_, ok := dirEntry.DirEntry().(*common.BucketContent)
 		if !ok {
-			err = &common.ObjectError{Url: mgcSchemaPkg.URI(objURI), Err: fmt.Errorf("expected object, got directory")}
-			return err, pipeline.ProcessOutput
+			run.Skip()
+			return nil, pipeline.ProcessSkip
 		}
 
+		dst := params.Destination.Join(dirEntry.Path())
+		entry := common.ManifestEntry{Path: dirEntry.Path(), Source: objURI.String(), Destination: dst.String()}
 		downloadAllLogger().Infow("Downloading object", "uri", objURI)
-		downloader, err := common.NewDownloader(ctx, cfg, objURI, params.Destination.Join(dirEntry.Path()), "") // since we are downloading N objects, can't set a version
+		err = run.Process(ctx, entry, func() error {
+			// since we are downloading N objects, can't set a version
+			downloader, err := common.NewDownloader(ctx, cfg, objURI, dst, "", params.DownloadOptions(params.Destination))
+			if err == nil {
+				err = downloader.Download(ctx)
+			}
+			if err != nil {
+				return &common.ObjectError{Url: mgcSchemaPkg.URI(objURI), Err: err}
+			}
+			return nil
+		})
 		if err != nil {
+			// The operation was cancelled with it by run
 			return err, pipeline.ProcessAbort
 		}
+		return nil, pipeline.ProcessSkip
+	}
+}
 
-		if err = downloader.Download(ctx); err != nil {
-			return err, pipeline.ProcessAbort
-		}
+func downloadMultipleFiles(ctx context.Context, cfg common.Config, params downloadAllObjectsParams) (common.BulkSummary, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	run, err := common.NewBulkRun("download-all", params.BulkParams, cancel)
+	if err != nil {
+		return common.BulkSummary{}, err
+	}
+
+	progressReportMsg := "Downloading objects from: " + params.Source.String()
+	progressReporter := progress_report.NewUnitsReporter(ctx, progressReportMsg, 0)
+	progressReporter.Start()
+	defer progressReporter.End()
 
+	onNewPage := func(objCount uint64) {
+		progressReporter.Report(0, objCount, nil)
+	}
+
+	objs, err := common.ListBulkObjects(ctx, cfg, "download-all", params.BulkParams, params.Source, params.FilterParams, cancel, onNewPage)
+	if err != nil {
+		return common.BulkSummary{}, err
+	}
+
+	downloadObjectsErrorChan := pipeline.ParallelProcess(ctx, cfg.Workers, objs, createObjectDownloadProcessor(cfg, params, run, progressReporter), nil)
+	downloadObjectsErrorChan = pipeline.Filter(ctx, downloadObjectsErrorChan, pipeline.FilterNonNil[error]{})
+
+	objErr, err := pipeline.SliceItemConsumer[utils.MultiError](ctx, downloadObjectsErrorChan)
+	if err == nil && len(objErr) > 0 {
+		err = objErr
+	}
+
+	return run.Finish(err)
+}
+
+// Returns the archive file to write to: dst itself, unless it's empty or a directory
+func downloadArchiveDst(dst mgcSchemaPkg.FilePath, src mgcSchemaPkg.URI, format string) (mgcSchemaPkg.FilePath, error) {
+	name := src.Filename()
+	if name == "" {
+		name = common.NewBucketNameFromURI(src).String()
+	}
+	name += "." + format
+
+	if dst == "" {
+		wd, err := os.Getwd()
 		if err != nil {
-			err = &common.ObjectError{Url: mgcSchemaPkg.URI(objURI), Err: err}
-			return err, pipeline.ProcessOutput
+			return "", err
 		}
+		return mgcSchemaPkg.FilePath(filepath.Join(wd, name)), nil
+	}
+	if info, err := os.Stat(dst.String()); err == nil && info.IsDir() {
+		return dst.Join(name), nil
+	}
+	return dst, nil
+}
 
-		return nil, pipeline.ProcessOutput
+func writeObjectToArchive(ctx context.Context, cfg common.Config, archive archiveWriter, objURI mgcSchemaPkg.URI, content *common.BucketContent) error {
+	reader, err := common.OpenObject(ctx, cfg, objURI, "", common.SSECustomerKey{}, "")
+	if err != nil {
+		return &common.ObjectError{Url: objURI, Err: err}
 	}
+	defer reader.Close()
+
+	if err = archive.WriteFile(content.Key, content.ContentSize, utils.FILE_PERMISSION, content.ModTime(), reader); err != nil {
+		return &common.ObjectError{Url: objURI, Err: err}
+	}
+	return nil
 }
 
-func downloadMultipleFiles(ctx context.Context, cfg common.Config, params downloadAllObjectsParams) error {
+// Objects are written to the archive one at a time, in the listed order. Returns the number of objects written
+func downloadArchive(ctx context.Context, cfg common.Config, params downloadAllObjectsParams) (files int, err error) {
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(nil)
 
+	file, err := os.Create(params.Destination.String())
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			// A partial archive would look valid, with files missing
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	archive, err := newArchiveWriter(file, params.Archive)
+	if err != nil {
+		return 0, err
+	}
+
 	listParams := common.ListObjectsParams{
 		Destination: params.Source,
 		Recursive:   true,
@@ -99,7 +207,7 @@ func downloadMultipleFiles(ctx context.Context, cfg common.Config, params downlo
 		},
 	}
 
-	progressReportMsg := "Downloading objects from: " + params.Source.String()
+	progressReportMsg := "Archiving objects from: " + params.Source.String()
 	progressReporter := progress_report.NewUnitsReporter(ctx, progressReportMsg, 0)
 	progressReporter.Start()
 	defer progressReporter.End()
@@ -111,30 +219,57 @@ func downloadMultipleFiles(ctx context.Context, cfg common.Config, params downlo
 	objs := common.ListGenerator(ctx, listParams, cfg, onNewPage)
 	objs = common.ApplyFilters(ctx, objs, params.FilterParams, cancel)
 
-	downloadObjectsErrorChan := pipeline.ParallelProcess(ctx, cfg.Workers, objs, createObjectDownloadProcessor(cfg, params, progressReporter), nil)
-	downloadObjectsErrorChan = pipeline.Filter(ctx, downloadObjectsErrorChan, pipeline.FilterNonNil[error]{})
-
-	objErr, err := pipeline.SliceItemConsumer[utils.MultiError](ctx, downloadObjectsErrorChan)
-	if err != nil {
-		return err
+	rootURI := common.NewBucketNameFromURI(params.Source).AsURI()
+	for entry := range objs {
+		if err = entry.Err(); err != nil {
+			return files, err
+		}
+		content, ok := entry.DirEntry().(*common.BucketContent)
+		if !ok {
+			continue
+		}
+		err = writeObjectToArchive(ctx, cfg, archive, rootURI.JoinPath(entry.Path()), content)
+		progressReporter.Report(1, 0, err)
+		if err != nil {
+			return files, err
+		}
+		files++
 	}
-	if len(objErr) > 0 {
-		return objErr
+	if err = context.Cause(ctx); err != nil {
+		return files, err
 	}
 
-	return nil
+	return files, archive.Close()
 }
 
-func downloadAll(ctx context.Context, p downloadAllObjectsParams, cfg common.Config) (result common.DownloadObjectParams, err error) {
+func downloadAll(ctx context.Context, p downloadAllObjectsParams, cfg common.Config) (result downloadAllResult, err error) {
+	cfg = cfg.WithBandwidthLimiter()
+	if p.Archive != "" {
+		if err = validateArchiveFormat(p.Archive); err != nil {
+			return
+		}
+		if p.ContinueOnError || p.FromManifest != "" {
+			// A partial archive is removed, there would be nothing to retry the failures into
+			return result, core.UsageError{Err: fmt.Errorf("continue_on_error and from_manifest cannot be used with archive")}
+		}
+		if p.Destination, err = downloadArchiveDst(p.Destination, p.Source, p.Archive); err != nil {
+			return result, fmt.Errorf("no destination specified and could not use local dir: %w", err)
+		}
+		files, err := downloadArchive(ctx, cfg, p)
+		if err != nil {
+			return result, err
+		}
+		return downloadAllResult{Source: p.Source, Destination: p.Destination, BulkSummary: common.BulkSummary{Succeeded: files}}, nil
+	}
+
 	p.Destination, err = common.GetDownloadFileDst(p.Destination, p.Source)
 	if err != nil {
 		return result, fmt.Errorf("no destination specified and could not use local dir: %w", err)
 	}
-	err = downloadMultipleFiles(ctx, cfg, p)
-
+	summary, err := downloadMultipleFiles(ctx, cfg, p)
 	if err != nil {
 		return result, err
 	}
 
-	return common.DownloadObjectParams{Source: p.Source, Destination: p.Destination}, nil
+	return downloadAllResult{Source: p.Source, Destination: p.Destination, BulkSummary: summary}, nil
 }
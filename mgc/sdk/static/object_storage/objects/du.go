@@ -0,0 +1,106 @@
+package objects
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type duParams struct {
+	Destination    mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the bucket or prefix to measure,example=bucket1/logs" mgc:"positional"`
+	Depth          int              `json:"depth,omitempty" jsonschema:"description=How many levels of prefixes below dst are reported. Zero only reports the total,default=1,minimum=0,required"`
+	common.Filters `json:",squash"` // nolint
+}
+
+type duEntry struct {
+	Prefix string `json:"prefix"`
+	Size   int64  `json:"size"`
+	Count  int64  `json:"count"`
+}
+
+var getDu = utils.NewLazyLoader[core.Executor](func() core.Executor {
+	executor := core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:    "du",
+			Summary: "Summarize the disk usage of a bucket path",
+			Description: `Recursively sums the size and the number of objects under the given path,
+per prefix up to the given depth. The last entry is the total of the whole path.`,
+		},
+		du,
+	)
+
+	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
+		return "table=PREFIX:$[*].prefix,SIZE:fileSize($[*].size),OBJECTS:$[*].count"
+	})
+})
+
+// Returns the prefixes of key, relative to base, up to the given depth. The key itself is never included
+func duPrefixes(base string, key string, depth int) []string {
+	relative := strings.TrimPrefix(key, base)
+	parts := strings.Split(relative, "/")
+	prefixes := make([]string, 0, depth)
+	for i := 1; i < len(parts) && i <= depth; i++ {
+		prefixes = append(prefixes, base+strings.Join(parts[:i], "/")+"/")
+	}
+	return prefixes
+}
+
+func du(ctx context.Context, params duParams, cfg common.Config) (result []duEntry, err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	bucketName := common.NewBucketNameFromURI(params.Destination)
+	base := params.Destination.Path()
+	if base != "" {
+		base += "/"
+	}
+
+	listParams := common.ListObjectsParams{
+		Destination:      params.Destination,
+		Recursive:        true,
+		PaginationParams: common.PaginationParams{MaxItems: math.MaxInt64},
+	}
+	objects := common.ListGenerator(ctx, listParams, cfg, nil)
+	objects = common.ApplyFilters(ctx, objects, params.FilterParams, cancel)
+
+	total := duEntry{Prefix: bucketName.String() + "/" + base}
+	byPrefix := map[string]*duEntry{}
+	for entry := range objects {
+		if err = entry.Err(); err != nil {
+			return nil, err
+		}
+		if entry.DirEntry().IsDir() {
+			continue
+		}
+		size := entry.DirEntry().(*common.BucketContent).ContentSize
+		total.Size += size
+		total.Count++
+
+		for _, prefix := range duPrefixes(base, entry.Path(), params.Depth) {
+			aggregated, ok := byPrefix[prefix]
+			if !ok {
+				aggregated = &duEntry{Prefix: bucketName.String() + "/" + prefix}
+				byPrefix[prefix] = aggregated
+			}
+			aggregated.Size += size
+			aggregated.Count++
+		}
+	}
+	if cause := context.Cause(ctx); cause != nil {
+		return nil, cause
+	}
+
+	result = make([]duEntry, 0, len(byPrefix)+1)
+	for _, aggregated := range byPrefix {
+		result = append(result, *aggregated)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Prefix < result[j].Prefix })
+	result = append(result, total)
+	return result, nil
+}
@@ -0,0 +1,183 @@
+package objects
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type editParams struct {
+	Destination             mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the object to be edited,example=bucket1/file.txt" mgc:"positional"`
+	Editor                  string           `json:"editor,omitempty" jsonschema_description:"Command to edit the object with. If omitted, $VISUAL or $EDITOR is used, falling back to vi"`
+	common.EncryptionParams `json:",squash"` // nolint
+}
+
+type editResult struct {
+	Destination mgcSchemaPkg.URI `json:"dst"`
+	Changed     bool             `json:"changed"`
+}
+
+var getEdit = utils.NewLazyLoader[core.Executor](func() core.Executor {
+	executor := core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:    "edit",
+			Summary: "Edit an object in place with a text editor",
+			Description: `Downloads the object to a temporary file and opens it in the editor. Once the editor
+exits, the object is uploaded again only if the file changed, keeping its headers, metadata
+and tags. The upload fails if the object was changed by someone else in the meantime. If the
+upload fails, the edited file is kept so the changes are not lost.`,
+		},
+		edit,
+	)
+
+	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
+		return "template={{if .changed}}Uploaded changes to {{.dst}}{{else}}No changes to {{.dst}}{{end}}\n"
+	})
+})
+
+func editorCommand(editor string) []string {
+	for _, value := range []string{editor, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if fields := strings.Fields(value); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// Opens the file in the editor, waiting for it to exit. Replaced by tests
+var runEditor = func(ctx context.Context, editor string, path string) error {
+	command := editorCommand(editor)
+	cmd := exec.CommandContext(ctx, command[0], append(command[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running editor %q: %w", strings.Join(command, " "), err)
+	}
+	return nil
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func edit(ctx context.Context, p editParams, cfg common.Config) (result editResult, err error) {
+	if p.Destination.Path() == "" || strings.HasSuffix(p.Destination.String(), "/") {
+		return result, core.UsageError{Err: fmt.Errorf("destination must be a URI to an object")}
+	}
+	result.Destination = p.Destination
+
+	key, err := p.CustomerKey()
+	if err != nil {
+		return result, err
+	}
+
+	// Taken before downloading, so changes made by others after it always fail the upload
+	metadata, err := common.HeadFile(ctx, cfg, p.Destination, "", key)
+	if err != nil {
+		return result, err
+	}
+	tags, err := common.GetObjectTags(ctx, cfg, p.Destination, "")
+	if err != nil {
+		return result, fmt.Errorf("error reading tags: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "mgc-edit-")
+	if err != nil {
+		return result, err
+	}
+	keepDir := false
+	defer func() {
+		if !keepDir {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	// Same name as the object, so editors recognize its type
+	path := filepath.Join(dir, p.Destination.Filename())
+	if err = downloadToEdit(ctx, cfg, p.Destination, key, path); err != nil {
+		return result, err
+	}
+
+	before, err := hashFile(path)
+	if err != nil {
+		return result, err
+	}
+	if err = runEditor(ctx, p.Editor, path); err != nil {
+		return result, err
+	}
+	after, err := hashFile(path)
+	if err != nil {
+		return result, err
+	}
+	if bytes.Equal(before, after) {
+		return result, nil
+	}
+
+	opts := common.UploadOptions{
+		StorageClass: metadata.StorageClass,
+		Headers:      metadata.Headers(),
+		Encryption:   key,
+		Conditions:   common.WriteConditions{IfMatch: metadata.ETag},
+	}
+	if len(tags) > 0 {
+		opts.Tags = common.ObjectTags{}
+		for _, tag := range tags {
+			opts.Tags[tag.Key] = tag.Value
+		}
+	}
+
+	uploader, err := common.NewUploader(cfg, mgcSchemaPkg.FilePath(path), p.Destination, opts)
+	if err == nil {
+		err = uploader.Upload(ctx)
+	}
+	if err != nil {
+		// Whatever the failure, the changes must not be lost
+		keepDir = true
+		return result, fmt.Errorf("%w. The edited file was kept at %s", err, path)
+	}
+
+	result.Changed = true
+	return result, nil
+}
+
+func downloadToEdit(ctx context.Context, cfg common.Config, src mgcSchemaPkg.URI, key common.SSECustomerKey, path string) error {
+	reader, err := common.OpenObject(ctx, cfg, src, "", key, "")
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, utils.FILE_PERMISSION)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, reader); err != nil {
+		f.Close()
+		return fmt.Errorf("error downloading %s: %w", src, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,136 @@
+package objects
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+func TestConditionalWrites(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "a.txt", []byte("a"))
+	stored, _ := server.Object("bucket", "a.txt")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"b.txt": "b"})
+	src := mgcSchemaPkg.FilePath(filepath.Join(dir, "b.txt"))
+
+	_, err := upload(ctx, uploadParams{Source: src, Destination: "bucket/a.txt", WriteConditions: common.WriteConditions{IfNoneMatch: "*"}}, cfg)
+	if !errors.As(err, new(*common.PreconditionFailedError)) {
+		t.Errorf("expected precondition error when the object exists, got %v", err)
+	}
+	_, err = upload(ctx, uploadParams{Source: src, Destination: "bucket/a.txt", WriteConditions: common.WriteConditions{IfMatch: "not-the-etag"}}, cfg)
+	if !errors.As(err, new(*common.PreconditionFailedError)) {
+		t.Errorf("expected precondition error for another ETag, got %v", err)
+	}
+	_, err = copy(ctx, common.CopyObjectParams{Source: "bucket/a.txt", Destination: "bucket/a.txt", WriteConditions: common.WriteConditions{IfNoneMatch: "*"}}, cfg)
+	if !errors.As(err, new(*common.PreconditionFailedError)) {
+		t.Errorf("expected precondition error when copying over the object, got %v", err)
+	}
+	_, err = deleteObject(ctx, common.DeleteObjectParams{Destination: "bucket/a.txt", IfMatch: "not-the-etag"}, cfg)
+	if !errors.As(err, new(*common.PreconditionFailedError)) {
+		t.Errorf("expected precondition error when deleting, got %v", err)
+	}
+	if current, ok := server.Object("bucket", "a.txt"); !ok || string(current.Data) != "a" {
+		t.Fatal("rejected writes must not change the object")
+	}
+
+	_, err = upload(ctx, uploadParams{Source: src, Destination: "bucket/a.txt", WriteConditions: common.WriteConditions{IfMatch: stored.ETag}}, cfg)
+	if err != nil {
+		t.Fatalf("upload() with the current ETag failed: %s", err)
+	}
+	_, err = upload(ctx, uploadParams{Source: src, Destination: "bucket/new.txt", WriteConditions: common.WriteConditions{IfNoneMatch: "*"}}, cfg)
+	if err != nil {
+		t.Fatalf("upload() of a new object failed: %s", err)
+	}
+	current, _ := server.Object("bucket", "a.txt")
+	if _, err = deleteObject(ctx, common.DeleteObjectParams{Destination: "bucket/a.txt", IfMatch: current.ETag}, cfg); err != nil {
+		t.Fatalf("deleteObject() with the current ETag failed: %s", err)
+	}
+	if _, ok := server.Object("bucket", "a.txt"); ok {
+		t.Error("object was not deleted")
+	}
+
+	_, err = upload(ctx, uploadParams{Source: src, Destination: "bucket/a.txt", WriteConditions: common.WriteConditions{IfNoneMatch: "etag"}}, cfg)
+	if !errors.As(err, new(core.UsageError)) {
+		t.Errorf("expected usage error for an if-none-match other than *, got %v", err)
+	}
+}
+
+func setEditor(t *testing.T, editor func(path string) error) {
+	previous := runEditor
+	runEditor = func(ctx context.Context, _ string, path string) error { return editor(path) }
+	t.Cleanup(func() { runEditor = previous })
+}
+
+func TestEdit(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "docs/notes.md", []byte("# Notes\n"))
+	if err := common.SetObjectTags(ctx, cfg, "bucket/docs/notes.md", "", common.ObjectTags{"team": "docs"}); err != nil {
+		t.Fatal(err)
+	}
+
+	setEditor(t, func(path string) error {
+		if filepath.Base(path) != "notes.md" {
+			t.Errorf("the file must keep the object name, got %q", path)
+		}
+		return nil
+	})
+	result, err := edit(ctx, editParams{Destination: "bucket/docs/notes.md"}, cfg)
+	if err != nil {
+		t.Fatalf("edit() failed: %s", err)
+	}
+	if result.Changed || server.VersionCount("bucket", "docs/notes.md") != 1 {
+		t.Errorf("unchanged files must not be uploaded: %+v", result)
+	}
+
+	setEditor(t, func(path string) error {
+		return os.WriteFile(path, []byte("# Notes\n\n- edited\n"), 0644)
+	})
+	if result, err = edit(ctx, editParams{Destination: "bucket/docs/notes.md"}, cfg); err != nil || !result.Changed {
+		t.Fatalf("edit() failed: %+v %v", result, err)
+	}
+	stored, _ := server.Object("bucket", "docs/notes.md")
+	if string(stored.Data) != "# Notes\n\n- edited\n" || stored.Tags["team"] != "docs" {
+		t.Errorf("edited object was not uploaded with its tags: %q %v", stored.Data, stored.Tags)
+	}
+
+	var editedPath string
+	setEditor(t, func(path string) error {
+		editedPath = path
+		// Someone else changes the object while it's being edited
+		server.PutObject("bucket", "docs/notes.md", []byte("concurrent"))
+		return os.WriteFile(path, []byte("mine"), 0644)
+	})
+	_, err = edit(ctx, editParams{Destination: "bucket/docs/notes.md"}, cfg)
+	if !errors.As(err, new(*common.PreconditionFailedError)) || !strings.Contains(err.Error(), editedPath) {
+		t.Fatalf("expected precondition error with the kept file, got %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(editedPath)) })
+	if data, err := os.ReadFile(editedPath); err != nil || string(data) != "mine" {
+		t.Errorf("edited file must be kept: %q %v", data, err)
+	}
+	if stored, _ = server.Object("bucket", "docs/notes.md"); string(stored.Data) != "concurrent" {
+		t.Errorf("concurrent change must not be overwritten, got %q", stored.Data)
+	}
+
+	setEditor(t, func(path string) error {
+		editedPath = path
+		server.FailRequests(http.MethodPut, "bucket", "docs/notes.md", 100)
+		return os.WriteFile(path, []byte("failed"), 0644)
+	})
+	if _, err = edit(ctx, editParams{Destination: "bucket/docs/notes.md"}, cfg); err == nil || !strings.Contains(err.Error(), editedPath) {
+		t.Fatalf("expected upload error with the kept file, got %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(editedPath)) })
+	if data, err := os.ReadFile(editedPath); err != nil || string(data) != "failed" {
+		t.Errorf("edited file must be kept on any upload failure: %q %v", data, err)
+	}
+}
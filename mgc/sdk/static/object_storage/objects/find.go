@@ -0,0 +1,237 @@
+package objects
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/dustin/go-humanize"
+)
+
+type findParams struct {
+	Destination  mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the bucket or prefix to search in,example=bucket1/logs" mgc:"positional"`
+	Name         string           `json:"name,omitempty" jsonschema:"description=Only objects whose file name matches the glob pattern,example=*.log"`
+	Regex        string           `json:"regex,omitempty" jsonschema:"description=Only objects whose file name matches the regular expression,example=^access-"`
+	MinSize      string           `json:"min_size,omitempty" jsonschema:"description=Only objects of at least this size,example=10MiB"`
+	MaxSize      string           `json:"max_size,omitempty" jsonschema:"description=Only objects of at most this size,example=1GiB"`
+	OlderThan    string           `json:"older_than,omitempty" jsonschema_description:"Only objects last modified before this age or date, such as 30d, 12h or 2024-01-31"`
+	NewerThan    string           `json:"newer_than,omitempty" jsonschema_description:"Only objects last modified after this age or date, such as 30d, 12h or 2024-01-31"`
+	StorageClass string           `json:"storage_class,omitempty" jsonschema:"description=Only objects of this storage class,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
+	Delete       bool             `json:"delete,omitempty" jsonschema_description:"Delete the matching objects instead of listing them. At least one criterion is required, use delete-all to delete everything" jsonschema:"default=false"`
+}
+
+func (p findParams) hasCriteria() bool {
+	return p.Name != "" || p.Regex != "" || p.MinSize != "" || p.MaxSize != "" || p.OlderThan != "" || p.NewerThan != "" || p.StorageClass != ""
+}
+
+type findResult struct {
+	// Not filled when deleting, the matches are only counted
+	Objects   []*common.BucketContent `json:"objects,omitempty"`
+	Count     int                     `json:"count"`
+	TotalSize int64                   `json:"total_size"`
+	Deleted   bool                    `json:"deleted"`
+}
+
+var getFind = utils.NewLazyLoader[core.Executor](func() core.Executor {
+	executor := core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:    "find",
+			Summary: "Find the objects of a bucket that match the given criteria",
+			Description: `Recursively searches the objects under the given path and keeps only those
+matching all the given criteria: file name glob or regular expression, size range,
+last modified range and storage class.
+
+` + ageFormatsDescription + `
+
+The matching objects are listed or, with --delete, deleted while the bucket is being searched.
+Deleting only reports how many objects were deleted and their total size.`,
+		},
+		find,
+	)
+
+	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
+		if value, ok := core.ResultAs[core.ResultWithValue](result); ok {
+			if m, ok := value.Value().(map[string]any); ok && m["deleted"] == true {
+				return "template=Deleted {{.count}} objects, {{.total_size}} bytes\n"
+			}
+		}
+		return "table=KEY:$.objects[*].Key,SIZE:fileSize($.objects[*].ContentSize),LASTMODIFIED:humanTime($.objects[*].LastModified),STORAGECLASS:$.objects[*].StorageClass"
+	})
+})
+
+// Rule for BucketContent entries, including those of the given storage class.
+// Others are left as unknown
+type filterIncludeStorageClass struct {
+	StorageClass  string
+	CancelOnError func(error)
+}
+
+func (r filterIncludeStorageClass) Filter(ctx context.Context, entry pipeline.WalkDirEntry) pipeline.FilterStatus {
+	if err := entry.Err(); err != nil {
+		if r.CancelOnError != nil {
+			r.CancelOnError(err)
+		}
+		return pipeline.FilterExclude
+	}
+	if content, ok := entry.DirEntry().(*common.BucketContent); ok && strings.EqualFold(content.StorageClass, r.StorageClass) {
+		return pipeline.FilterInclude
+	}
+	return pipeline.FilterUnknown
+}
+
+var _ pipeline.FilterRule[pipeline.WalkDirEntry] = (*filterIncludeStorageClass)(nil)
+
+// Parses sizes such as "10MiB" or "1GB", returning unset if s is empty
+func parseSize(s string, unset int64) (int64, error) {
+	if s == "" {
+		return unset, nil
+	}
+	size, err := humanize.ParseBytes(s)
+	if err != nil || size > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q, expected something like 10MiB", s)
+	}
+	return int64(size), nil
+}
+
+func newFindFilter(params findParams, now time.Time, cancel context.CancelCauseFunc) (pipeline.FilterRule[pipeline.WalkDirEntry], error) {
+	cancelOnError := func(err error) { cancel(err) }
+	criteria := []pipeline.FilterRule[pipeline.WalkDirEntry]{}
+
+	if params.Name != "" {
+		if _, err := path.Match(params.Name, ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %w", params.Name, err)
+		}
+		criteria = append(criteria, pipeline.FilterWalkDirEntryIncludeGlobMatch{Pattern: params.Name, CancelOnError: cancelOnError})
+	}
+
+	if params.Regex != "" {
+		re, err := regexp.Compile(params.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", params.Regex, err)
+		}
+		criteria = append(criteria, pipeline.FilterWalkDirEntryIncludeRegExp{Regexp: re, CancelOnError: cancelOnError})
+	}
+
+	if params.MinSize != "" || params.MaxSize != "" {
+		minSize, err := parseSize(params.MinSize, 0)
+		if err != nil {
+			return nil, err
+		}
+		maxSize, err := parseSize(params.MaxSize, math.MaxInt64)
+		if err != nil {
+			return nil, err
+		}
+		if maxSize < minSize {
+			return nil, fmt.Errorf("max_size %q is smaller than min_size %q", params.MaxSize, params.MinSize)
+		}
+		criteria = append(criteria, pipeline.FilterWalkDirEntryIncludeSizeRange{Min: minSize, Max: maxSize, CancelOnError: cancelOnError})
+	}
+
+	if params.OlderThan != "" || params.NewerThan != "" {
+		modTimeRange := pipeline.FilterWalkDirEntryIncludeModTimeRange{CancelOnError: cancelOnError}
+		var err error
+		if params.OlderThan != "" {
+			if modTimeRange.Before, err = parseAge(params.OlderThan, now); err != nil {
+				return nil, err
+			}
+		}
+		if params.NewerThan != "" {
+			if modTimeRange.After, err = parseAge(params.NewerThan, now); err != nil {
+				return nil, err
+			}
+		}
+		criteria = append(criteria, modTimeRange)
+	}
+
+	if params.StorageClass != "" {
+		criteria = append(criteria, filterIncludeStorageClass{StorageClass: params.StorageClass, CancelOnError: cancelOnError})
+	}
+
+	rules := []pipeline.FilterRule[pipeline.WalkDirEntry]{pipeline.FilterWalkDirEntryExcludeDir}
+	for _, criterion := range criteria {
+		rules = append(rules, pipeline.FilterRuleIncludeOnly[pipeline.WalkDirEntry]{Pattern: criterion})
+	}
+	return pipeline.FilterRuleAnd[pipeline.WalkDirEntry]{And: rules}, nil
+}
+
+func find(ctx context.Context, params findParams, cfg common.Config) (result findResult, err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	if params.Delete && !params.hasCriteria() {
+		return result, core.UsageError{Err: fmt.Errorf("refusing to delete every object under %s without any criteria, use delete-all instead", params.Destination)}
+	}
+
+	rule, err := newFindFilter(params, time.Now(), cancel)
+	if err != nil {
+		return result, core.UsageError{Err: err}
+	}
+
+	listParams := common.ListObjectsParams{
+		Destination:      params.Destination,
+		Recursive:        true,
+		PaginationParams: common.PaginationParams{MaxItems: math.MaxInt64},
+	}
+	matches := common.ListGenerator(ctx, listParams, cfg, nil)
+	matches = pipeline.Filter(ctx, matches, rule)
+
+	if params.Delete {
+		err = deleteEntries(ctx, cfg, params.Destination, common.MaxBatchSize, matches, func(entry pipeline.WalkDirEntry) {
+			result.Count++
+			result.TotalSize += entry.DirEntry().(*common.BucketContent).ContentSize
+		})
+		result.Deleted = err == nil
+		return result, err
+	}
+
+	result.Objects = []*common.BucketContent{}
+	for entry := range matches {
+		if err = entry.Err(); err != nil {
+			return result, err
+		}
+		content := entry.DirEntry().(*common.BucketContent)
+		result.Objects = append(result.Objects, content)
+		result.TotalSize += content.ContentSize
+	}
+
+	if cause := context.Cause(ctx); cause != nil {
+		return result, cause
+	}
+	result.Count = len(result.Objects)
+	return result, nil
+}
+
+// Deletes the entries as they are produced, calling counted() for each of them before it's
+// sent to be deleted. Only the entries of the batches being deleted are kept in memory
+func deleteEntries(ctx context.Context, cfg common.Config, dst mgcSchemaPkg.URI, batchSize int, entries <-chan pipeline.WalkDirEntry, counted func(pipeline.WalkDirEntry)) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	toDelete := pipeline.Process(ctx, entries, func(ctx context.Context, entry pipeline.WalkDirEntry) (pipeline.WalkDirEntry, pipeline.ProcessStatus) {
+		if err := entry.Err(); err != nil {
+			cancel(err)
+			return nil, pipeline.ProcessAbort
+		}
+		counted(entry)
+		return entry, pipeline.ProcessOutput
+	}, nil)
+
+	err := common.DeleteObjects(ctx, common.DeleteObjectsParams{
+		Destination: common.NewBucketNameFromURI(dst).AsURI(),
+		ToDelete:    toDelete,
+		BatchSize:   batchSize,
+	}, cfg)
+	if cause := context.Cause(ctx); cause != nil {
+		return cause
+	}
+	return err
+}
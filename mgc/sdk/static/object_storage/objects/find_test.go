@@ -0,0 +1,107 @@
+package objects
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDu(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "top.txt", []byte("1"))
+	server.PutObject("bucket", "logs/a.log", []byte("22"))
+	server.PutObject("bucket", "logs/2024/b.log", []byte("333"))
+	server.PutObject("bucket", "logs/2024/01/c.log", []byte("4444"))
+	server.PutObject("bucket", "data/d.bin", []byte("55555"))
+
+	result, err := du(ctx, duParams{Destination: "bucket", Depth: 2}, cfg)
+	if err != nil {
+		t.Fatalf("du() failed: %s", err)
+	}
+	expected := []duEntry{
+		{Prefix: "bucket/data/", Size: 5, Count: 1},
+		{Prefix: "bucket/logs/", Size: 9, Count: 3},
+		{Prefix: "bucket/logs/2024/", Size: 7, Count: 2},
+		{Prefix: "bucket/", Size: 15, Count: 5},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], result[i])
+		}
+	}
+
+	result, err = du(ctx, duParams{Destination: "bucket/logs", Depth: 0}, cfg)
+	if err != nil {
+		t.Fatalf("du() failed: %s", err)
+	}
+	if len(result) != 1 || result[0] != (duEntry{Prefix: "bucket/logs/", Size: 9, Count: 3}) {
+		t.Errorf("expected only the total, got %+v", result)
+	}
+}
+
+func TestFind(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "logs/access-1.log", []byte("small"))
+	server.PutObject("bucket", "logs/access-2.log", []byte(strings.Repeat("x", 2048)))
+	server.PutObject("bucket", "logs/error.log", []byte(strings.Repeat("x", 4096)))
+	server.PutObject("bucket", "logs/readme.txt", []byte("readme"))
+
+	keys := func(result findResult) (keys []string) {
+		for _, obj := range result.Objects {
+			keys = append(keys, obj.Key)
+		}
+		return
+	}
+
+	for _, tc := range []struct {
+		params   findParams
+		expected string
+	}{
+		{findParams{Name: "*.log"}, "logs/access-1.log logs/access-2.log logs/error.log"},
+		{findParams{Regex: "^access-[0-9]+"}, "logs/access-1.log logs/access-2.log"},
+		{findParams{Name: "*.log", MinSize: "1KiB", MaxSize: "3KiB"}, "logs/access-2.log"},
+		{findParams{MaxSize: "1KB"}, "logs/access-1.log logs/readme.txt"},
+		{findParams{NewerThan: "1h", StorageClass: "standard"}, "logs/access-1.log logs/access-2.log logs/error.log logs/readme.txt"},
+		{findParams{OlderThan: "30d"}, ""},
+		{findParams{OlderThan: "2000-01-01"}, ""},
+		{findParams{StorageClass: "cold"}, ""},
+	} {
+		tc.params.Destination = "bucket/logs"
+		result, err := find(ctx, tc.params, cfg)
+		if err != nil {
+			t.Fatalf("find(%+v) failed: %s", tc.params, err)
+		}
+		if got := strings.Join(keys(result), " "); got != tc.expected {
+			t.Errorf("find(%+v): expected %q, got %q", tc.params, tc.expected, got)
+		}
+	}
+
+	for _, params := range []findParams{{Regex: "("}, {MinSize: "huge"}, {OlderThan: "yesterday"}, {MinSize: "2KB", MaxSize: "1KB"}} {
+		params.Destination = "bucket"
+		if _, err := find(ctx, params, cfg); err == nil {
+			t.Errorf("find(%+v): expected an error", params)
+		}
+	}
+
+	if _, err := find(ctx, findParams{Destination: "bucket/logs", Delete: true}, cfg); err == nil {
+		t.Error("deleting without criteria must fail")
+	}
+	if _, ok := server.Object("bucket", "logs/readme.txt"); !ok {
+		t.Fatal("nothing must be deleted without criteria")
+	}
+
+	result, err := find(ctx, findParams{Destination: "bucket", Name: "access-*", Delete: true}, cfg)
+	if err != nil {
+		t.Fatalf("find() failed: %s", err)
+	}
+	if !result.Deleted || result.Count != 2 || result.TotalSize != 5+2048 || result.Objects != nil {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	for key, exists := range map[string]bool{"logs/access-1.log": false, "logs/access-2.log": false, "logs/error.log": true} {
+		if _, ok := server.Object("bucket", key); ok != exists {
+			t.Errorf("%q: expected existence to be %v", key, exists)
+		}
+	}
+}
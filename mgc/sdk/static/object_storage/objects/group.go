@@ -5,6 +5,7 @@ import (
 	"github.com/MagaluCloud/magalu/mgc/core/utils"
 	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/objects/acl"
 	object_lock "github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/objects/object-lock"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/objects/tags"
 )
 
 var GetGroup = utils.NewLazyLoader[core.Grouper](func() core.Grouper {
@@ -16,22 +17,31 @@ var GetGroup = utils.NewLazyLoader[core.Grouper](func() core.Grouper {
 		func() []core.Descriptor {
 			return []core.Descriptor{
 				acl.GetGroup(),         // object-storage objects acl
+				getCat(),               // object-storage objects cat
 				getCopy(),              // object-storage objects copy
 				getCopyAll(),           // object-storage objects copy-all
 				getDelete(),            // object-storage objects delete
 				getDeleteAll(),         // object-storage objects delete-all
 				getDownload(),          // object-storage objects download
 				getDownloadAll(),       // object-storage objects download-all
+				getDu(),                // object-storage objects du
+				getEdit(),              // object-storage objects edit
+				getFind(),              // object-storage objects find
 				getHead(),              // object-storage objects head
 				getList(),              // object-storage objects list
 				getMoveDir(),           // object-storage objects move-dir
 				getMove(),              // object-storage objects move
 				object_lock.GetGroup(), // object-storage objects object-lock
 				getSync(),              // object-storage objects sync
+				tags.GetGroup(),        // object-storage objects tags
 				getUpload(),            // object-storage objects upload
 				getUploadDir(),         // object-storage objects upload-dir
 				getPresign(),           // object-storage objects presigned
+				getPresignMultipart(),  // object-storage objects presign-multipart
+				getPresignPost(),       // object-storage objects presign-post
 				getPublicUrl(),         // object-storage objects public-url
+				getPurgeVersions(),     // object-storage objects purge-versions
+				getRestoreVersion(),    // object-storage objects restore-version
 				getVersions(),          // object-storage objects versions
 			}
 		},
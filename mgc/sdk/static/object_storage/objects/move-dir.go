@@ -36,6 +36,7 @@ var getMoveDir = utils.NewLazyLoader[core.Executor](func() core.Executor {
 })
 
 func moveDir(ctx context.Context, params moveDirParams, cfg common.Config) (moveDirParams, error) {
+	cfg = cfg.WithBandwidthLimiter()
 	srcIsRemote := isRemote(params.Source)
 	dstIsRemote := isRemote(params.Destination)
 
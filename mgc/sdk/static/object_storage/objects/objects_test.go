@@ -0,0 +1,218 @@
+package objects
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/s3test"
+)
+
+func newTestServer(t *testing.T) (*s3test.Server, context.Context, common.Config) {
+	server := s3test.NewServer(t)
+	server.CreateBucket("bucket")
+	return server, server.Context(context.Background()), server.Config()
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUploadDownload(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"page.html": "<html></html>"})
+
+	result, err := upload(ctx, uploadParams{
+		Source:      mgcSchemaPkg.FilePath(filepath.Join(dir, "page.html")),
+		Destination: "bucket/site/",
+		Tags:        common.ObjectTags{"team": "web"},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("upload() failed: %s", err)
+	}
+	if result.URI != "bucket/site/page.html" {
+		t.Errorf("unexpected destination %q", result.URI)
+	}
+
+	stored, ok := server.Object("bucket", "site/page.html")
+	if !ok {
+		t.Fatal("object was not uploaded")
+	}
+	if contentType := stored.Header.Get("Content-Type"); contentType != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type was not detected, got %q", contentType)
+	}
+	if stored.Tags["team"] != "web" {
+		t.Errorf("tags were not set: %v", stored.Tags)
+	}
+
+	head, err := headObject(ctx, headObjectParams{Destination: "bucket/site/page.html"}, cfg)
+	if err != nil {
+		t.Fatalf("headObject() failed: %s", err)
+	}
+	if head.ContentLength != int64(len("<html></html>")) {
+		t.Errorf("unexpected head response: %+v", head)
+	}
+
+	dst := mgcSchemaPkg.FilePath(filepath.Join(dir, "downloaded.html"))
+	if _, err = download(ctx, common.DownloadObjectParams{Source: "bucket/site/page.html", Destination: dst}, cfg); err != nil {
+		t.Fatalf("download() failed: %s", err)
+	}
+	data, err := os.ReadFile(dst.String())
+	if err != nil || string(data) != "<html></html>" {
+		t.Errorf("unexpected downloaded content %q: %v", data, err)
+	}
+}
+
+func TestHeadReplicationStatus(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "page.html", []byte("<html></html>"))
+
+	head, err := headObject(ctx, headObjectParams{Destination: "bucket/page.html"}, cfg)
+	if err != nil || head.ReplicationStatus != "" {
+		t.Errorf("objects that aren't replicated must have no status: %+v %v", head, err)
+	}
+
+	server.SetReplicationStatus("bucket", "page.html", "COMPLETED")
+	if head, err = headObject(ctx, headObjectParams{Destination: "bucket/page.html"}, cfg); err != nil || head.ReplicationStatus != "COMPLETED" {
+		t.Errorf("replication status was not exposed: %+v %v", head, err)
+	}
+}
+
+func TestList(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	for _, key := range []string{"a.txt", "b.log", "dir/c.txt", "dir/sub/d.txt"} {
+		server.PutObject("bucket", key, []byte(key))
+	}
+
+	keys := func(result listResponse) (files, dirs []string) {
+		for _, c := range result.Contents {
+			files = append(files, c.Key)
+		}
+		for _, p := range result.CommonPrefixes {
+			dirs = append(dirs, p.Path)
+		}
+		return
+	}
+
+	pagination := common.PaginationParams{MaxItems: 1000}
+
+	result, err := List(ctx, listParams{ListObjectsParams: common.ListObjectsParams{Destination: "bucket", PaginationParams: pagination}}, cfg)
+	if err != nil {
+		t.Fatalf("List() failed: %s", err)
+	}
+	files, dirs := keys(result)
+	if len(files) != 2 || len(dirs) != 1 || dirs[0] != "dir/" {
+		t.Errorf("unexpected listing: files=%v dirs=%v", files, dirs)
+	}
+
+	result, err = List(ctx, listParams{
+		ListObjectsParams: common.ListObjectsParams{Destination: "bucket", Recursive: true, PaginationParams: pagination},
+		Filters:           common.Filters{FilterParams: []common.FilterParams{{Exclude: "*.log"}}},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("List() failed: %s", err)
+	}
+	files, _ = keys(result)
+	sort.Strings(files)
+	expected := []string{"a.txt", "dir/c.txt", "dir/sub/d.txt"}
+	if len(files) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, files)
+	}
+	for i := range expected {
+		if files[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, files)
+		}
+	}
+
+	// More than a page, so the listing follows the continuation token
+	for i := 0; i < 1500; i++ {
+		server.PutObject("bucket", fmt.Sprintf("many/%04d", i), nil)
+	}
+	result, err = List(ctx, listParams{ListObjectsParams: common.ListObjectsParams{
+		Destination:      "bucket/many",
+		Recursive:        true,
+		PaginationParams: common.PaginationParams{MaxItems: math.MaxInt64},
+	}}, cfg)
+	if err != nil {
+		t.Fatalf("List() failed: %s", err)
+	}
+	if len(result.Contents) != 1500 || result.Contents[1499].Key != "many/1499" {
+		t.Errorf("expected 1500 objects, got %d", len(result.Contents))
+	}
+}
+
+func TestDeleteAndVersions(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "file.txt", []byte("first"))
+	server.PutObject("bucket", "file.txt", []byte("second"))
+
+	versions, err := getObjectVersioning(ctx, versioningObjectParams{Destination: "bucket/file.txt"}, cfg)
+	if err != nil {
+		t.Fatalf("getObjectVersioning() failed: %s", err)
+	}
+	if len(versions) != 2 || !versions[0].IsLatest || versions[0].Size != int64(len("second")) {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+
+	if _, err = deleteObject(ctx, common.DeleteObjectParams{Destination: "bucket/file.txt"}, cfg); err != nil {
+		t.Fatalf("deleteObject() failed: %s", err)
+	}
+	if _, ok := server.Object("bucket", "file.txt"); ok {
+		t.Error("object is still visible after being deleted")
+	}
+
+	oldest := versions[1].VersionID
+	previous, ok := server.ObjectVersion("bucket", "file.txt", oldest)
+	if !ok || string(previous.Data) != "first" {
+		t.Error("previous versions must be kept when deleting without a version")
+	}
+
+	if _, err = deleteObject(ctx, common.DeleteObjectParams{Destination: "bucket/file.txt", Version: oldest}, cfg); err != nil {
+		t.Fatalf("deleteObject() failed: %s", err)
+	}
+	if _, ok = server.ObjectVersion("bucket", "file.txt", oldest); ok {
+		t.Error("version was not deleted")
+	}
+}
+
+func TestCopyKeepsHeaders(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"data.json": "{}"})
+
+	_, err := upload(ctx, uploadParams{
+		Source:        mgcSchemaPkg.FilePath(filepath.Join(dir, "data.json")),
+		Destination:   "bucket/data.json",
+		ObjectHeaders: common.ObjectHeaders{CacheControl: "no-cache"},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("upload() failed: %s", err)
+	}
+
+	if _, err = copy(ctx, common.CopyObjectParams{Source: "bucket/data.json", Destination: "bucket/backup/"}, cfg); err != nil {
+		t.Fatalf("copy() failed: %s", err)
+	}
+
+	copied, ok := server.Object("bucket", "backup/data.json")
+	if !ok || string(copied.Data) != "{}" {
+		t.Fatalf("object was not copied: %+v", copied)
+	}
+	if copied.Header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("headers were not copied: %v", copied.Header)
+	}
+}
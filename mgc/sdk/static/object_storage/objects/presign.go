@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"path"
 	"strings"
 	"time"
 
@@ -21,14 +22,17 @@ type presignObjectParams struct {
 }
 
 type presignedUrlResult struct {
-	URL mgcSchemaPkg.URI `json:"url"`
+	URL       mgcSchemaPkg.URI `json:"url"`
+	Method    string           `json:"method"`
+	ExpiresAt string           `json:"expires_at"`
+	Curl      string           `json:"curl"`
 }
 
 var getPresign = utils.NewLazyLoader[core.Executor](func() core.Executor {
 	executor := core.NewStaticExecute(
 		core.DescriptorSpec{
 			Name:        "presign",
-			Description: "Generate a pre-signed URL for accessing an object",
+			Description: "Generate a pre-signed URL for downloading (GET) or uploading (PUT) an object",
 		},
 		presign,
 	)
@@ -38,6 +42,10 @@ var getPresign = utils.NewLazyLoader[core.Executor](func() core.Executor {
 })
 
 func presign(ctx context.Context, p presignObjectParams, cfg common.Config) (presignResult *presignedUrlResult, err error) {
+	if p.Method, err = normalizePresignMethod(p.Method); err != nil {
+		return
+	}
+
 	req, err := newPresignedRequest(ctx, cfg, p)
 	if err != nil {
 		return
@@ -50,27 +58,72 @@ func presign(ctx context.Context, p presignObjectParams, cfg common.Config) (pre
 
 	accessKey, accessSecretKey := auth.AccessKeyPair()
 
-	if p.Expiry == "" {
-		p.Expiry = "5m"
-	}
-
-	expirationTime, err := time.ParseDuration(p.Expiry)
+	expirationTime, err := parsePresignExpiry(p.Expiry)
 	if err != nil {
-		return nil, core.UsageError{Err: fmt.Errorf("error when parsing the expirationTime for presigned url: %w", err)}
+		return
 	}
 
 	presignedURL, err := getPresignedURL(cfg, req, accessKey, accessSecretKey, expirationTime)
 	if err != nil {
 		return
 	}
+
+	fileName := path.Base(p.Destination.Path())
+	curl := "curl -o " + shellQuote(fileName) + " " + shellQuote(presignedURL)
+	if p.Method == http.MethodPut {
+		curl = "curl -X PUT --upload-file " + shellQuote(fileName) + " " + shellQuote(presignedURL)
+	}
+
 	return &presignedUrlResult{
-		URL: mgcSchemaPkg.URI(presignedURL),
+		URL:       mgcSchemaPkg.URI(presignedURL),
+		Method:    p.Method,
+		ExpiresAt: presignExpiresAt(expirationTime),
+		Curl:      curl,
 	}, nil
 }
 
+// The default is only set by the CLI, so an empty method is taken as GET as well
+func normalizePresignMethod(method string) (string, error) {
+	switch method = strings.ToUpper(method); method {
+	case "":
+		return http.MethodGet, nil
+	case http.MethodGet, http.MethodPut:
+		return method, nil
+	}
+	return "", core.UsageError{Err: fmt.Errorf("invalid method %q, expected GET or PUT", method)}
+}
+
+// Longest expiry accepted by the server for presigned URLs and policies
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// Parses the expiry of presigned URLs and policies, 5 minutes if empty
+func parsePresignExpiry(expiry string) (time.Duration, error) {
+	if expiry == "" {
+		expiry = "5m"
+	}
+
+	expirationTime, err := time.ParseDuration(expiry)
+	if err != nil {
+		return 0, core.UsageError{Err: fmt.Errorf("error when parsing the expirationTime for presigned url: %w", err)}
+	}
+	if expirationTime < time.Second || expirationTime > maxPresignExpiry {
+		return 0, core.UsageError{Err: fmt.Errorf("expirationTime for presigned URL should be between 1 second and 7 days")}
+	}
+	return expirationTime, nil
+}
+
+func presignExpiresAt(expirationTime time.Duration) string {
+	return time.Now().Add(expirationTime).UTC().Format(time.RFC3339)
+}
+
+// Quotes s so it's taken literally by POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 func newPresignedRequest(ctx context.Context, cfg common.Config, p presignObjectParams) (*http.Request, error) {
-	if p.Method == "GET" {
-		headFile, err := common.HeadFile(ctx, cfg, p.Destination, "")
+	if p.Method == http.MethodGet {
+		headFile, err := common.HeadFile(ctx, cfg, p.Destination, "", nil)
 		if err != nil {
 			return nil, err
 		}
@@ -80,7 +133,6 @@ func newPresignedRequest(ctx context.Context, cfg common.Config, p presignObject
 
 		}
 	} else {
-		fmt.Println(len(strings.Split(p.Destination.String(), "/")))
 		if len(strings.Split(p.Destination.String(), "/")) < 2 {
 			return nil, core.UsageError{Err: fmt.Errorf("at least one key is required. Your input: %s", p.Destination)}
 		}
@@ -94,12 +146,8 @@ func newPresignedRequest(ctx context.Context, cfg common.Config, p presignObject
 	return http.NewRequestWithContext(ctx, p.Method, string(host), nil)
 }
 
+// The expiration time must have been validated by parsePresignExpiry()
 func getPresignedURL(cfg common.Config, req *http.Request, accessKey, secretKey string, expirationTime time.Duration) (presignedUrl string, err error) {
-	if expirationTime < time.Second || expirationTime > 604000*time.Second {
-		err = core.UsageError{Err: fmt.Errorf("expirationTime for presigned URL should be between 1 second and 7 days")}
-		return
-	}
-
 	url, err := common.SignedUrl(req, accessKey, secretKey, cfg.Region, expirationTime)
 	if err != nil {
 		return
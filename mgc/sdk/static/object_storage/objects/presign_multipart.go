@@ -0,0 +1,145 @@
+package objects
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcAuthPkg "github.com/MagaluCloud/magalu/mgc/core/auth"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+// Limit of parts of a multipart upload
+const maxUploadParts = 10000
+
+type presignMultipartParams struct {
+	Destination  mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the object to be uploaded,example=bucket1/video.mp4" mgc:"positional"`
+	Parts        int              `json:"parts" jsonschema:"description=Number of parts to generate URLs for,minimum=1,maximum=10000,example=10"`
+	UploadId     string           `json:"upload_id,omitempty" jsonschema:"description=Existing multipart upload to generate URLs for. A new one is started if omitted"`
+	FirstPart    int              `json:"first_part,omitempty" jsonschema:"description=Number of the first part to generate an URL for,default=1,minimum=1,maximum=10000"`
+	StorageClass string           `json:"storage_class,omitempty" jsonschema:"description=Storage class of the new multipart upload,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
+	Expiry       string           `json:"expires-in,omitempty" jsonschema_description:"Expiration time for the pre-signed URLs. Valid time units are 'ns, 'us' (or 'µs'), 'ms', 's',  'm', and 'h'.default=5m" jsonschema:"example=2h"`
+}
+
+type presignedPart struct {
+	PartNumber int    `json:"part_number"`
+	URL        string `json:"url"`
+	Curl       string `json:"curl"`
+}
+
+type presignMultipartResult struct {
+	Destination  mgcSchemaPkg.URI `json:"dst"`
+	UploadId     string           `json:"upload_id"`
+	ExpiresAt    string           `json:"expires_at"`
+	Parts        []presignedPart  `json:"parts"`
+	CompleteURL  string           `json:"complete_url"`
+	CompleteBody string           `json:"complete_body"`
+	CompleteCurl string           `json:"complete_curl"`
+	AbortURL     string           `json:"abort_url"`
+}
+
+var getPresignMultipart = utils.NewLazyLoader[core.Executor](func() core.Executor {
+	executor := core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:    "presign-multipart",
+			Summary: "Generate pre-signed URLs for uploading a large object in parts",
+			Description: `Starts a multipart upload, unless one is given, and generates a pre-signed URL
+for each of its parts, so clients without credentials can upload large objects.
+
+Every part but the last must be at least 5MiB. Each part upload responds with an ETag header,
+which must replace the matching placeholder of complete_body before posting it to complete_url.
+The upload may be discarded with a DELETE request to abort_url.`,
+		},
+		presignMultipart,
+	)
+	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
+		return "table=PART:$.parts[*].part_number,URL:$.parts[*].url"
+	})
+})
+
+func presignMultipart(ctx context.Context, p presignMultipartParams, cfg common.Config) (result presignMultipartResult, err error) {
+	if p.Destination.Path() == "" {
+		return result, core.UsageError{Err: fmt.Errorf("at least one key is required. Your input: %s", p.Destination)}
+	}
+	if p.FirstPart == 0 {
+		p.FirstPart = 1
+	}
+	if p.Parts < 1 || p.FirstPart < 1 || p.FirstPart+p.Parts-1 > maxUploadParts {
+		return result, core.UsageError{Err: fmt.Errorf("part numbers must be between 1 and %d", maxUploadParts)}
+	}
+
+	expirationTime, err := parsePresignExpiry(p.Expiry)
+	if err != nil {
+		return
+	}
+
+	auth := mgcAuthPkg.FromContext(ctx)
+	if auth == nil {
+		return result, fmt.Errorf("programming error: unable to get auth from context")
+	}
+	accessKey, secretKey := auth.AccessKeyPair()
+
+	if p.UploadId == "" {
+		if p.UploadId, err = common.CreateMultipartUpload(ctx, cfg, p.Destination, p.StorageClass); err != nil {
+			return
+		}
+	}
+
+	host, err := common.BuildBucketHostWithPath(cfg, common.NewBucketNameFromURI(p.Destination), p.Destination.Path())
+	if err != nil {
+		return result, core.UsageError{Err: err}
+	}
+	sign := func(method string, partNumber int) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, method, string(host), nil)
+		if err != nil {
+			return "", err
+		}
+		q := req.URL.Query()
+		q.Set("uploadId", p.UploadId)
+		if partNumber > 0 {
+			q.Set("partNumber", strconv.Itoa(partNumber))
+		}
+		req.URL.RawQuery = q.Encode()
+		return getPresignedURL(cfg, req, accessKey, secretKey, expirationTime)
+	}
+
+	result = presignMultipartResult{
+		Destination: p.Destination,
+		UploadId:    p.UploadId,
+		ExpiresAt:   presignExpiresAt(expirationTime),
+		Parts:       make([]presignedPart, 0, p.Parts),
+	}
+
+	fileName := path.Base(p.Destination.Path())
+	var completeBody strings.Builder
+	completeBody.WriteString("<CompleteMultipartUpload>")
+	for partNumber := p.FirstPart; partNumber < p.FirstPart+p.Parts; partNumber++ {
+		url, err := sign(http.MethodPut, partNumber)
+		if err != nil {
+			return result, err
+		}
+		result.Parts = append(result.Parts, presignedPart{
+			PartNumber: partNumber,
+			URL:        url,
+			Curl:       fmt.Sprintf("curl -i -X PUT --upload-file %s %s", shellQuote(fmt.Sprintf("%s.part%d", fileName, partNumber)), shellQuote(url)),
+		})
+		fmt.Fprintf(&completeBody, "<Part><PartNumber>%d</PartNumber><ETag>ETAG_%d</ETag></Part>", partNumber, partNumber)
+	}
+	completeBody.WriteString("</CompleteMultipartUpload>")
+	result.CompleteBody = completeBody.String()
+
+	if result.CompleteURL, err = sign(http.MethodPost, 0); err != nil {
+		return
+	}
+	result.CompleteCurl = fmt.Sprintf("curl -X POST -H 'Content-Type: application/xml' --data-binary @complete.xml %s", shellQuote(result.CompleteURL))
+	if result.AbortURL, err = sign(http.MethodDelete, 0); err != nil {
+		return
+	}
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package objects
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcAuthPkg "github.com/MagaluCloud/magalu/mgc/core/auth"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type presignPostParams struct {
+	Destination       mgcSchemaPkg.URI `json:"dst" jsonschema_description:"Object to be uploaded or, if ending with '/', the key prefix allowed for the uploaded files, which keep their names" jsonschema:"example=bucket1/uploads/" mgc:"positional"`
+	MinSize           string           `json:"min_size,omitempty" jsonschema:"description=Smallest file size allowed,example=1KiB"`
+	MaxSize           string           `json:"max_size,omitempty" jsonschema:"description=Largest file size allowed,example=10MiB"`
+	ContentType       string           `json:"content_type,omitempty" jsonschema:"description=Content-Type the form must send,example=image/png"`
+	ContentTypePrefix string           `json:"content_type_prefix,omitempty" jsonschema:"description=Prefix of the Content-Type the form must send,example=image/"`
+	Expiry            string           `json:"expires-in,omitempty" jsonschema_description:"Expiration time for the policy. Valid time units are 'ns, 'us' (or 'µs'), 'ms', 's',  'm', and 'h'.default=5m" jsonschema:"example=2h"`
+}
+
+type presignPostResult struct {
+	common.PostPolicyForm `json:",squash"` // nolint
+	ExpiresAt             string           `json:"expires_at"`
+	Curl                  string           `json:"curl"`
+	HTML                  string           `json:"html"`
+}
+
+var getPresignPost = utils.NewLazyLoader[core.Executor](func() core.Executor {
+	executor := core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:    "presign-post",
+			Summary: "Generate a signed POST policy for uploading from browsers with HTML forms",
+			Description: `Generates the URL and the form fields that allow anyone to upload a file straight to the bucket,
+as long as the upload matches the given conditions on the key, size and Content-Type.
+
+The fields must be posted as multipart/form-data, with the file as the last field. When
+content_type_prefix is given, the form must also send a Content-Type field starting with it.`,
+		},
+		presignPost,
+	)
+	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
+		return "template={{.curl}}\n"
+	})
+})
+
+func presignPost(ctx context.Context, p presignPostParams, cfg common.Config) (result presignPostResult, err error) {
+	if p.ContentType != "" && p.ContentTypePrefix != "" {
+		return result, core.UsageError{Err: fmt.Errorf("content_type and content_type_prefix are mutually exclusive")}
+	}
+
+	expirationTime, err := parsePresignExpiry(p.Expiry)
+	if err != nil {
+		return
+	}
+
+	auth := mgcAuthPkg.FromContext(ctx)
+	if auth == nil {
+		return result, fmt.Errorf("programming error: unable to get auth from context")
+	}
+	accessKey, secretKey := auth.AccessKeyPair()
+
+	fields := map[string]string{}
+	conditions := []any{}
+
+	key := p.Destination.Path()
+	if key == "" || strings.HasSuffix(p.Destination.String(), "/") {
+		if key != "" {
+			key += "/"
+		}
+		fields["key"] = key + "${filename}"
+		conditions = append(conditions, []any{"starts-with", "$key", key})
+	} else {
+		fields["key"] = key
+		conditions = append(conditions, map[string]string{"key": key})
+	}
+
+	if p.MinSize != "" || p.MaxSize != "" {
+		minSize, err := parseSize(p.MinSize, 0)
+		if err != nil {
+			return result, core.UsageError{Err: err}
+		}
+		maxSize, err := parseSize(p.MaxSize, math.MaxInt64)
+		if err != nil {
+			return result, core.UsageError{Err: err}
+		}
+		if maxSize < minSize {
+			return result, core.UsageError{Err: fmt.Errorf("max_size %q is smaller than min_size %q", p.MaxSize, p.MinSize)}
+		}
+		conditions = append(conditions, []any{"content-length-range", minSize, maxSize})
+	}
+
+	if p.ContentType != "" {
+		fields["Content-Type"] = p.ContentType
+		conditions = append(conditions, map[string]string{"Content-Type": p.ContentType})
+	}
+	if p.ContentTypePrefix != "" {
+		conditions = append(conditions, []any{"starts-with", "$Content-Type", p.ContentTypePrefix})
+	}
+
+	bucketName := common.NewBucketNameFromURI(p.Destination)
+	form, err := common.SignPostPolicy(cfg, bucketName, fields, conditions, time.Now().Add(expirationTime), accessKey, secretKey)
+	if err != nil {
+		return
+	}
+
+	names := make([]string, 0, len(form.Fields))
+	for name := range form.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fileName := "file"
+	if !strings.HasSuffix(fields["key"], "${filename}") {
+		fileName = path.Base(key)
+	}
+
+	curl := []string{"curl -X POST " + shellQuote(form.URL)}
+	htmlForm := []string{fmt.Sprintf(`<form action="%s" method="post" enctype="multipart/form-data">`, html.EscapeString(form.URL))}
+	for _, name := range names {
+		curl = append(curl, "-F "+shellQuote(name+"="+form.Fields[name]))
+		htmlForm = append(htmlForm, fmt.Sprintf(`  <input type="hidden" name="%s" value="%s" />`, html.EscapeString(name), html.EscapeString(form.Fields[name])))
+	}
+	if p.ContentTypePrefix != "" {
+		curl = append(curl, "-F "+shellQuote("Content-Type="+p.ContentTypePrefix))
+		htmlForm = append(htmlForm, fmt.Sprintf(`  <input type="text" name="Content-Type" value="%s" />`, html.EscapeString(p.ContentTypePrefix)))
+	}
+	curl = append(curl, "-F "+shellQuote("file=@"+fileName))
+	htmlForm = append(htmlForm, `  <input type="file" name="file" />`, `  <input type="submit" value="Upload" />`, `</form>`)
+
+	return presignPostResult{
+		PostPolicyForm: form,
+		ExpiresAt:      presignExpiresAt(expirationTime),
+		Curl:           strings.Join(curl, " \\\n  "),
+		HTML:           strings.Join(htmlForm, "\n"),
+	}, nil
+}
@@ -0,0 +1,214 @@
+package objects
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+)
+
+func TestPresignPutAndGet(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+
+	put, err := presign(ctx, presignObjectParams{Destination: "bucket/dir/file.txt", Method: http.MethodPut}, cfg)
+	if err != nil {
+		t.Fatalf("presign() failed: %s", err)
+	}
+	if !strings.HasPrefix(put.Curl, "curl -X PUT --upload-file 'file.txt' '") {
+		t.Errorf("unexpected curl snippet: %s", put.Curl)
+	}
+	req, _ := http.NewRequest(http.MethodPut, put.URL.String(), strings.NewReader("content"))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("presigned PUT failed: %v %v", resp, err)
+	}
+	if obj, ok := server.Object("bucket", "dir/file.txt"); !ok || string(obj.Data) != "content" {
+		t.Fatal("object was not uploaded with the presigned URL")
+	}
+
+	get, err := presign(ctx, presignObjectParams{Destination: "bucket/dir/file.txt", Method: http.MethodGet, Expiry: "1h"}, cfg)
+	if err != nil {
+		t.Fatalf("presign() failed: %s", err)
+	}
+	resp, err = http.Get(get.URL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if data, _ := io.ReadAll(resp.Body); string(data) != "content" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	if _, err = presign(ctx, presignObjectParams{Destination: "bucket/dir/file.txt", Method: http.MethodGet, Expiry: "8d"}, cfg); err == nil {
+		t.Error("expected an error when expiring after 7 days")
+	}
+}
+
+func TestPresignMethod(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "file.txt", []byte("content"))
+
+	for method, expected := range map[string]string{"": http.MethodGet, "get": http.MethodGet, "put": http.MethodPut} {
+		result, err := presign(ctx, presignObjectParams{Destination: "bucket/file.txt", Method: method}, cfg)
+		if err != nil {
+			t.Fatalf("%q: presign() failed: %s", method, err)
+		}
+		if result.Method != expected {
+			t.Errorf("%q: expected method %s, got %q", method, expected, result.Method)
+		}
+	}
+
+	// Without a method, the object must exist as it does for GET
+	if _, err := presign(ctx, presignObjectParams{Destination: "bucket/missing.txt"}, cfg); err == nil {
+		t.Error("expected an error presigning a missing object")
+	}
+
+	var usageErr core.UsageError
+	if _, err := presign(ctx, presignObjectParams{Destination: "bucket/file.txt", Method: http.MethodDelete}, cfg); !errors.As(err, &usageErr) {
+		t.Errorf("expected a usage error for DELETE, got %v", err)
+	}
+}
+
+func TestParsePresignExpiry(t *testing.T) {
+	for expiry, valid := range map[string]bool{
+		"":        true,
+		"1s":      true,
+		"168h":    true,
+		"999ms":   false,
+		"168h1s":  false,
+		"8d":      false,
+		"forever": false,
+	} {
+		if _, err := parsePresignExpiry(expiry); (err == nil) != valid {
+			t.Errorf("%q: expected valid=%v, got %v", expiry, valid, err)
+		}
+	}
+}
+
+func TestPresignMultipart(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+
+	result, err := presignMultipart(ctx, presignMultipartParams{Destination: "bucket/big.bin", Parts: 2}, cfg)
+	if err != nil {
+		t.Fatalf("presignMultipart() failed: %s", err)
+	}
+	if result.UploadId == "" || len(result.Parts) != 2 || result.Parts[1].PartNumber != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	body := result.CompleteBody
+	for i, content := range []string{"first-", "second"} {
+		req, _ := http.NewRequest(http.MethodPut, result.Parts[i].URL, strings.NewReader(content))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("presigned part upload failed: %v %v", resp, err)
+		}
+		body = strings.Replace(body, "ETAG_"+string(rune('1'+i)), resp.Header.Get("ETag"), 1)
+	}
+
+	resp, err := http.Post(result.CompleteURL, "application/xml", strings.NewReader(body))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("presigned completion failed: %v %v", resp, err)
+	}
+	if obj, ok := server.Object("bucket", "big.bin"); !ok || string(obj.Data) != "first-second" {
+		t.Errorf("object was not assembled from the parts: %q", obj.Data)
+	}
+
+	// More URLs for an existing upload
+	more, err := presignMultipart(ctx, presignMultipartParams{Destination: "bucket/big.bin", Parts: 1, FirstPart: 3, UploadId: "existing"}, cfg)
+	if err != nil {
+		t.Fatalf("presignMultipart() failed: %s", err)
+	}
+	if more.UploadId != "existing" || more.Parts[0].PartNumber != 3 || !strings.Contains(more.Parts[0].URL, "partNumber=3") {
+		t.Errorf("unexpected result: %+v", more)
+	}
+
+	if _, err = presignMultipart(ctx, presignMultipartParams{Destination: "bucket/big.bin", Parts: 2, FirstPart: 10000}, cfg); !errors.As(err, new(core.UsageError)) {
+		t.Errorf("expected an usage error past the last part, got %v", err)
+	}
+}
+
+func postForm(t *testing.T, form presignPostResult, fileName string, data []byte, extra map[string]string) *http.Response {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range form.Fields {
+		_ = writer.WriteField(name, value)
+	}
+	for name, value := range extra {
+		_ = writer.WriteField(name, value)
+	}
+	file, _ := writer.CreateFormFile("file", fileName)
+	_, _ = file.Write(data)
+	_ = writer.Close()
+
+	resp, err := http.Post(form.URL, writer.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestPresignPost(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+
+	form, err := presignPost(ctx, presignPostParams{
+		Destination:       "bucket/uploads/",
+		MaxSize:           "10B",
+		ContentTypePrefix: "image/",
+	}, cfg)
+	if err != nil {
+		t.Fatalf("presignPost() failed: %s", err)
+	}
+	if form.Fields["key"] != "uploads/${filename}" || !strings.Contains(form.HTML, `name="policy"`) || !strings.HasSuffix(form.Curl, "-F 'file=@file'") {
+		t.Errorf("unexpected form: %+v", form)
+	}
+
+	if resp := postForm(t, form, "a.png", []byte("png"), map[string]string{"Content-Type": "image/png"}); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST upload failed: %s", resp.Status)
+	}
+	if obj, ok := server.Object("bucket", "uploads/a.png"); !ok || obj.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("object was not uploaded: %+v", obj)
+	}
+
+	for _, tc := range []struct {
+		data  string
+		extra map[string]string
+	}{
+		{"too large to upload", map[string]string{"Content-Type": "image/png"}},
+		{"text", map[string]string{"Content-Type": "text/plain"}},
+		{"png", map[string]string{"Content-Type": "image/png", "acl": "public-read"}},
+	} {
+		if resp := postForm(t, form, "b.png", []byte(tc.data), tc.extra); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%v: expected the upload to be denied, got %s", tc.extra, resp.Status)
+		}
+	}
+
+	tampered := form
+	tampered.Fields = map[string]string{}
+	for name, value := range form.Fields {
+		tampered.Fields[name] = value
+	}
+	tampered.Fields["key"] = "elsewhere/${filename}"
+	if resp := postForm(t, tampered, "c.png", []byte("png"), map[string]string{"Content-Type": "image/png"}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the upload outside of the prefix to be denied, got %s", resp.Status)
+	}
+
+	single, err := presignPost(ctx, presignPostParams{Destination: "bucket/avatar.png", ContentType: "image/png"}, cfg)
+	if err != nil {
+		t.Fatalf("presignPost() failed: %s", err)
+	}
+	if single.Fields["key"] != "avatar.png" || !strings.HasSuffix(single.Curl, "-F 'file=@avatar.png'") {
+		t.Errorf("unexpected form: %+v", single)
+	}
+	if resp := postForm(t, single, "whatever.png", []byte("png"), nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST upload failed: %s", resp.Status)
+	}
+	if _, ok := server.Object("bucket", "avatar.png"); !ok {
+		t.Error("object was not uploaded to the given key")
+	}
+}
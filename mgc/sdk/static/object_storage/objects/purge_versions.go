@@ -0,0 +1,189 @@
+package objects
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+const (
+	purgeTargetNoncurrent    = "noncurrent"
+	purgeTargetDeleteMarkers = "delete-markers"
+)
+
+type purgeVersionsParams struct {
+	Destination mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the bucket or prefix whose versions are purged,example=bucket1/logs" mgc:"positional"`
+	Target      string           `json:"target,omitempty" jsonschema:"description=What to purge: noncurrent versions or delete markers,enum=noncurrent,enum=delete-markers,default=noncurrent,required"`
+	KeepLast    int              `json:"keep_last,omitempty" jsonschema:"description=Number of the most recent noncurrent versions kept for each object,default=0,minimum=0,required"`
+	OlderThan   string           `json:"older_than,omitempty" jsonschema_description:"Only versions last modified before this age or date, such as 30d, 12h or 2024-01-31"`
+	DryRun      bool             `json:"dry_run,omitempty" jsonschema:"description=Only list the versions that would be purged,default=false"`
+	BatchSize   int              `json:"batch_size,omitempty" jsonschema:"description=Limit of items per batch to delete,default=1000,minimum=1,maximum=1000,required"`
+}
+
+type purgeVersionsResult struct {
+	Versions  []*common.ObjectVersionEntry `json:"versions"`
+	Count     int                          `json:"count"`
+	TotalSize int64                        `json:"total_size"`
+	DryRun    bool                         `json:"dry_run"`
+}
+
+var getPurgeVersions = utils.NewLazyLoader[core.Executor](func() core.Executor {
+	executor := core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:    "purge-versions",
+			Summary: "Delete the noncurrent versions or the delete markers of objects",
+			Description: `Permanently deletes the noncurrent versions of every object under the given path,
+keeping the current version and the most recent keep_last noncurrent ones.
+
+With the delete-markers target, only delete markers are removed: the noncurrent ones and the
+current ones of objects without any version left. Current delete markers hiding older versions
+are kept, since removing them would bring the object back.
+
+` + ageFormatsDescription,
+		},
+		purgeVersions,
+	)
+
+	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
+		return "table=KEY:$.versions[*].Key,VERSION:$.versions[*].VersionId,SIZE:fileSize($.versions[*].ContentSize),LASTMODIFIED:humanTime($.versions[*].LastModified)"
+	})
+})
+
+// Selects the entries of a single key, listed latest first, that must be purged
+func selectPurgedVersions(entries []*common.ObjectVersionEntry, params purgeVersionsParams, olderThan time.Time) []*common.ObjectVersionEntry {
+	selected := []*common.ObjectVersionEntry{}
+	noncurrent := 0
+	onlyDeleteMarkers := true
+	for _, entry := range entries {
+		onlyDeleteMarkers = onlyDeleteMarkers && entry.IsDeleteMarker()
+	}
+
+	for _, entry := range entries {
+		if !olderThan.IsZero() && !entry.ModTime().Before(olderThan) {
+			if !entry.IsLatest {
+				noncurrent++
+			}
+			continue
+		}
+
+		switch params.Target {
+		case purgeTargetDeleteMarkers:
+			if entry.IsDeleteMarker() && (!entry.IsLatest || onlyDeleteMarkers) {
+				selected = append(selected, entry)
+			}
+		default:
+			if entry.IsLatest {
+				continue
+			}
+			noncurrent++
+			if noncurrent > params.KeepLast {
+				selected = append(selected, entry)
+			}
+		}
+	}
+	return selected
+}
+
+func purgeVersions(ctx context.Context, params purgeVersionsParams, cfg common.Config) (result purgeVersionsResult, err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	switch params.Target {
+	case "":
+		params.Target = purgeTargetNoncurrent
+	case purgeTargetNoncurrent, purgeTargetDeleteMarkers:
+	default:
+		return result, core.UsageError{Err: fmt.Errorf("invalid target %q, expected %s or %s", params.Target, purgeTargetNoncurrent, purgeTargetDeleteMarkers)}
+	}
+	if params.KeepLast < 0 {
+		return result, core.UsageError{Err: fmt.Errorf("keep_last must not be negative: %d", params.KeepLast)}
+	}
+	if params.BatchSize == 0 {
+		params.BatchSize = common.MaxBatchSize
+	}
+
+	var olderThan time.Time
+	if params.OlderThan != "" {
+		if olderThan, err = parseAge(params.OlderThan, time.Now()); err != nil {
+			return result, core.UsageError{Err: err}
+		}
+	}
+
+	result.Versions = []*common.ObjectVersionEntry{}
+	result.DryRun = params.DryRun
+
+	versions := common.ListVersionsGenerator(ctx, cfg, params.Destination, nil)
+	selected := purgedVersionsGenerator(ctx, versions, params, olderThan)
+	collect := func(entry pipeline.WalkDirEntry) {
+		version := entry.DirEntry().(*common.ObjectVersionEntry)
+		result.Versions = append(result.Versions, version)
+		result.TotalSize += version.ContentSize
+	}
+
+	if params.DryRun {
+		for entry := range selected {
+			if err = entry.Err(); err != nil {
+				return result, err
+			}
+			collect(entry)
+		}
+	} else if err = deleteEntries(ctx, cfg, params.Destination, params.BatchSize, selected, collect); err != nil {
+		return result, err
+	}
+
+	if cause := context.Cause(ctx); cause != nil {
+		return result, cause
+	}
+	result.Count = len(result.Versions)
+	return result, nil
+}
+
+// Versions of the same key are listed together, so they are selected a key at a time.
+// Listing errors are sent as they are and stop the generation
+func purgedVersionsGenerator(ctx context.Context, versions <-chan pipeline.WalkDirEntry, params purgeVersionsParams, olderThan time.Time) <-chan pipeline.WalkDirEntry {
+	out := make(chan pipeline.WalkDirEntry)
+	send := func(entry pipeline.WalkDirEntry) bool {
+		select {
+		case out <- entry:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(out)
+
+		var group []*common.ObjectVersionEntry
+		flush := func() bool {
+			for _, entry := range selectPurgedVersions(group, params, olderThan) {
+				if !send(pipeline.NewSimpleWalkDirEntry(entry.Key, entry, nil)) {
+					return false
+				}
+			}
+			group = group[:0]
+			return true
+		}
+
+		for entry := range versions {
+			if entry.Err() != nil {
+				send(entry)
+				return
+			}
+			version := entry.DirEntry().(*common.ObjectVersionEntry)
+			if len(group) > 0 && group[0].Key != version.Key && !flush() {
+				return
+			}
+			group = append(group, version)
+		}
+		flush()
+	}()
+
+	return out
+}
@@ -0,0 +1,56 @@
+package objects
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type restoreVersionParams struct {
+	Destination mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the object to be restored,example=bucket1/file.txt" mgc:"positional"`
+	Version     string           `json:"version" jsonschema:"description=Version of the object to restore as the current one,required" mgc:"positional"`
+}
+
+type restoreVersionResult struct {
+	Destination mgcSchemaPkg.URI `json:"dst"`
+	Version     string           `json:"version"`
+}
+
+var getRestoreVersion = utils.NewLazyLoader[core.Executor](func() core.Executor {
+	executor := core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:    "restore-version",
+			Summary: "Restore a previous version of an object",
+			Description: `Copies the given version over the object, in the server, so it becomes the
+current version again. The versions in between are kept, so the restore may be undone.`,
+		},
+		restoreVersion,
+	)
+
+	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
+		return "template=Restored {{.dst}} to version {{.version}}\n"
+	})
+})
+
+func restoreVersion(ctx context.Context, p restoreVersionParams, cfg common.Config) (result restoreVersionResult, err error) {
+	if p.Destination.Path() == "" {
+		return result, core.UsageError{Err: fmt.Errorf("destination must be a URI to an object")}
+	}
+	if p.Version == "" {
+		return result, core.UsageError{Err: fmt.Errorf("version cannot be empty")}
+	}
+
+	copier, err := common.NewCopier(ctx, cfg, p.Destination, p.Destination, p.Version, common.CopyOptions{})
+	if err != nil {
+		return result, fmt.Errorf("error validating version %q: %w", p.Version, err)
+	}
+	if err = copier.Copy(ctx); err != nil {
+		return
+	}
+
+	return restoreVersionResult{Destination: p.Destination, Version: p.Version}, nil
+}
@@ -2,319 +2,649 @@ package objects
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
-	sy "sync"
 	"time"
 
 	"github.com/MagaluCloud/magalu/mgc/core"
 	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
+	"github.com/MagaluCloud/magalu/mgc/core/progress_report"
 	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
 	"github.com/MagaluCloud/magalu/mgc/core/utils"
-	"github.com/MagaluCloud/magalu/mgc/sdk/openapi"
 	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
-	"github.com/pterm/pterm"
+	"go.uber.org/zap"
 )
 
-type UploadCounter struct {
-	mu sy.Mutex
-	v  uint64
-}
+const (
+	syncCompareMtime    = "mtime"
+	syncCompareSizeOnly = "size-only"
+	syncCompareChecksum = "checksum"
+)
 
-type fileSyncStats struct {
-	SourceLength  int64
-	SourceModTime int64
-	Etag          string
-}
+const (
+	syncActionUpload   = "upload"
+	syncActionDownload = "download"
+	syncActionCopy     = "copy"
+	syncActionDelete   = "delete"
+)
+
+var syncLogger = utils.NewLazyLoader(func() *zap.SugaredLogger {
+	return logger().Named("sync")
+})
 
 type syncParams struct {
-	Local     mgcSchemaPkg.URI `json:"local" jsonschema:"description=Local path,example=./" mgc:"positional"`
-	Bucket    mgcSchemaPkg.URI `json:"bucket" jsonschema:"description=Bucket path,example=my-bucket/dir/" mgc:"positional"`
-	Delete    bool             `json:"delete,omitempty" jsonschema:"description=Deletes any item at the bucket not present on the local,default=false"`
-	BatchSize int              `json:"batch_size,omitempty" jsonschema:"description=Limit of items per batch to delete,default=1000,minimum=1,maximum=1000" example:"1000"`
+	Source      mgcSchemaPkg.URI `json:"src,omitempty" jsonschema_description:"Source path, either a local folder or a bucket path" jsonschema:"example=./" mgc:"positional"`
+	Destination mgcSchemaPkg.URI `json:"dst,omitempty" jsonschema_description:"Destination path, either a local folder or a bucket path" jsonschema:"example=my-bucket/dir/" mgc:"positional"`
+	// Names used before syncing in both directions, always a local to bucket sync
+	Local                          mgcSchemaPkg.URI `json:"local,omitempty" jsonschema:"description=Local path to be uploaded. Same as src,example=./"`
+	Bucket                         mgcSchemaPkg.URI `json:"bucket,omitempty" jsonschema:"description=Bucket path to upload to. Same as dst,example=my-bucket/dir/"`
+	Delete                         bool             `json:"delete,omitempty" jsonschema:"description=Deletes any item at the destination not present on the source,default=false"`
+	BatchSize                      int              `json:"batch_size,omitempty" jsonschema:"description=Limit of items per batch to delete,default=1000,minimum=1,maximum=1000" example:"1000"`
+	DryRun                         bool             `json:"dry_run,omitempty" jsonschema:"description=Only show the changes that would be made,default=false"`
+	Compare                        string           `json:"compare,omitempty" jsonschema_description:"How to decide if an item present on both sides must be transferred. 'mtime' transfers it if the sizes differ or the source is newer, 'size-only' if the sizes differ and 'checksum' if the MD5 differs from the destination ETag (multipart objects are always transferred)" jsonschema:"enum=mtime,enum=size-only,enum=checksum,default=mtime"`
+	DetectContentType              *bool            `json:"detect_content_type,omitempty" jsonschema:"description=Detect the Content-Type of each file from its extension and content when content_type is omitted,default=true"`
+	common.ObjectHeaders           `json:",squash"` // nolint
+	common.Filters                 `json:",squash"` // nolint
+	common.FileAttributesParams    `json:",squash"` // nolint
+	common.RestoreAttributesParams `json:",squash"` // nolint
+	common.EncryptionParams        `json:",squash"` // nolint
+}
+
+type syncAction struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
 }
 
 type syncResult struct {
-	Source        mgcSchemaPkg.URI `json:"src" jsonschema:"description=Source path to sync the remote with,example=./" mgc:"positional"`
-	Destination   mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Full destination path to sync with the source path,example=s3://my-bucket/dir/" mgc:"positional"`
-	FilesDeleted  int              `json:"deleted"`
-	FilesUploaded int              `json:"uploaded"`
-	Deleted       bool             `json:"hasDeleted"`
-	DeletedFiles  string           `json:"deletedFiles"`
+	Source          mgcSchemaPkg.URI `json:"src" jsonschema:"description=Source path to sync the destination with,example=./" mgc:"positional"`
+	Destination     mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Full destination path to sync with the source path,example=s3://my-bucket/dir/" mgc:"positional"`
+	FilesDeleted    int              `json:"deleted"`
+	FilesUploaded   int              `json:"uploaded"`
+	FilesDownloaded int              `json:"downloaded"`
+	FilesCopied     int              `json:"copied"`
+	FilesSkipped    int              `json:"skipped"`
+	Deleted         bool             `json:"hasDeleted"`
+	DeletedFiles    string           `json:"deletedFiles"`
+	DryRun          bool             `json:"dry_run"`
+	Planned         []syncAction     `json:"planned,omitempty"`
 }
 
 var getSync = utils.NewLazyLoader[core.Executor](func() core.Executor {
 	executor := core.NewStaticExecute(
 		core.DescriptorSpec{
-			Name:        "sync",
-			Summary:     "Synchronizes a local path with a bucket",
-			Description: "This command uploads any file from the local path to the bucket if it is not already present or has modified time changed.",
+			Name:    "sync",
+			Summary: "Synchronizes a local path with a bucket, a bucket with a local path or two bucket paths",
+			Description: `This command transfers any item from the source to the destination if it is not
+already present or if it changed, according to the comparison strategy. The direction is given
+by which of the paths are buckets (prefixed with "s3://"): local to bucket uploads, bucket to
+local downloads and bucket to bucket copies. If neither path has the prefix, the destination
+is taken as the bucket.`,
 		},
 		sync,
 	)
 
 	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
-		return "template={{if and (eq .deleted 0) (eq .uploaded 0)}}Already Synced{{- else}}" +
-			"Synced files from {{.src}} to {{.dst}}\n- {{.uploaded}} files uploaded\n- {{if .hasDeleted}}{{.deleted}} files deleted\n\nDeleted files:\n-{{.deletedFiles}}{{- else}}{{.deleted}} files to be deleted with the --delete parameter{{- end}}{{- end}}\n"
+		return "template={{if .dry_run}}Planned changes from {{.src}} to {{.dst}}:{{range .planned}}\n- {{.action}} {{.path}}{{else}}\nNothing to do{{end}}" +
+			"{{- else if and (eq .deleted 0) (eq .uploaded 0) (eq .downloaded 0) (eq .copied 0)}}Already Synced{{- else}}" +
+			"Synced files from {{.src}} to {{.dst}}\n- {{.uploaded}} files uploaded\n- {{.downloaded}} files downloaded\n- {{.copied}} files copied\n" +
+			"- {{if .hasDeleted}}{{.deleted}} files deleted\n\nDeleted files:\n-{{.deletedFiles}}{{- else}}{{.deleted}} files to be deleted with the --delete parameter{{- end}}{{- end}}\n"
 	})
 })
 
-var (
-	allBucketFiles = make(map[string]bool)
-	uploadFiles    = &UploadCounter{}
-)
+// Either a local folder or a bucket path
+type syncEndpoint struct {
+	uri    mgcSchemaPkg.URI
+	remote bool
+}
 
-func sync(ctx context.Context, params syncParams, cfg common.Config) (result core.Value, err error) {
-	if !strings.HasPrefix(string(params.Bucket), common.URIPrefix) {
-		logger().Debugw("Bucket path missing prefix, adding prefix")
-		params.Bucket = common.URIPrefix + params.Bucket
-	}
+// Items are identified by their slash separated path relative to the endpoint
+func (e syncEndpoint) remoteURI(rel string) mgcSchemaPkg.URI {
+	return e.uri.JoinPath(rel)
+}
 
-	if strings.HasPrefix(string(params.Local), common.URIPrefix) {
-		return nil, fmt.Errorf("local cannot be an bucket! To copy or move between buckets, use \"mgc object-storage objects copy/move\"")
-	}
+func (e syncEndpoint) localPath(rel string) mgcSchemaPkg.FilePath {
+	return mgcSchemaPkg.FilePath(filepath.Join(e.uri.String(), filepath.FromSlash(rel)))
+}
+
+func (e syncEndpoint) String() string {
+	return e.uri.String()
+}
+
+type syncEntry struct {
+	size    int64
+	modTime time.Time
+}
+
+// Holds the state of a single sync execution, nothing is shared between executions
+type syncState struct {
+	cfg      common.Config
+	params   syncParams
+	src      syncEndpoint
+	dst      syncEndpoint
+	key      common.SSECustomerKey
+	template uploadParams
+}
 
+func sync(ctx context.Context, params syncParams, cfg common.Config) (result core.Value, err error) {
+	cfg = cfg.WithBandwidthLimiter()
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(nil)
 
-	basePath, err := common.GetAbsSystemURI(params.Local)
+	s, err := newSyncState(params, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	if f, _ := os.Stat(basePath.String()); !f.IsDir() {
-		return nil, fmt.Errorf("local path must be a folder")
+	srcEntries, err := s.listEntries(ctx, s.src, cancel)
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", s.src, err)
+	}
+	dstEntries, err := s.listEntries(ctx, s.dst, cancel)
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", s.dst, err)
 	}
 
-	files, err := walkDir(ctx, basePath.String(), false)
+	transfers, skipped, err := s.planTransfers(ctx, srcEntries, dstEntries)
 	if err != nil {
 		return nil, err
 	}
 
-	totalFiles := len(files)
-	progressBar := pterm.DefaultProgressbar.
-		WithTotal(totalFiles).
-		WithTitle("Syncing files").
-		WithRemoveWhenDone(true)
+	extraneous := make([]string, 0)
+	for rel := range dstEntries {
+		if _, ok := srcEntries[rel]; !ok {
+			extraneous = append(extraneous, rel)
+		}
+	}
+	sort.Strings(extraneous)
+
+	res := syncResult{
+		Source:       s.src.uri,
+		Destination:  s.dst.uri,
+		FilesDeleted: len(extraneous),
+		FilesSkipped: skipped,
+		DryRun:       params.DryRun,
+	}
+	for _, action := range transfers {
+		switch action.Action {
+		case syncActionUpload:
+			res.FilesUploaded++
+		case syncActionDownload:
+			res.FilesDownloaded++
+		case syncActionCopy:
+			res.FilesCopied++
+		}
+	}
+
+	if params.DryRun {
+		res.Planned = transfers
+		if params.Delete {
+			for _, rel := range extraneous {
+				res.Planned = append(res.Planned, syncAction{Action: syncActionDelete, Path: rel})
+			}
+		}
+		return res, nil
+	}
+
+	if err = s.transfer(ctx, transfers); err != nil {
+		return nil, err
+	}
 
-	if !openapi.GetRawOutputFlag(ctx) {
-		progressBar, _ = progressBar.Start()
+	if params.Delete && len(extraneous) > 0 {
+		if err = s.delete(ctx, extraneous); err != nil {
+			return nil, err
+		}
+		res.Deleted = true
+		res.DeletedFiles = strings.Join(extraneous, ", ")
 	}
 
-	fillBucketFiles(ctx, params, cfg)
+	return res, nil
+}
 
-	err = processSyncFiles(ctx, cfg, params.Local, params.Bucket, basePath.String(), files, progressBar)
+// Fills src and dst from the names used before syncing in both directions
+func (p *syncParams) resolveLegacyPaths() error {
+	for _, legacy := range []struct {
+		name  string
+		value mgcSchemaPkg.URI
+		dst   *mgcSchemaPkg.URI
+	}{
+		{"local", p.Local, &p.Source},
+		{"bucket", p.Bucket, &p.Destination},
+	} {
+		if legacy.value == "" {
+			continue
+		}
+		if *legacy.dst != "" && *legacy.dst != legacy.value {
+			return core.UsageError{Err: fmt.Errorf("%s conflicts with the positional path %q", legacy.name, *legacy.dst)}
+		}
+		*legacy.dst = legacy.value
+	}
+	if p.Bucket != "" && !strings.HasPrefix(p.Destination.String(), common.URIPrefix) {
+		p.Destination = common.URIPrefix + p.Destination
+	}
+	return nil
+}
 
+func newSyncState(params syncParams, cfg common.Config) (*syncState, error) {
+	if err := params.resolveLegacyPaths(); err != nil {
+		return nil, err
+	}
+	if params.Source == "" || params.Destination == "" {
+		return nil, core.UsageError{Err: fmt.Errorf("source and destination cannot be empty")}
+	}
+
+	switch params.Compare {
+	case "":
+		params.Compare = syncCompareMtime
+	case syncCompareMtime, syncCompareSizeOnly, syncCompareChecksum:
+	default:
+		return nil, core.UsageError{Err: fmt.Errorf("invalid comparison strategy %q", params.Compare)}
+	}
+
+	if params.BatchSize == 0 {
+		params.BatchSize = common.MaxBatchSize
+	}
+	if err := common.ValidateSymlinks(params.Symlinks); err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+	key, err := params.CustomerKey()
 	if err != nil {
 		return nil, err
 	}
 
-	_, _ = progressBar.Stop()
+	src := syncEndpoint{uri: params.Source, remote: strings.HasPrefix(params.Source.String(), common.URIPrefix)}
+	dst := syncEndpoint{uri: params.Destination, remote: strings.HasPrefix(params.Destination.String(), common.URIPrefix)}
+
+	if !src.remote && !dst.remote {
+		syncLogger().Debugw("Destination path missing prefix, adding prefix")
+		dst = syncEndpoint{uri: common.URIPrefix + params.Destination, remote: true}
+	}
+
+	if !dst.remote && !params.ObjectHeaders.IsEmpty() {
+		return nil, core.UsageError{Err: fmt.Errorf("object headers can only be set when the destination is a bucket")}
+	}
+
+	for _, e := range []*syncEndpoint{&src, &dst} {
+		if e.remote {
+			continue
+		}
+		abs, err := common.GetAbsSystemURI(e.uri)
+		if err != nil {
+			return nil, err
+		}
+		e.uri = abs
+	}
+
+	if !src.remote {
+		if info, err := os.Stat(src.uri.String()); err != nil || !info.IsDir() {
+			return nil, core.UsageError{Err: fmt.Errorf("local path must be a folder")}
+		}
+	}
 
-	deletedFiles := make([]string, 0, len(allBucketFiles))
+	return &syncState{
+		cfg:    cfg,
+		params: params,
+		src:    src,
+		dst:    dst,
+		key:    key,
+		template: uploadParams{
+			DetectContentType:    params.DetectContentType,
+			ObjectHeaders:        params.ObjectHeaders,
+			FileAttributesParams: params.FileAttributesParams,
+			EncryptionParams:     params.EncryptionParams,
+		},
+	}, nil
+}
 
-	if params.Delete {
-		for file := range allBucketFiles {
+// Lists the files of the endpoint that pass the filters, keyed by their relative path
+func (s *syncState) listEntries(ctx context.Context, e syncEndpoint, cancel context.CancelCauseFunc) (map[string]syncEntry, error) {
+	// Stops the listing when returning early, so its goroutine isn't left blocked sending
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	var entries <-chan pipeline.WalkDirEntry
+	var toRelative func(string) string
+
+	if e.remote {
+		entries = common.ListGenerator(ctx, common.ListObjectsParams{
+			Destination: e.uri,
+			Recursive:   true,
+			PaginationParams: common.PaginationParams{
+				MaxItems: math.MaxInt64,
+			},
+		}, s.cfg, nil)
+		prefix := e.uri.Path()
+		if prefix != "" {
+			prefix += "/"
+		}
+		toRelative = func(key string) string { return strings.TrimPrefix(key, prefix) }
+	} else {
+		root := e.uri.String()
+		if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
+			// The destination folder is created by the downloads
+			return map[string]syncEntry{}, nil
+		}
+		entries = pipeline.WalkDirEntries(ctx, root, nil)
+		toRelative = func(p string) string {
+			rel, err := filepath.Rel(root, p)
 			if err != nil {
-				logger().Debugw("error deleting file", "error", err)
+				return p
 			}
-			deletedFiles = append(deletedFiles, file)
+			return filepath.ToSlash(rel)
 		}
-		delOb := common.DeleteObjectsParams{
-			Destination: params.Bucket,
-			ToDelete:    bucketObjectsToWalkDirEntry(ctx, deletedFiles),
-			BatchSize:   params.BatchSize,
+	}
+
+	entries = common.ApplyFilters(ctx, entries, s.params.FilterParams, cancel)
+
+	result := map[string]syncEntry{}
+	for entry := range entries {
+		if err := entry.Err(); err != nil {
+			return nil, err
+		}
+		if entry.DirEntry().IsDir() {
+			continue
 		}
-		err = common.DeleteObjects(ctx, delOb, cfg)
+		info, err := entry.DirEntry().Info()
 		if err != nil {
-			logger().Debugw("error deleting objects", "error", err)
+			return nil, err
+		}
+		if !e.remote && info.Mode()&fs.ModeSymlink != 0 {
+			var ok bool
+			if info, ok, err = s.localSymlinkInfo(entry.Path(), info, e == s.src); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
 		}
+		result[toRelative(entry.Path())] = syncEntry{size: info.Size(), modTime: info.ModTime()}
 	}
 
-	return syncResult{
-		Source:        params.Local,
-		Destination:   params.Bucket,
-		FilesDeleted:  len(allBucketFiles),
-		FilesUploaded: int(uploadFiles.Value()),
-		Deleted:       len(deletedFiles) > 0,
-		DeletedFiles:  strings.Join(deletedFiles, ", "),
-	}, nil
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-func bucketObjectsToWalkDirEntry(ctx context.Context, bucketObjects []string) <-chan pipeline.WalkDirEntry {
-	out := make(chan pipeline.WalkDirEntry)
-	go func() {
-		defer close(out)
-		var err error
-		for _, obj := range bucketObjects {
-			if ctx.Err() != nil {
-				return
-			}
-			entry := pipeline.NewSimpleWalkDirEntry(obj, &common.BucketContent{
-				Key: strings.TrimPrefix(obj, "/"),
-			}, err)
-			out <- entry
+// Returns the info of the local link to compare with the bucket, ok is false if the link is
+// ignored. Links stored as links are empty objects and links to directories are never followed
+func (s *syncState) localSymlinkInfo(path string, info fs.FileInfo, isSource bool) (fs.FileInfo, bool, error) {
+	symlinks := s.params.Symlinks
+	if !isSource {
+		if !s.params.Restore() || !s.params.RestoreSymlinks {
+			return info, true, nil
 		}
-	}()
-	return out
+		// Links are only downloaded back as links if they were stored as such
+		symlinks = common.SymlinksStore
+	}
+
+	switch symlinks {
+	case common.SymlinksSkip:
+		return nil, false, nil
+	case common.SymlinksStore:
+		return emptyFileInfo{info}, true, nil
+	default:
+		target, err := os.Stat(path)
+		if err != nil {
+			return nil, false, err
+		}
+		return target, !target.IsDir(), nil
+	}
 }
 
-func fillBucketFiles(ctx context.Context, params syncParams, cfg common.Config) {
-	logger().Debug("Getting bucket files")
+// Info of a link stored as an empty object
+type emptyFileInfo struct {
+	fs.FileInfo
+}
 
-	dirBucketFiles := common.ListGenerator(ctx, common.ListObjectsParams{
-		Destination: params.Bucket,
-		Recursive:   true,
-		PaginationParams: common.PaginationParams{
-			MaxItems: common.MaxBatchSize,
-		},
-	}, cfg, nil)
+func (emptyFileInfo) Size() int64 {
+	return 0
+}
 
-	for file := range dirBucketFiles {
-		allBucketFiles["/"+file.Path()] = true
+func (s *syncState) transferAction() string {
+	switch {
+	case !s.src.remote:
+		return syncActionUpload
+	case !s.dst.remote:
+		return syncActionDownload
+	default:
+		return syncActionCopy
 	}
 }
 
-func getFileStats(ctx context.Context, destination mgcSchemaPkg.URI, cfg common.Config) (fileSyncStats, error) {
-	dstHead, err := headObject(ctx, headObjectParams{
-		Destination: destination,
-	}, cfg)
-	if err != nil {
-		return fileSyncStats{}, err
+func (s *syncState) planTransfers(ctx context.Context, srcEntries, dstEntries map[string]syncEntry) (transfers []syncAction, skipped int, err error) {
+	paths := make([]string, 0, len(srcEntries))
+	for rel := range srcEntries {
+		paths = append(paths, rel)
 	}
-	dstModTime, err := time.Parse(time.RFC1123, dstHead.LastModified)
-	if err != nil {
-		logger().Debug("%s %s\n", dstModTime, err)
-		return fileSyncStats{}, err
+	sort.Strings(paths)
+
+	action := s.transferAction()
+	for _, rel := range paths {
+		dstEntry, exists := dstEntries[rel]
+		if exists {
+			changed, err := s.changed(ctx, rel, srcEntries[rel], dstEntry)
+			if err != nil {
+				return nil, 0, err
+			}
+			if !changed {
+				syncLogger().Debugw("Skipping file - no change", "path", rel)
+				skipped++
+				continue
+			}
+		}
+		transfers = append(transfers, syncAction{Action: action, Path: rel})
 	}
-	return fileSyncStats{
-		SourceLength:  dstHead.ContentLength,
-		SourceModTime: dstModTime.Unix(),
-		Etag:          cleanEtag(dstHead.ETag),
-	}, nil
+	return transfers, skipped, nil
 }
 
-func cleanEtag(etag string) string {
-	return strings.Trim(etag, "\"")
-}
+func (s *syncState) changed(ctx context.Context, rel string, src, dst syncEntry) (bool, error) {
+	if src.size != dst.size {
+		return true, nil
+	}
 
-func uploadFile(ctx context.Context, local mgcSchemaPkg.URI, bucket mgcSchemaPkg.URI, cfg common.Config) error {
-	_, err := upload(
-		ctx,
-		uploadParams{Source: mgcSchemaPkg.FilePath(local), Destination: bucket},
-		cfg,
-	)
-	return err
+	switch s.params.Compare {
+	case syncCompareSizeOnly:
+		return false, nil
+	case syncCompareChecksum:
+		srcSum, err := s.md5(ctx, s.src, rel)
+		if err != nil {
+			return false, err
+		}
+		dstSum, err := s.md5(ctx, s.dst, rel)
+		if err != nil {
+			return false, err
+		}
+		return srcSum == "" || dstSum == "" || srcSum != dstSum, nil
+	default:
+		// Bucket modification times have a resolution of seconds
+		dstModTime := dst.modTime.Truncate(time.Second)
+		if !src.modTime.Truncate(time.Second).After(dstModTime) {
+			return false, nil
+		}
+		if s.src.remote && !s.dst.remote && s.params.Restore() {
+			// Downloads restore the modification time stored with the object, which is older than the object itself
+			stored, ok, err := s.storedModTime(ctx, rel)
+			if err != nil || ok {
+				return stored.Truncate(time.Second).After(dstModTime), err
+			}
+		}
+		return true, nil
+	}
 }
 
-func (c *UploadCounter) Increment() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.v++
+// Returns the modification time stored as metadata of the source object, ok is false if there is none
+func (s *syncState) storedModTime(ctx context.Context, rel string) (modTime time.Time, ok bool, err error) {
+	head, err := headObject(ctx, headObjectParams{Destination: s.src.remoteURI(rel), EncryptionParams: s.params.EncryptionParams}, s.cfg)
+	if err != nil {
+		return modTime, false, err
+	}
+	attrs, _ := common.FileAttributesFromMetadata(head.Metadata)
+	return attrs.ModTime, !attrs.ModTime.IsZero(), nil
 }
 
-func (c *UploadCounter) Value() uint64 {
-	return c.v
+// Returns the hex encoded MD5 of the item, or empty if unknown, as the ETag of multipart
+// and SSE-C encrypted objects is not their MD5
+func (s *syncState) md5(ctx context.Context, e syncEndpoint, rel string) (string, error) {
+	if e.remote {
+		head, err := headObject(ctx, headObjectParams{Destination: e.remoteURI(rel), EncryptionParams: s.params.EncryptionParams}, s.cfg)
+		if err != nil {
+			return "", err
+		}
+		etag := cleanEtag(head.ETag)
+		if strings.Contains(etag, "-") || len(s.key) > 0 {
+			return "", nil
+		}
+		return etag, nil
+	}
+
+	file, err := os.Open(e.localPath(rel).String())
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := md5.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func processSyncFiles(ctx context.Context, cfg common.Config, source, destination mgcSchemaPkg.URI, basePath string, files []string, progressBar *pterm.ProgressbarPrinter) error {
-	results := make(chan error, cfg.Workers)
-	filesChan := make(chan string, cfg.Workers)
+func cleanEtag(etag string) string {
+	return strings.Trim(etag, "\"")
+}
 
-	var wg sy.WaitGroup
-	wg.Add(cfg.Workers)
-	for i := 0; i < cfg.Workers; i++ {
-		go func() {
-			defer wg.Done()
-			syncWorker(ctx, cfg, source, destination, basePath, filesChan, results, progressBar)
-		}()
+func (s *syncState) transfer(ctx context.Context, transfers []syncAction) error {
+	if len(transfers) == 0 {
+		return nil
 	}
 
+	progressReporter := progress_report.NewUnitsReporter(ctx, fmt.Sprintf("Syncing files from %q to %q", s.src, s.dst), uint64(len(transfers)))
+	progressReporter.Start()
+	defer progressReporter.End()
+
+	actions := make(chan syncAction)
 	go func() {
-		defer close(filesChan)
-		for _, file := range files {
+		defer close(actions)
+		for _, action := range transfers {
 			select {
-			case filesChan <- file:
 			case <-ctx.Done():
 				return
+			case actions <- action:
 			}
 		}
 	}()
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	errChan := pipeline.ParallelProcess(ctx, s.cfg.Workers, actions, s.createTransferProcessor(progressReporter), nil)
+	errChan = pipeline.Filter(ctx, errChan, pipeline.FilterNonNil[error]{})
 
-	for err := range results {
-		if err != nil {
-			return err
-		}
+	objErr, err := pipeline.SliceItemConsumer[utils.MultiError](ctx, errChan)
+	if err != nil {
+		return err
+	}
+	if len(objErr) > 0 {
+		return objErr
 	}
-
 	return nil
 }
 
-func syncWorker(ctx context.Context, cfg common.Config, source, destination mgcSchemaPkg.URI, basePath string, files <-chan string, results chan<- error, progressBar *pterm.ProgressbarPrinter) {
-	for {
-		select {
-		case file, ok := <-files:
-			if !ok {
-				return
-			}
-			err := processSyncFile(ctx, cfg, source, destination, basePath, file, progressBar)
-			if err != nil {
-				select {
-				case results <- err:
-				case <-ctx.Done():
-					return
-				}
-			}
-		case <-ctx.Done():
-			return
+func (s *syncState) createTransferProcessor(progressReporter *progress_report.UnitsReporter) pipeline.Processor[syncAction, error] {
+	return func(ctx context.Context, action syncAction) (error, pipeline.ProcessStatus) {
+		var err error
+		defer func() { progressReporter.Report(1, 0, err) }()
+
+		var url mgcSchemaPkg.URI
+		switch action.Action {
+		case syncActionUpload:
+			url = mgcSchemaPkg.URI(s.src.localPath(action.Path))
+			err = uploadFile(ctx, url, s.dst.remoteURI(action.Path), s.template, s.cfg)
+		case syncActionDownload:
+			url = s.src.remoteURI(action.Path)
+			err = s.download(ctx, url, s.dst.localPath(action.Path))
+		case syncActionCopy:
+			url = s.src.remoteURI(action.Path)
+			err = s.copy(ctx, url, s.dst.remoteURI(action.Path))
 		}
-	}
-}
 
-func processSyncFile(ctx context.Context, cfg common.Config, source, destination mgcSchemaPkg.URI, basePath, file string, progressBar *pterm.ProgressbarPrinter) error {
-	normalizedSource, err := common.GetAbsSystemURI(mgcSchemaPkg.URI(file))
-	if err != nil {
-		logger().Debugw("error with path", "error", err)
-		return nil
+		if err != nil {
+			return &common.ObjectError{Url: url, Err: err}, pipeline.ProcessOutput
+		}
+		return nil, pipeline.ProcessOutput
 	}
+}
 
-	pathWithFolder := strings.TrimPrefix(file, basePath)
-	normalizedDestination := destination.JoinPath(pathWithFolder)
+// template holds the upload options shared by all the files, Source and Destination are filled here
+func uploadFile(ctx context.Context, local mgcSchemaPkg.URI, bucket mgcSchemaPkg.URI, template uploadParams, cfg common.Config) error {
+	params := template
+	params.Source = mgcSchemaPkg.FilePath(local)
+	params.Destination = bucket
+	_, err := upload(ctx, params, cfg)
+	return err
+}
 
-	info, err := os.Stat(file)
+func (s *syncState) download(ctx context.Context, src mgcSchemaPkg.URI, dst mgcSchemaPkg.FilePath) error {
+	opts := s.params.DownloadOptions(s.dst.localPath(""))
+	opts.Encryption = s.key
+	downloader, err := common.NewDownloader(ctx, s.cfg, src, dst, "", opts)
 	if err != nil {
 		return err
 	}
+	return downloader.Download(ctx)
+}
 
-	if allBucketFiles[pathWithFolder] {
-		delete(allBucketFiles, pathWithFolder)
-	}
-
-	fileStats, err := getFileStats(ctx, normalizedDestination, cfg)
+func (s *syncState) copy(ctx context.Context, src, dst mgcSchemaPkg.URI) error {
+	copier, err := common.NewCopier(ctx, s.cfg, src, dst, "", common.CopyOptions{Headers: s.params.ObjectHeaders, Encryption: s.key, SourceEncryption: s.key})
 	if err != nil {
-		logger().Debugw("error getting file stats", "error", err)
+		return err
 	}
+	return copier.Copy(ctx)
+}
 
-	isSameSize := info.Size() == fileStats.SourceLength
-	isLocalOlderThenBucket := info.ModTime().Unix() < fileStats.SourceModTime
-	if err == nil && isSameSize && isLocalOlderThenBucket {
-		logger().Debug("Skipping file [%s] - no change", normalizedSource)
-		progressBar.Increment()
+func (s *syncState) delete(ctx context.Context, paths []string) error {
+	if !s.dst.remote {
+		for _, rel := range paths {
+			if err := os.Remove(s.dst.localPath(rel).String()); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 
-	err = uploadFile(ctx, normalizedSource, normalizedDestination, cfg)
-	if err != nil {
-		return &common.ObjectError{Url: mgcSchemaPkg.URI(normalizedSource.Path()), Err: err}
+	prefix := s.dst.uri.Path()
+	keys := make([]string, 0, len(paths))
+	for _, rel := range paths {
+		keys = append(keys, strings.TrimPrefix(prefix+"/"+rel, "/"))
 	}
 
-	uploadFiles.Increment()
-	progressBar.Increment()
-	return nil
+	return common.DeleteObjects(ctx, common.DeleteObjectsParams{
+		Destination: s.dst.uri,
+		ToDelete:    bucketObjectsToWalkDirEntry(ctx, keys),
+		BatchSize:   s.params.BatchSize,
+	}, s.cfg)
+}
+
+func bucketObjectsToWalkDirEntry(ctx context.Context, bucketObjects []string) <-chan pipeline.WalkDirEntry {
+	out := make(chan pipeline.WalkDirEntry)
+	go func() {
+		defer close(out)
+		var err error
+		for _, obj := range bucketObjects {
+			if ctx.Err() != nil {
+				return
+			}
+			entry := pipeline.NewSimpleWalkDirEntry(obj, &common.BucketContent{
+				Key: strings.TrimPrefix(obj, "/"),
+			}, err)
+			out <- entry
+		}
+	}()
+	return out
 }
@@ -0,0 +1,180 @@
+package objects
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+func TestSyncLocalToBucket(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	server.PutObject("bucket", "backup/stale.txt", []byte("stale"))
+
+	params := syncParams{Source: mgcSchemaPkg.URI(dir), Destination: "s3://bucket/backup", DryRun: true, Delete: true}
+	value, err := sync(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	planned := value.(syncResult).Planned
+	if len(planned) != 3 || planned[2] != (syncAction{Action: syncActionDelete, Path: "stale.txt"}) {
+		t.Errorf("unexpected plan: %+v", planned)
+	}
+	if _, ok := server.Object("bucket", "backup/a.txt"); ok {
+		t.Fatal("dry run must not transfer anything")
+	}
+
+	params.DryRun = false
+	value, err = sync(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	result := value.(syncResult)
+	if result.FilesUploaded != 2 || result.FilesDeleted != 1 || !result.Deleted {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	for key, content := range map[string]string{"backup/a.txt": "a", "backup/sub/b.txt": "b"} {
+		if obj, ok := server.Object("bucket", key); !ok || string(obj.Data) != content {
+			t.Errorf("%q was not uploaded", key)
+		}
+	}
+	if _, ok := server.Object("bucket", "backup/stale.txt"); ok {
+		t.Error("extraneous object was not deleted")
+	}
+
+	// Nothing changed, so nothing is transferred
+	value, err = sync(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	if result = value.(syncResult); result.FilesUploaded != 0 || result.FilesSkipped != 2 {
+		t.Errorf("expected everything to be skipped: %+v", result)
+	}
+
+	// Same size, but newer
+	future := time.Now().Add(time.Hour)
+	writeFiles(t, dir, map[string]string{"a.txt": "A"})
+	if err = os.Chtimes(filepath.Join(dir, "a.txt"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	value, err = sync(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	if result = value.(syncResult); result.FilesUploaded != 1 {
+		t.Errorf("expected the modified file to be uploaded: %+v", result)
+	}
+	if obj, _ := server.Object("bucket", "backup/a.txt"); string(obj.Data) != "A" {
+		t.Errorf("modified file was not uploaded, got %q", obj.Data)
+	}
+}
+
+func TestSyncBucketToLocalWithChecksum(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"same.txt": "same", "changed.txt": "old", "extra.txt": "extra"})
+	server.PutObject("bucket", "data/same.txt", []byte("same"))
+	server.PutObject("bucket", "data/changed.txt", []byte("new"))
+	server.PutObject("bucket", "data/dir/new.txt", []byte("new"))
+
+	value, err := sync(ctx, syncParams{Source: "s3://bucket/data", Destination: mgcSchemaPkg.URI(dir), Compare: syncCompareChecksum, Delete: true}, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	result := value.(syncResult)
+	if result.FilesDownloaded != 2 || result.FilesSkipped != 1 || result.FilesDeleted != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	for name, content := range map[string]string{"same.txt": "same", "changed.txt": "new", "dir/new.txt": "new"} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || string(data) != content {
+			t.Errorf("expected %q in %q, got %q: %v", content, name, data, err)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(dir, "extra.txt")); !os.IsNotExist(err) {
+		t.Error("extraneous file was not deleted")
+	}
+}
+
+func TestSyncBucketToBucket(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.CreateBucket("mirror")
+	server.PutObject("bucket", "x/1.txt", []byte("1"))
+	server.PutObject("bucket", "x/2.txt", []byte("2"))
+
+	value, err := sync(ctx, syncParams{Source: "s3://bucket/x", Destination: "s3://mirror"}, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	if result := value.(syncResult); result.FilesCopied != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	for _, key := range []string{"1.txt", "2.txt"} {
+		if _, ok := server.Object("mirror", key); !ok {
+			t.Errorf("%q was not copied", key)
+		}
+	}
+}
+
+func TestSyncLegacyPaths(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a"})
+
+	value, err := sync(ctx, syncParams{Local: mgcSchemaPkg.URI(dir), Bucket: "bucket/legacy"}, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	if result := value.(syncResult); result.FilesUploaded != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if _, ok := server.Object("bucket", "legacy/a.txt"); !ok {
+		t.Error("file was not uploaded")
+	}
+
+	if _, err = sync(ctx, syncParams{Source: "s3://bucket/other", Local: mgcSchemaPkg.URI(dir), Bucket: "bucket/legacy"}, cfg); err == nil {
+		t.Error("expected conflicting paths to fail")
+	}
+}
+
+func TestSyncWithCustomerKey(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a"})
+	encryption := common.EncryptionParams{SSECustomerKey: "0123456789abcdef0123456789abcdef"}
+
+	params := syncParams{Source: mgcSchemaPkg.URI(dir), Destination: "s3://bucket/enc", EncryptionParams: encryption}
+	if _, err := sync(ctx, params, cfg); err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	// The MD5 of "a"
+	if obj, ok := server.Object("bucket", "enc/a.txt"); !ok || obj.ETag == "0cc175b9c0f1b6a831c399e269772661" {
+		t.Fatalf("object was not uploaded encrypted: %+v", obj)
+	}
+
+	// The ETag of encrypted objects is not their MD5, so they are always transferred
+	params.Compare = syncCompareChecksum
+	value, err := sync(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	if result := value.(syncResult); result.FilesUploaded != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	out := t.TempDir()
+	writeFiles(t, out, map[string]string{"a.txt": "b"})
+	_, err = sync(ctx, syncParams{Source: "s3://bucket/enc", Destination: mgcSchemaPkg.URI(out), Compare: syncCompareChecksum, EncryptionParams: encryption}, cfg)
+	if err != nil {
+		t.Fatalf("sync() failed: %s", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(out, "a.txt")); string(data) != "a" {
+		t.Errorf("encrypted object was not downloaded, got %q", data)
+	}
+}
@@ -0,0 +1,35 @@
+package tags
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type deleteObjectTagsParams struct {
+	Destination mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the object to delete the tags from,example=my-bucket/file.txt" mgc:"positional"`
+	Version     string           `json:"obj_version,omitempty" jsonschema:"description=Version of the object"`
+}
+
+var getDelete = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "delete",
+			Description: "Delete all the tags of the specified object",
+		},
+		deleteTags,
+	)
+
+	return core.NewExecuteFormat(exec, func(exec core.Executor, result core.Result) string {
+		return fmt.Sprintf("Successfully deleted tags for object %q", result.Source().Parameters["dst"])
+	})
+})
+
+func deleteTags(ctx context.Context, params deleteObjectTagsParams, cfg common.Config) (result core.Value, err error) {
+	err = common.DeleteObjectTags(ctx, cfg, params.Destination, params.Version)
+	return
+}
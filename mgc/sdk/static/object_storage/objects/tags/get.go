@@ -0,0 +1,32 @@
+package tags
+
+import (
+	"context"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type getObjectTagsParams struct {
+	Destination mgcSchemaPkg.URI `json:"dst" jsonschema:"description=Path of the object to get the tags from,example=my-bucket/file.txt" mgc:"positional"`
+	Version     string           `json:"obj_version,omitempty" jsonschema:"description=Version of the object"`
+}
+
+var getGet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "get",
+			Description: "Get the tags of the specified object",
+		},
+		getTags,
+	)
+	return core.NewExecuteResultOutputOptions(exec, func(exec core.Executor, result core.Result) string {
+		return "table"
+	})
+})
+
+func getTags(ctx context.Context, params getObjectTagsParams, cfg common.Config) ([]common.ObjectTag, error) {
+	return common.GetObjectTags(ctx, cfg, params.Destination, params.Version)
+}
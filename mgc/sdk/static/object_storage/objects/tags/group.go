@@ -0,0 +1,22 @@
+package tags
+
+import (
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+)
+
+var GetGroup = utils.NewLazyLoader(func() core.Grouper {
+	return core.NewStaticGroup(
+		core.DescriptorSpec{
+			Name:        "tags",
+			Description: "Object tagging commands",
+		},
+		func() []core.Descriptor {
+			return []core.Descriptor{
+				getGet(),    // object-storage objects tags get
+				getSet(),    // object-storage objects tags set
+				getDelete(), // object-storage objects tags delete
+			}
+		},
+	)
+})
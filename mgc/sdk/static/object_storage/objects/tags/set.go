@@ -0,0 +1,36 @@
+package tags
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/core/utils"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+type setObjectTagsParams struct {
+	Destination mgcSchemaPkg.URI  `json:"dst" jsonschema:"description=Path of the object to set the tags for,example=my-bucket/file.txt" mgc:"positional"`
+	Tags        common.ObjectTags `json:"tag" jsonschema:"description=Tags to set as key=value pairs. Replaces all the existing tags,minProperties=1"`
+	Version     string            `json:"obj_version,omitempty" jsonschema:"description=Version of the object"`
+}
+
+var getSet = utils.NewLazyLoader(func() core.Executor {
+	var exec core.Executor = core.NewStaticExecute(
+		core.DescriptorSpec{
+			Name:        "set",
+			Description: "Set the tags of the specified object, replacing the existing ones",
+		},
+		setTags,
+	)
+
+	return core.NewExecuteFormat(exec, func(exec core.Executor, result core.Result) string {
+		return fmt.Sprintf("Successfully set tags for object %q", result.Source().Parameters["dst"])
+	})
+})
+
+func setTags(ctx context.Context, params setObjectTagsParams, cfg common.Config) (result core.Value, err error) {
+	err = common.SetObjectTags(ctx, cfg, params.Destination, params.Version, params.Tags)
+	return
+}
@@ -3,6 +3,7 @@ package objects
 import (
 	"context"
 	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/MagaluCloud/magalu/mgc/core"
@@ -12,14 +13,25 @@ import (
 )
 
 type uploadParams struct {
-	Source       mgcSchemaPkg.FilePath `json:"src" jsonschema:"description=Source file path to be uploaded,example=./file.txt" mgc:"positional"`
-	Destination  mgcSchemaPkg.URI      `json:"dst" jsonschema:"description=Full destination path in the bucket with desired filename,example=my-bucket/dir/file.txt" mgc:"positional"`
-	StorageClass string                `json:"storage_class,omitempty" jsonschema:"description=Type of Storage in which to store object,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
+	Source          mgcSchemaPkg.FilePath `json:"src" jsonschema:"description=Source file path to be uploaded. Use - to read from the standard input,example=./file.txt" mgc:"positional"`
+	Destination     mgcSchemaPkg.URI      `json:"dst" jsonschema:"description=Full destination path in the bucket with desired filename,example=my-bucket/dir/file.txt" mgc:"positional"`
+	StorageClass    string                `json:"storage_class,omitempty" jsonschema:"description=Type of Storage in which to store object,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
+	Tags            common.ObjectTags     `json:"tag,omitempty" jsonschema:"description=Tags to set on the object as key=value pairs"`
+	Resume          *bool                 `json:"resume,omitempty" jsonschema:"description=Resume a previous interrupted multipart upload of the same file to the same destination,default=true"`
+	KeepOnInterrupt bool                  `json:"keep_on_interrupt,omitempty" jsonschema_description:"Keep the multipart upload when interrupted by Ctrl+C, so the next execution resumes it. By default it's aborted" jsonschema:"default=false"`
+	// nil means true, so callers building uploadParams directly keep the detection
+	DetectContentType           *bool            `json:"detect_content_type,omitempty" jsonschema:"description=Detect the Content-Type from the file extension and content when content_type is omitted,default=true"`
+	common.ObjectHeaders        `json:",squash"` // nolint
+	common.EncryptionParams     `json:",squash"` // nolint
+	common.ChecksumParams       `json:",squash"` // nolint
+	common.FileAttributesParams `json:",squash"` // nolint
+	common.WriteConditions      `json:",squash"` // nolint
 }
 
 type uploadTemplateResult struct {
-	File string `json:"file"`
-	URI  string `json:"uri"`
+	File    string `json:"file"`
+	URI     string `json:"uri"`
+	Skipped bool   `json:"skipped,omitempty"`
 }
 
 var getUpload = utils.NewLazyLoader[core.Executor](func() core.Executor {
@@ -32,7 +44,7 @@ var getUpload = utils.NewLazyLoader[core.Executor](func() core.Executor {
 	)
 
 	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
-		return "template=Uploaded file {{.file}} to {{.uri}}\n"
+		return "template={{if .skipped}}Skipped symbolic link {{.file}}{{else}}Uploaded file {{.file}} to {{.uri}}{{end}}\n"
 	})
 })
 
@@ -46,10 +58,55 @@ func upload(ctx context.Context, params uploadParams, cfg common.Config) (*uploa
 	fileName := common.ExtractFileName(srcPath)
 
 	if params.Destination.IsRoot() || strings.HasSuffix(fullDstPath.String(), "/") {
+		if params.Source == common.StdinPath {
+			return nil, core.UsageError{Err: fmt.Errorf("destination must include the object name when uploading from the standard input")}
+		}
 		fullDstPath = fullDstPath.JoinPath(fileName)
 	}
+	if params.Source == common.StdinPath {
+		fileName = "stdin"
+	}
+
+	key, err := params.CustomerKey()
+	if err != nil {
+		return nil, err
+	}
+	if err = common.ValidateSymlinks(params.Symlinks); err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+
+	opts := common.UploadOptions{
+		StorageClass:      params.StorageClass,
+		Tags:              params.Tags,
+		Headers:           params.ObjectHeaders,
+		DetectContentType: params.DetectContentType == nil || *params.DetectContentType,
+		Encryption:        key,
+		Checksum:          params.Checksum,
+		Resume:            params.Resume == nil || *params.Resume,
+		KeepOnInterrupt:   params.KeepOnInterrupt,
+		Conditions:        params.WriteConditions,
+	}
+
+	var attrs common.FileAttributes
+	if params.Source != common.StdinPath {
+		var skip bool
+		if attrs, skip, err = common.ReadFileAttributes(params.Source.String(), params.Symlinks); err != nil {
+			return nil, fmt.Errorf("error reading object: %w", err)
+		}
+		if skip {
+			return &uploadTemplateResult{File: fileName, Skipped: true}, nil
+		}
+		if !params.Preserve() {
+			// The link target is still needed to download it back as a link
+			attrs = common.FileAttributes{SymlinkTarget: attrs.SymlinkTarget, Uid: -1, Gid: -1}
+		}
+		// The metadata may be shared with other uploads, so it's never changed in place
+		opts.Headers.Metadata = attrs.AddToMetadata(maps.Clone(opts.Headers.Metadata))
+	}
+
+	opts.Symlink = attrs.IsSymlink()
 
-	uploader, err := common.NewUploader(cfg, params.Source, fullDstPath, params.StorageClass)
+	uploader, err := common.NewUploader(cfg, params.Source, fullDstPath, opts)
 	if err != nil {
 		return nil, err
 	}
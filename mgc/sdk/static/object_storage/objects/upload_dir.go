@@ -3,11 +3,16 @@ package objects
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	syncer "sync"
 
 	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/pipeline"
 	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
 	"github.com/MagaluCloud/magalu/mgc/core/utils"
 	"github.com/MagaluCloud/magalu/mgc/sdk/openapi"
@@ -16,33 +21,48 @@ import (
 )
 
 type uploadDirParams struct {
-	Source         mgcSchemaPkg.DirPath `json:"src" jsonschema:"description=Source directory path for upload,example=path/to/folder" mgc:"positional"`
-	Destination    mgcSchemaPkg.URI     `json:"dst" jsonschema:"description=Full destination path in the bucket,example=my-bucket/dir/" mgc:"positional"`
-	Shallow        bool                 `json:"shallow,omitempty" jsonschema:"description=Don't upload subdirectories,default=false"`
-	StorageClass   string               `json:"storage_class,omitempty" jsonschema:"description=Type of Storage in which to store object,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
-	common.Filters `json:",squash"`     // nolint
+	Source                      mgcSchemaPkg.DirPath `json:"src" jsonschema:"description=Source directory path for upload,example=path/to/folder" mgc:"positional"`
+	Destination                 mgcSchemaPkg.URI     `json:"dst" jsonschema:"description=Full destination path in the bucket,example=my-bucket/dir/" mgc:"positional"`
+	Shallow                     bool                 `json:"shallow,omitempty" jsonschema:"description=Don't upload subdirectories,default=false"`
+	StorageClass                string               `json:"storage_class,omitempty" jsonschema:"description=Type of Storage in which to store object,example=cold,enum=,enum=standard,enum=cold,enum=glacier_ir,enum=cold_instant,default="`
+	Tags                        common.ObjectTags    `json:"tag,omitempty" jsonschema:"description=Tags to set on every uploaded object as key=value pairs"`
+	DetectContentType           *bool                `json:"detect_content_type,omitempty" jsonschema:"description=Detect the Content-Type of each file from its extension and content when content_type is omitted,default=true"`
+	common.FileAttributesParams `json:",squash"`     // nolint
+	Archive                     string               `json:"archive,omitempty" jsonschema:"description=Upload the directory as a single archive object of this format instead of an object per file,enum=,enum=tar,enum=tar.gz,enum=zip,default="`
+	common.Filters              `json:",squash"`     // nolint
+	common.ObjectHeaders        `json:",squash"`     // nolint
+	common.EncryptionParams     `json:",squash"`     // nolint
+	common.ChecksumParams       `json:",squash"`     // nolint
+	common.BulkParams           `json:",squash"`     // nolint
 }
 
 type uploadDirResult struct {
-	Dir string `json:"dir"`
-	URI string `json:"uri"`
+	Dir                string           `json:"dir"`
+	URI                string           `json:"uri"`
+	Files              int              `json:"files,omitempty"`
+	common.BulkSummary `json:",squash"` // nolint
 }
 
 var getUploadDir = utils.NewLazyLoader[core.Executor](func() core.Executor {
 	executor := core.NewStaticExecute(
 		core.DescriptorSpec{
-			Name:        "upload-dir",
-			Description: "Upload a directory to a bucket",
+			Name:    "upload-dir",
+			Summary: "Upload a directory to a bucket",
+			Description: `Uploads every file of the directory as an object under the destination path.
+
+With --archive, the directory is streamed as a single tar, tar.gz or zip object instead, without
+temporary files. If the destination ends with '/', the object is named after the directory.`,
 		},
 		uploadDir,
 	)
 
 	return core.NewExecuteResultOutputOptions(executor, func(exec core.Executor, result core.Result) string {
-		return "template=Uploaded directory {{.dir}} to {{.uri}}\n"
+		return "template=Uploaded directory {{.dir}} to {{.uri}}{{if .skipped}}, {{.skipped}} files skipped{{end}}\n"
 	})
 })
 
 func uploadDir(ctx context.Context, params uploadDirParams, cfg common.Config) (*uploadDirResult, error) {
+	cfg = cfg.WithBandwidthLimiter()
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(nil)
 
@@ -55,7 +75,23 @@ func uploadDir(ctx context.Context, params uploadDirParams, cfg common.Config) (
 		return nil, err
 	}
 
-	files, err := walkDir(ctx, basePath.String(), params.Shallow)
+	if params.Archive != "" {
+		if params.ContinueOnError || params.FromManifest != "" {
+			return nil, core.UsageError{Err: fmt.Errorf("continue_on_error and from_manifest cannot be used with archive")}
+		}
+		return uploadDirArchive(ctx, params, cfg, basePath.String())
+	}
+
+	if err := common.ValidateSymlinks(params.Symlinks); err != nil {
+		return nil, core.UsageError{Err: err}
+	}
+
+	run, err := common.NewBulkRun("upload-dir", params.BulkParams, cancel)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := listUploadDirFiles(ctx, params, basePath.String())
 	if err != nil {
 		return nil, err
 	}
@@ -70,49 +106,83 @@ func uploadDir(ctx context.Context, params uploadDirParams, cfg common.Config) (
 		progressBar, _ = progressBar.Start()
 	}
 
-	err = processCurrentAndSubfolders(ctx, cfg, params.Destination, params.StorageClass, basePath.String(), files, progressBar)
+	template := uploadParams{
+		StorageClass:         params.StorageClass,
+		Tags:                 params.Tags,
+		DetectContentType:    params.DetectContentType,
+		ObjectHeaders:        params.ObjectHeaders,
+		EncryptionParams:     params.EncryptionParams,
+		ChecksumParams:       params.ChecksumParams,
+		FileAttributesParams: params.FileAttributesParams,
+	}
+	err = processCurrentAndSubfolders(ctx, cfg, params.Destination, template, basePath.String(), files, run, progressBar)
+	summary, err := run.Finish(err)
+
+	_, _ = progressBar.Stop()
 
 	if err != nil {
 		return &uploadDirResult{}, err
 	}
 
-	_, _ = progressBar.Stop()
-
 	return &uploadDirResult{
-		URI: params.Destination.String(),
-		Dir: basePath.String(),
+		URI:         params.Destination.String(),
+		Dir:         basePath.String(),
+		BulkSummary: summary,
 	}, nil
 }
 
-func processFile(ctx context.Context, cfg common.Config, destination mgcSchemaPkg.URI, basePath string, storageClass string, file string, progressBar *pterm.ProgressbarPrinter) error {
+// The files of the manifest, if given, or all those under root
+func listUploadDirFiles(ctx context.Context, params uploadDirParams, root string) ([]string, error) {
+	if params.FromManifest == "" {
+		return walkDir(ctx, root, params.Shallow, params.Symlinks)
+	}
+
+	entries, err := common.ReadManifest(params.FromManifest, "upload-dir", root)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		files = append(files, entry.Source)
+	}
+	return files, nil
+}
+
+// template holds the upload options shared by all the files, Source and Destination are filled per file.
+// Returns the error only if the upload of the directory must stop
+func processFile(ctx context.Context, cfg common.Config, destination mgcSchemaPkg.URI, basePath string, template uploadParams, file string, run *common.BulkRun, progressBar *pterm.ProgressbarPrinter) error {
+	defer progressBar.Increment()
 
 	relPath := common.GetRelativePath(basePath, file)
 
 	dst := destination.JoinPath(relPath)
 
-	_, err := upload(
-		ctx,
-		uploadParams{Source: mgcSchemaPkg.FilePath(file), Destination: dst, StorageClass: storageClass},
-		cfg,
-	)
-
-	if err != nil {
-		err = &common.ObjectError{Url: mgcSchemaPkg.URI(dst), Err: err}
-		return err
-	}
+	params := template
+	params.Source = mgcSchemaPkg.FilePath(file)
+	params.Destination = dst
 
-	progressBar.Increment()
-	return nil
+	entry := common.ManifestEntry{Path: relPath, Source: file, Destination: dst.String()}
+	return run.Process(ctx, entry, func() error {
+		result, err := upload(ctx, params, cfg)
+		if err != nil {
+			return &common.ObjectError{Url: mgcSchemaPkg.URI(dst), Err: err}
+		}
+		if result.Skipped {
+			// A symbolic link that must not be uploaded
+			return common.ErrSkipItem
+		}
+		return nil
+	})
 }
 
-func worker(ctx context.Context, cfg common.Config, destination mgcSchemaPkg.URI, basePath string, storageClass string, files <-chan string, results chan<- error, progressBar *pterm.ProgressbarPrinter) {
+func worker(ctx context.Context, cfg common.Config, destination mgcSchemaPkg.URI, basePath string, template uploadParams, files <-chan string, results chan<- error, run *common.BulkRun, progressBar *pterm.ProgressbarPrinter) {
 	for {
 		select {
 		case file, ok := <-files:
 			if !ok {
 				return
 			}
-			err := processFile(ctx, cfg, destination, basePath, storageClass, file, progressBar)
+			err := processFile(ctx, cfg, destination, basePath, template, file, run, progressBar)
 			if err != nil {
 				select {
 				case results <- err:
@@ -126,7 +196,7 @@ func worker(ctx context.Context, cfg common.Config, destination mgcSchemaPkg.URI
 	}
 }
 
-func processCurrentAndSubfolders(ctx context.Context, cfg common.Config, destination mgcSchemaPkg.URI, storageClass string, path string, files []string, progressBar *pterm.ProgressbarPrinter) error {
+func processCurrentAndSubfolders(ctx context.Context, cfg common.Config, destination mgcSchemaPkg.URI, template uploadParams, path string, files []string, run *common.BulkRun, progressBar *pterm.ProgressbarPrinter) error {
 	results := make(chan error, cfg.Workers)
 	filesChan := make(chan string, cfg.Workers)
 
@@ -135,7 +205,7 @@ func processCurrentAndSubfolders(ctx context.Context, cfg common.Config, destina
 	for i := 0; i < cfg.Workers; i++ {
 		go func() {
 			defer wg.Done()
-			worker(ctx, cfg, destination, path, storageClass, filesChan, results, progressBar)
+			worker(ctx, cfg, destination, path, template, filesChan, results, run, progressBar)
 		}()
 	}
 
@@ -164,7 +234,134 @@ func processCurrentAndSubfolders(ctx context.Context, cfg common.Config, destina
 	return nil
 }
 
-func walkDir(ctx context.Context, root string, shallow bool) ([]string, error) {
+// Walks the directory, writing the files kept by the filters to archive. Returns the number of files written
+func writeDirArchive(ctx context.Context, params uploadDirParams, root string, archive archiveWriter) (files int, err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	checkPath := func(p string, d fs.DirEntry, err error) error {
+		if params.Shallow && d.IsDir() && p != path.Clean(root) {
+			return fs.SkipDir
+		}
+		return nil
+	}
+	entries := pipeline.WalkDirEntries(ctx, root, checkPath)
+	entries = common.ApplyFilters(ctx, entries, params.FilterParams, cancel)
+
+	for entry := range entries {
+		if err = entry.Err(); err != nil {
+			return files, err
+		}
+		if entry.DirEntry().IsDir() {
+			continue
+		}
+		written, err := writeFileToArchive(archive, common.GetRelativePath(root, entry.Path()), entry.Path(), params.FileAttributesParams)
+		if err != nil {
+			return files, err
+		}
+		if written {
+			files++
+		}
+	}
+	return files, context.Cause(ctx)
+}
+
+// Symbolic links are written as links to the archive if they must be stored, written is false
+// if the file was skipped
+func writeFileToArchive(archive archiveWriter, name string, file string, p common.FileAttributesParams) (written bool, err error) {
+	attrs, skip, err := common.ReadFileAttributes(file, p.Symlinks)
+	if err != nil || skip {
+		return false, err
+	}
+	if attrs.IsSymlink() {
+		return true, archive.WriteSymlink(name, attrs.SymlinkTarget, attrs.ModTime)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		// Links to directories are never followed
+		return false, err
+	}
+	mode := fs.FileMode(utils.FILE_PERMISSION)
+	if p.Preserve() {
+		mode = info.Mode()
+	}
+	return true, archive.WriteFile(name, info.Size(), mode, info.ModTime(), f)
+}
+
+func uploadDirArchive(ctx context.Context, params uploadDirParams, cfg common.Config, root string) (*uploadDirResult, error) {
+	if err := validateArchiveFormat(params.Archive); err != nil {
+		return nil, err
+	}
+
+	dst := params.Destination
+	if dst.IsRoot() || strings.HasSuffix(dst.String(), "/") {
+		dst = dst.JoinPath(filepath.Base(root) + "." + params.Archive)
+	}
+
+	key, err := params.CustomerKey()
+	if err != nil {
+		return nil, err
+	}
+	headers := params.ObjectHeaders
+	if headers.ContentType == "" {
+		headers.ContentType = archiveContentTypes[params.Archive]
+	}
+
+	reader, writer := io.Pipe()
+	uploader, err := common.NewStreamUploader(cfg, reader, dst, common.UploadOptions{
+		StorageClass: params.StorageClass,
+		Tags:         params.Tags,
+		Headers:      headers,
+		Encryption:   key,
+		Checksum:     params.Checksum,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var files int
+	written := make(chan error, 1)
+	go func() {
+		archive, err := newArchiveWriter(writer, params.Archive)
+		if err == nil {
+			files, err = writeDirArchive(ctx, params, root, archive)
+			if closeErr := archive.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		// An error makes the upload fail and be aborted, instead of finishing with a partial archive
+		_ = writer.CloseWithError(err)
+		written <- err
+	}()
+
+	err = uploader.Upload(ctx)
+	// Unblocks the archive writer if the upload stopped reading early
+	_ = reader.CloseWithError(err)
+	writeErr := <-written
+	if err != nil {
+		// Includes the archive error, if that is what made the upload fail
+		return nil, err
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	return &uploadDirResult{
+		Dir:   root,
+		URI:   dst.String(),
+		Files: files,
+	}, nil
+}
+
+// Links to directories are never followed, other links are listed unless they must be skipped
+func walkDir(ctx context.Context, root string, shallow bool, symlinks string) ([]string, error) {
 	var files []string
 
 	var walkFn func(string) error
@@ -182,6 +379,14 @@ func walkDir(ctx context.Context, root string, shallow bool) ([]string, error) {
 			}
 
 			path := filepath.Join(dir, entry.Name())
+			if entry.Type()&fs.ModeSymlink != 0 {
+				if symlinks == common.SymlinksSkip {
+					continue
+				}
+				if info, err := os.Stat(path); symlinks != common.SymlinksStore && err == nil && info.IsDir() {
+					continue
+				}
+			}
 			if entry.IsDir() {
 				if !shallow {
 					if err := walkFn(path); err != nil {
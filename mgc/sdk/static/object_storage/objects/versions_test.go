@@ -0,0 +1,131 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	mgcSchemaPkg "github.com/MagaluCloud/magalu/mgc/core/schema"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+func TestRestoreVersion(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "file.txt", []byte("first"))
+	server.PutObject("bucket", "file.txt", []byte("second"))
+
+	versions, err := getObjectVersioning(ctx, versioningObjectParams{Destination: "bucket/file.txt"}, cfg)
+	if err != nil {
+		t.Fatalf("getObjectVersioning() failed: %s", err)
+	}
+	oldest := versions[1].VersionID
+
+	if _, err = restoreVersion(ctx, restoreVersionParams{Destination: "bucket/file.txt", Version: oldest}, cfg); err != nil {
+		t.Fatalf("restoreVersion() failed: %s", err)
+	}
+	current, ok := server.Object("bucket", "file.txt")
+	if !ok || string(current.Data) != "first" {
+		t.Errorf("expected the restored content to be current, got %q", current.Data)
+	}
+	if count := server.VersionCount("bucket", "file.txt"); count != 3 {
+		t.Errorf("expected the restore to add a version, got %d versions", count)
+	}
+
+	_, err = restoreVersion(ctx, restoreVersionParams{Destination: "bucket/file.txt", Version: "missing"}, cfg)
+	if err == nil {
+		t.Error("restoring a missing version must fail")
+	}
+	_, err = restoreVersion(ctx, restoreVersionParams{Destination: "bucket", Version: oldest}, cfg)
+	if !errors.As(err, new(core.UsageError)) {
+		t.Errorf("expected usage error for a bucket destination, got %v", err)
+	}
+}
+
+func TestPurgeVersions(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	for i := 0; i < 4; i++ {
+		server.PutObject("bucket", "logs/a.log", []byte(fmt.Sprint("a", i)))
+	}
+	server.PutObject("bucket", "logs/b.log", []byte("b"))
+	server.PutObject("bucket", "other.txt", []byte("old"))
+	server.PutObject("bucket", "other.txt", []byte("new"))
+
+	dryRun, err := purgeVersions(ctx, purgeVersionsParams{Destination: "bucket/logs", KeepLast: 1, DryRun: true}, cfg)
+	if err != nil {
+		t.Fatalf("purgeVersions() failed: %s", err)
+	}
+	if dryRun.Count != 2 || server.VersionCount("bucket", "logs/a.log") != 4 {
+		t.Fatalf("dry run must only list the versions, got %+v", dryRun)
+	}
+
+	result, err := purgeVersions(ctx, purgeVersionsParams{Destination: "bucket/logs", KeepLast: 1}, cfg)
+	if err != nil {
+		t.Fatalf("purgeVersions() failed: %s", err)
+	}
+	if result.Count != 2 || result.TotalSize != 4 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if count := server.VersionCount("bucket", "logs/a.log"); count != 2 {
+		t.Errorf("expected the current and one noncurrent version to be kept, got %d versions", count)
+	}
+	if current, _ := server.Object("bucket", "logs/a.log"); string(current.Data) != "a3" {
+		t.Errorf("current version must be kept, got %q", current.Data)
+	}
+	if server.VersionCount("bucket", "logs/b.log") != 1 || server.VersionCount("bucket", "other.txt") != 2 {
+		t.Error("only noncurrent versions under the prefix must be purged")
+	}
+
+	_, err = purgeVersions(ctx, purgeVersionsParams{Destination: "bucket", OlderThan: "1d"}, cfg)
+	if err != nil {
+		t.Fatalf("purgeVersions() failed: %s", err)
+	}
+	if server.VersionCount("bucket", "other.txt") != 2 {
+		t.Error("recent versions must be kept when older_than is given")
+	}
+
+	_, err = purgeVersions(ctx, purgeVersionsParams{Destination: "bucket", Target: "everything"}, cfg)
+	if !errors.As(err, new(core.UsageError)) {
+		t.Errorf("expected usage error for an invalid target, got %v", err)
+	}
+}
+
+func TestPurgeDeleteMarkers(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	server.PutObject("bucket", "gone.txt", []byte("gone"))
+	server.PutObject("bucket", "hidden.txt", []byte("hidden"))
+	for _, key := range []string{"gone.txt", "hidden.txt"} {
+		if _, err := deleteObject(ctx, common.DeleteObjectParams{Destination: mgcSchemaPkg.URI("bucket/" + key)}, cfg); err != nil {
+			t.Fatalf("deleteObject() failed: %s", err)
+		}
+	}
+	if _, err := purgeVersions(ctx, purgeVersionsParams{Destination: "bucket/gone.txt"}, cfg); err != nil {
+		t.Fatalf("purgeVersions() failed: %s", err)
+	}
+
+	result, err := purgeVersions(ctx, purgeVersionsParams{Destination: "bucket", Target: purgeTargetDeleteMarkers}, cfg)
+	if err != nil {
+		t.Fatalf("purgeVersions() failed: %s", err)
+	}
+	if result.Count != 1 || result.Versions[0].Key != "gone.txt" || !result.Versions[0].IsDeleteMarker() {
+		t.Errorf("only the delete marker left alone must be purged, got %+v", result.Versions)
+	}
+	if server.VersionCount("bucket", "gone.txt") != 0 || server.VersionCount("bucket", "hidden.txt") != 2 {
+		t.Error("delete markers hiding versions must be kept")
+	}
+}
+
+func TestPurgeVersionsPaginates(t *testing.T) {
+	server, ctx, cfg := newTestServer(t)
+	for i := 0; i < common.ApiLimitMaxItems+2; i++ {
+		server.PutObject("bucket", "file.txt", []byte(fmt.Sprint(i)))
+	}
+
+	result, err := purgeVersions(ctx, purgeVersionsParams{Destination: "bucket/file.txt"}, cfg)
+	if err != nil {
+		t.Fatalf("purgeVersions() failed: %s", err)
+	}
+	if result.Count != common.ApiLimitMaxItems+1 || server.VersionCount("bucket", "file.txt") != 1 {
+		t.Errorf("expected every noncurrent version to be purged, got %d and %d left", result.Count, server.VersionCount("bucket", "file.txt"))
+	}
+}
@@ -0,0 +1,114 @@
+package s3test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+)
+
+const (
+	allUsersURI           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	authenticatedUsersURI = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+type grantee struct {
+	XMLName     xml.Name `xml:"Grantee"`
+	DisplayName string   `xml:"DisplayName,omitempty"`
+	ID          string   `xml:"ID,omitempty"`
+	URI         string   `xml:"URI,omitempty"`
+}
+
+type grant struct {
+	Grantee    grantee `xml:"Grantee"`
+	Permission string  `xml:"Permission"`
+}
+
+type accessControlPolicy struct {
+	XMLName xml.Name `xml:"AccessControlPolicy"`
+	Owner   xmlOwner `xml:"Owner"`
+	Grants  []grant  `xml:"AccessControlList>Grant"`
+}
+
+var grantHeaders = []struct {
+	header     string
+	permission string
+}{
+	{"X-Amz-Grant-Full-Control", "FULL_CONTROL"},
+	{"X-Amz-Grant-Read", "READ"},
+	{"X-Amz-Grant-Write", "WRITE"},
+	{"X-Amz-Grant-Read-Acp", "READ_ACP"},
+	{"X-Amz-Grant-Write-Acp", "WRITE_ACP"},
+}
+
+// Builds the ACL from the canned ACL and grant headers. Without them, only the owner has access
+func newAccessControlPolicy(header http.Header) accessControlPolicy {
+	policy := accessControlPolicy{
+		Owner:  owner,
+		Grants: []grant{{Grantee: grantee{ID: ownerID, DisplayName: ownerID}, Permission: "FULL_CONTROL"}},
+	}
+
+	switch header.Get("X-Amz-Acl") {
+	case "public-read":
+		policy.Grants = append(policy.Grants, grant{Grantee: grantee{URI: allUsersURI}, Permission: "READ"})
+	case "public-read-write":
+		policy.Grants = append(policy.Grants,
+			grant{Grantee: grantee{URI: allUsersURI}, Permission: "READ"},
+			grant{Grantee: grantee{URI: allUsersURI}, Permission: "WRITE"},
+		)
+	case "authenticated-read":
+		policy.Grants = append(policy.Grants, grant{Grantee: grantee{URI: authenticatedUsersURI}, Permission: "READ"})
+	}
+
+	for _, h := range grantHeaders {
+		value := header.Get(h.header)
+		if value == "" {
+			continue
+		}
+		for _, item := range strings.Split(value, ",") {
+			kind, id, _ := strings.Cut(strings.TrimSpace(item), "=")
+			g := grantee{ID: id}
+			if kind == "uri" {
+				g = grantee{URI: strings.Trim(id, `"`)}
+			}
+			policy.Grants = append(policy.Grants, grant{Grantee: g, Permission: h.permission})
+		}
+	}
+
+	return policy
+}
+
+// Replaces the ACL of the latest version of the object with a canned ACL, such as public-read
+func (s *Server) SetObjectACL(bucketName, key, cannedACL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[bucketName]; ok {
+		if obj := b.latest(key); obj != nil {
+			obj.acl = newAccessControlPolicy(http.Header{"X-Amz-Acl": {cannedACL}})
+		}
+	}
+}
+
+// As "permission:grantee" strings, the grantee being its ID or URI
+func (p accessControlPolicy) grants() []string {
+	result := make([]string, 0, len(p.Grants))
+	for _, g := range p.Grants {
+		who := g.Grantee.ID
+		if who == "" {
+			who = g.Grantee.URI
+		}
+		result = append(result, g.Permission+":"+who)
+	}
+	return result
+}
+
+func serveACL(w http.ResponseWriter, r *http.Request, acl *accessControlPolicy) {
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, acl)
+	case http.MethodPut:
+		*acl = newAccessControlPolicy(r.Header)
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported ACL operation")
+	}
+}
@@ -0,0 +1,599 @@
+package s3test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	versioningEnabled   = "Enabled"
+	versioningSuspended = "Suspended"
+)
+
+// Configurations stored as sent, since the server doesn't interpret them
+var rawSubresources = map[string]string{
+	"cors":        "NoSuchCORSConfiguration",
+	"lifecycle":   "NoSuchLifecycleConfiguration",
+	"policy":      "NoSuchBucketPolicy",
+	"replication": "ReplicationConfigurationNotFoundError",
+	"tagging":     "NoSuchTagSet",
+	"website":     "NoSuchWebsiteConfiguration",
+}
+
+type rawSubresource struct {
+	contentType string
+	body        []byte
+}
+
+type bucket struct {
+	name       string
+	created    time.Time
+	versioning string
+	acl        accessControlPolicy
+	objectLock []byte
+	// Applied to new objects, nil if the configuration has no default retention
+	defaultRetention *defaultRetention
+	raw              map[string]rawSubresource
+	// Versions of each key, from the oldest to the latest
+	objects map[string][]*object
+	uploads map[string]*multipartUpload
+}
+
+// Creates a bucket directly, without going through HTTP. Buckets are created with
+// versioning enabled, like the buckets created by the service
+func (s *Server) CreateBucket(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.createBucket(name, nil)
+}
+
+// Whether the bucket exists
+func (s *Server) HasBucket(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.buckets[name]
+	return ok
+}
+
+// Versioning status of the bucket: Enabled, Suspended or empty if it doesn't exist
+func (s *Server) Versioning(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[name]; ok {
+		return b.versioning
+	}
+	return ""
+}
+
+// Grants of the bucket ACL, as "permission:grantee" strings
+func (s *Server) BucketGrants(name string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[name]; ok {
+		return b.acl.grants()
+	}
+	return nil
+}
+
+// Object lock configuration of the bucket as sent, nil if it's not set
+func (s *Server) ObjectLockConfiguration(name string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[name]; ok {
+		return b.objectLock
+	}
+	return nil
+}
+
+// Sets the bucket policy directly, as sent by a client
+func (s *Server) SetBucketPolicy(name string, policy []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[name]; ok {
+		b.raw["policy"] = rawSubresource{contentType: "application/json", body: policy}
+	}
+}
+
+// Sets the website configuration directly, as sent by a client
+func (s *Server) SetBucketWebsite(name string, website []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[name]; ok {
+		b.raw["website"] = rawSubresource{contentType: "application/xml", body: website}
+	}
+}
+
+// Enables object lock on the bucket, without a default retention
+func (s *Server) EnableObjectLock(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[name]; ok {
+		b.objectLock = []byte("<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>")
+	}
+}
+
+func (s *Server) createBucket(name string, header http.Header) *bucket {
+	b := &bucket{
+		name:       name,
+		created:    time.Now(),
+		versioning: versioningEnabled,
+		acl:        newAccessControlPolicy(header),
+		raw:        map[string]rawSubresource{},
+		objects:    map[string][]*object{},
+		uploads:    map[string]*multipartUpload{},
+	}
+	s.buckets[name] = b
+	return b
+}
+
+// Latest version of the key, nil if it doesn't exist or is deleted
+func (b *bucket) latest(key string) *object {
+	versions := b.objects[key]
+	if len(versions) == 0 || versions[len(versions)-1].deleteMarker {
+		return nil
+	}
+	return versions[len(versions)-1]
+}
+
+func (b *bucket) version(key, versionId string) *object {
+	if versionId == "" {
+		return b.latest(key)
+	}
+	for _, obj := range b.objects[key] {
+		if obj.versionId == versionId {
+			return obj
+		}
+	}
+	return nil
+}
+
+func (b *bucket) isEmpty() bool {
+	for key := range b.objects {
+		if len(b.objects[key]) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Adds a new version of the key, replacing the "null" version unless versioning is enabled.
+// The default retention of the bucket, if any, is applied to the new version
+func (s *Server) addVersion(b *bucket, obj *object) {
+	if b.defaultRetention != nil && !obj.deleteMarker && obj.retention == nil {
+		obj.retention = b.defaultRetention.retention(obj.modified)
+	}
+	if b.versioning == versioningEnabled {
+		obj.versionId = s.newId()
+		b.objects[obj.key] = append(b.objects[obj.key], obj)
+		return
+	}
+
+	obj.versionId = nullVersionId
+	versions := b.objects[obj.key]
+	kept := versions[:0]
+	for _, v := range versions {
+		if v.versionId != nullVersionId {
+			kept = append(kept, v)
+		}
+	}
+	b.objects[obj.key] = append(kept, obj)
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, name string, body []byte) {
+	b, exists := s.buckets[name]
+
+	if r.Method == http.MethodPut && len(r.URL.Query()) == 0 {
+		if exists {
+			writeError(w, http.StatusConflict, "BucketAlreadyOwnedByYou", "the bucket already exists")
+			return
+		}
+		s.createBucket(name, r.Header)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !exists {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "the bucket does not exist")
+		return
+	}
+
+	for subresource, notFoundCode := range rawSubresources {
+		if hasParam(r, subresource) {
+			s.serveRawSubresource(w, r, b, subresource, notFoundCode, body)
+			return
+		}
+	}
+
+	switch {
+	case hasParam(r, "versioning"):
+		s.serveVersioning(w, r, b, body)
+	case hasParam(r, "acl"):
+		serveACL(w, r, &b.acl)
+	case hasParam(r, "object-lock"):
+		s.serveObjectLock(w, r, b, body)
+	case hasParam(r, "versions") && r.Method == http.MethodGet:
+		s.listVersions(w, r, b)
+	case hasParam(r, "uploads") && r.Method == http.MethodGet:
+		s.listMultipartUploads(w, r, b)
+	case hasParam(r, "delete") && r.Method == http.MethodPost:
+		s.deleteObjects(w, b, body)
+	case r.Method == http.MethodGet:
+		s.listObjects(w, r, b)
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete:
+		s.deleteBucket(w, r, b)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported bucket operation")
+	}
+}
+
+func (s *Server) deleteBucket(w http.ResponseWriter, r *http.Request, b *bucket) {
+	if !b.isEmpty() && r.Header.Get(forceDeleteHeader) != "true" {
+		writeError(w, http.StatusConflict, "BucketNotEmpty", "the bucket you tried to delete is not empty")
+		return
+	}
+	delete(s.buckets, b.name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveRawSubresource(w http.ResponseWriter, r *http.Request, b *bucket, name, notFoundCode string, body []byte) {
+	switch r.Method {
+	case http.MethodPut:
+		// As the server does, policies are returned as JSON and the other subresources as XML,
+		// regardless of the type they were sent with
+		contentType := "application/xml"
+		if name == "policy" {
+			contentType = "application/json"
+		}
+		b.raw[name] = rawSubresource{contentType: contentType, body: body}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		sub, ok := b.raw[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, notFoundCode, "the "+name+" configuration does not exist")
+			return
+		}
+		w.Header().Set("Content-Type", sub.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(sub.body)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(sub.body)
+	case http.MethodDelete:
+		delete(b.raw, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported "+name+" operation")
+	}
+}
+
+type versioningConfiguration struct {
+	XMLName xml.Name `xml:"VersioningConfiguration"`
+	Status  string   `xml:"Status,omitempty"`
+}
+
+func (s *Server) serveVersioning(w http.ResponseWriter, r *http.Request, b *bucket, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, versioningConfiguration{Status: b.versioning})
+	case http.MethodPut:
+		var config versioningConfiguration
+		if err := readXML(body, &config); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		if config.Status != versioningEnabled && config.Status != versioningSuspended {
+			writeError(w, http.StatusBadRequest, "IllegalVersioningConfigurationException", "invalid versioning status "+config.Status)
+			return
+		}
+		if config.Status == versioningSuspended && b.objectLock != nil {
+			writeError(w, http.StatusConflict, "InvalidBucketState", "versioning can't be suspended on buckets with object lock")
+			return
+		}
+		b.versioning = config.Status
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported versioning operation")
+	}
+}
+
+type defaultRetention struct {
+	Mode  string `xml:"Mode"`
+	Days  int    `xml:"Days"`
+	Years int    `xml:"Years"`
+}
+
+func (d *defaultRetention) retention(from time.Time) *retention {
+	until := from.AddDate(d.Years, 0, d.Days).UTC()
+	return &retention{Mode: d.Mode, RetainUntilDate: until.Format(time.RFC3339), until: until}
+}
+
+type objectLockConfiguration struct {
+	XMLName           xml.Name          `xml:"ObjectLockConfiguration"`
+	ObjectLockEnabled string            `xml:"ObjectLockEnabled"`
+	DefaultRetention  *defaultRetention `xml:"Rule>DefaultRetention"`
+}
+
+func (s *Server) serveObjectLock(w http.ResponseWriter, r *http.Request, b *bucket, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		if b.objectLock == nil {
+			writeError(w, http.StatusNotFound, "ObjectLockConfigurationNotFoundError", "object lock configuration does not exist for this bucket")
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(b.objectLock)
+	case http.MethodPut:
+		var config objectLockConfiguration
+		if err := readXML(body, &config); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		if b.versioning != versioningEnabled {
+			writeError(w, http.StatusConflict, "InvalidBucketState", "object lock requires versioning to be enabled")
+			return
+		}
+		b.objectLock = body
+		b.defaultRetention = config.DefaultRetention
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported object lock operation")
+	}
+}
+
+type listedObject struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type listObjectsResult struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	KeyCount              int            `xml:"KeyCount"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	Contents              []listedObject `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+func parseMaxKeys(query url.Values) int {
+	maxKeys, err := strconv.Atoi(query.Get("max-keys"))
+	if err != nil || maxKeys <= 0 || maxKeys > defaultMaxKeys {
+		return defaultMaxKeys
+	}
+	return maxKeys
+}
+
+// Keys with a latest version, sorted
+func (b *bucket) sortedKeys(prefix string) []string {
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) && b.latest(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ListObjectsV2. The continuation token is the last key or common prefix returned
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, b *bucket) {
+	query := r.URL.Query()
+	result := listObjectsResult{
+		Name:              b.name,
+		Prefix:            query.Get("prefix"),
+		Delimiter:         query.Get("delimiter"),
+		MaxKeys:           parseMaxKeys(query),
+		ContinuationToken: query.Get("continuation-token"),
+	}
+	after := result.ContinuationToken
+	if after == "" {
+		after = query.Get("start-after")
+	}
+
+	last := ""
+	for _, key := range b.sortedKeys(result.Prefix) {
+		entry := key
+		isPrefix := false
+		if result.Delimiter != "" {
+			rest := strings.TrimPrefix(key, result.Prefix)
+			if i := strings.Index(rest, result.Delimiter); i >= 0 {
+				entry = result.Prefix + rest[:i+len(result.Delimiter)]
+				isPrefix = true
+			}
+		}
+		if entry <= after || entry == last {
+			continue
+		}
+		if result.KeyCount == result.MaxKeys {
+			result.IsTruncated = true
+			result.NextContinuationToken = last
+			break
+		}
+
+		last = entry
+		result.KeyCount++
+		if isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: entry})
+			continue
+		}
+		obj := b.latest(key)
+		result.Contents = append(result.Contents, listedObject{
+			Key:          key,
+			LastModified: formatLastModified(obj.modified),
+			ETag:         obj.quotedETag(),
+			Size:         int64(len(obj.data)),
+			StorageClass: obj.storageClass,
+		})
+	}
+
+	writeXML(w, http.StatusOK, result)
+}
+
+type listedVersion struct {
+	// Either Version or DeleteMarker
+	XMLName      xml.Name
+	Key          string   `xml:"Key"`
+	VersionId    string   `xml:"VersionId"`
+	IsLatest     bool     `xml:"IsLatest"`
+	LastModified string   `xml:"LastModified"`
+	ETag         string   `xml:"ETag,omitempty"`
+	Size         int64    `xml:"Size"`
+	StorageClass string   `xml:"StorageClass,omitempty"`
+	Owner        xmlOwner `xml:"Owner"`
+}
+
+type listVersionsResult struct {
+	XMLName             xml.Name `xml:"ListVersionsResult"`
+	Name                string   `xml:"Name"`
+	Prefix              string   `xml:"Prefix"`
+	KeyMarker           string   `xml:"KeyMarker"`
+	VersionIdMarker     string   `xml:"VersionIdMarker"`
+	MaxKeys             int      `xml:"MaxKeys"`
+	IsTruncated         bool     `xml:"IsTruncated"`
+	NextKeyMarker       string   `xml:"NextKeyMarker,omitempty"`
+	NextVersionIdMarker string   `xml:"NextVersionIdMarker,omitempty"`
+	// Versions and delete markers, interleaved in listing order
+	Entries []listedVersion
+}
+
+// Lists every version of the keys with the prefix, latest first, in pages of max-keys
+// entries that continue after key-marker and version-id-marker
+func (s *Server) listVersions(w http.ResponseWriter, r *http.Request, b *bucket) {
+	query := r.URL.Query()
+	result := listVersionsResult{
+		Name:            b.name,
+		Prefix:          query.Get("prefix"),
+		KeyMarker:       query.Get("key-marker"),
+		VersionIdMarker: query.Get("version-id-marker"),
+		MaxKeys:         defaultMaxKeys,
+	}
+	if maxKeys, err := strconv.Atoi(query.Get("max-keys")); err == nil && maxKeys > 0 && maxKeys < defaultMaxKeys {
+		result.MaxKeys = maxKeys
+	}
+	// Without a version marker, the listing continues after every version of the key marker
+	skipping := result.KeyMarker != ""
+	var lastKey, lastVersionId string
+
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		if strings.HasPrefix(key, result.Prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if skipping && key < result.KeyMarker {
+			continue
+		}
+		versions := b.objects[key]
+		for i := len(versions) - 1; i >= 0; i-- {
+			obj := versions[i]
+			if skipping {
+				if key == result.KeyMarker && (result.VersionIdMarker == "" || obj.versionId != result.VersionIdMarker) {
+					continue
+				}
+				skipping = false
+				if key == result.KeyMarker {
+					continue
+				}
+			}
+			if len(result.Entries) == result.MaxKeys {
+				result.IsTruncated = true
+				result.NextKeyMarker, result.NextVersionIdMarker = lastKey, lastVersionId
+				writeXML(w, http.StatusOK, result)
+				return
+			}
+			listed := listedVersion{
+				XMLName:      xml.Name{Local: "Version"},
+				Key:          key,
+				VersionId:    obj.versionId,
+				IsLatest:     i == len(versions)-1,
+				LastModified: formatLastModified(obj.modified),
+				Owner:        owner,
+			}
+			if query.Get("encoding-type") == "url" {
+				listed.Key = url.QueryEscape(key)
+			}
+			if obj.deleteMarker {
+				listed.XMLName.Local = "DeleteMarker"
+			} else {
+				listed.ETag = obj.quotedETag()
+				listed.Size = int64(len(obj.data))
+				listed.StorageClass = obj.storageClass
+			}
+			result.Entries = append(result.Entries, listed)
+			lastKey, lastVersionId = key, obj.versionId
+		}
+	}
+
+	writeXML(w, http.StatusOK, result)
+}
+
+type deleteRequest struct {
+	XMLName xml.Name `xml:"Delete"`
+	Quiet   bool     `xml:"Quiet"`
+	Objects []struct {
+		Key       string `xml:"Key"`
+		VersionId string `xml:"VersionId"`
+	} `xml:"Object"`
+}
+
+type deletedObject struct {
+	Key       string `xml:"Key"`
+	VersionId string `xml:"VersionId,omitempty"`
+}
+
+type deleteError struct {
+	Key       string `xml:"Key"`
+	VersionId string `xml:"VersionId,omitempty"`
+	Code      string `xml:"Code"`
+	Message   string `xml:"Message"`
+}
+
+type deleteResult struct {
+	XMLName xml.Name        `xml:"DeleteResult"`
+	Deleted []deletedObject `xml:"Deleted"`
+	Errors  []deleteError   `xml:"Error"`
+}
+
+func (s *Server) deleteObjects(w http.ResponseWriter, b *bucket, body []byte) {
+	var req deleteRequest
+	if err := readXML(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	if len(req.Objects) > defaultMaxKeys {
+		writeError(w, http.StatusBadRequest, "MalformedXML", "at most 1000 objects can be deleted per request")
+		return
+	}
+
+	var result deleteResult
+	for _, obj := range req.Objects {
+		if code, message := s.deleteObject(b, obj.Key, obj.VersionId); code != "" {
+			result.Errors = append(result.Errors, deleteError{Key: obj.Key, VersionId: obj.VersionId, Code: code, Message: message})
+			continue
+		}
+		if !req.Quiet {
+			result.Deleted = append(result.Deleted, deletedObject{Key: obj.Key, VersionId: obj.VersionId})
+		}
+	}
+	writeXML(w, http.StatusOK, result)
+}
@@ -0,0 +1,406 @@
+package s3test
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type uploadedPart struct {
+	data     []byte
+	etag     string
+	checksum []byte
+	modified time.Time
+}
+
+type multipartUpload struct {
+	id        string
+	key       string
+	initiated time.Time
+	// Headers of the object to be created on completion
+	header    http.Header
+	tags      []tag
+	sseKeyMD5 string
+	// Flexible checksum algorithm required for every part, empty if none
+	checksumAlgorithm string
+	parts             map[int]*uploadedPart
+}
+
+// Number of multipart uploads in progress in the bucket
+func (s *Server) UploadCount(bucketName string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[bucketName]; ok {
+		return len(b.uploads)
+	}
+	return 0
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadId string   `xml:"UploadId"`
+}
+
+func (s *Server) createMultipartUpload(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	keyMD5, err := sseKeyMD5(r.Header, "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	tags, err := parseTags(r.Header.Get("X-Amz-Tagging"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	algorithm := strings.ToUpper(r.Header.Get("X-Amz-Checksum-Algorithm"))
+	if _, ok := checksumAlgorithms[algorithm]; algorithm != "" && !ok {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "unsupported checksum algorithm "+algorithm)
+		return
+	}
+
+	upload := &multipartUpload{
+		id:                s.newId(),
+		key:               key,
+		initiated:         time.Now(),
+		header:            r.Header.Clone(),
+		tags:              tags,
+		sseKeyMD5:         keyMD5,
+		checksumAlgorithm: algorithm,
+		parts:             map[int]*uploadedPart{},
+	}
+	b.uploads[upload.id] = upload
+
+	writeXML(w, http.StatusOK, initiateMultipartUploadResult{Bucket: b.name, Key: key, UploadId: upload.id})
+}
+
+func (s *Server) serveMultipartUpload(w http.ResponseWriter, r *http.Request, b *bucket, key string, body []byte) {
+	upload, ok := b.uploads[r.URL.Query().Get("uploadId")]
+	if !ok || upload.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "the specified multipart upload does not exist")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		s.uploadPart(w, r, upload, body)
+	case http.MethodPost:
+		if checkWriteConditions(w, r, b.latest(key)) {
+			s.completeMultipartUpload(w, b, upload, body)
+		}
+	case http.MethodGet:
+		listParts(w, r, b, upload)
+	case http.MethodDelete:
+		delete(b.uploads, upload.id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported multipart upload operation")
+	}
+}
+
+type copyPartResult struct {
+	XMLName      xml.Name `xml:"CopyPartResult"`
+	ETag         string   `xml:"ETag"`
+	LastModified string   `xml:"LastModified"`
+}
+
+// Uploads the part from the body, or from a range of another object with UploadPartCopy
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, upload *multipartUpload, body []byte) {
+	partNumber, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > 10000 {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "part number must be an integer between 1 and 10000")
+		return
+	}
+
+	keyMD5, err := sseKeyMD5(r.Header, "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	if keyMD5 != upload.sseKeyMD5 {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "the SSE-C key of the part differs from the one of the upload")
+		return
+	}
+
+	isCopy := r.Header.Get("X-Amz-Copy-Source") != ""
+	data := body
+	if isCopy {
+		src, status, code, message := s.copySource(r)
+		if status != 0 {
+			writeError(w, status, code, message)
+			return
+		}
+		data = src.data
+		if rangeHeader := r.Header.Get("X-Amz-Copy-Source-Range"); rangeHeader != "" {
+			start, end, ok := parseRange(rangeHeader, int64(len(data)))
+			if !ok {
+				writeError(w, http.StatusBadRequest, "InvalidArgument", "invalid copy source range "+rangeHeader)
+				return
+			}
+			data = data[start : end+1]
+		}
+		data = append([]byte(nil), data...)
+	}
+
+	checksums, err := verifyChecksums(r.Header, data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BadDigest", err.Error())
+		return
+	}
+
+	part := &uploadedPart{data: data, etag: contentETag(data, keyMD5), modified: time.Now()}
+	if upload.checksumAlgorithm != "" {
+		part.checksum = checksum(upload.checksumAlgorithm, data)
+		checksums[upload.checksumAlgorithm] = base64.StdEncoding.EncodeToString(part.checksum)
+	}
+	upload.parts[partNumber] = part
+
+	if isCopy {
+		writeXML(w, http.StatusOK, copyPartResult{ETag: `"` + part.etag + `"`, LastModified: formatLastModified(part.modified)})
+		return
+	}
+	w.Header().Set("ETag", `"`+part.etag+`"`)
+	for algorithm, value := range checksums {
+		w.Header().Set(checksumHeader(algorithm), value)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+type completedPart struct {
+	PartNumber     int    `xml:"PartNumber"`
+	ETag           string `xml:"ETag"`
+	ChecksumCRC32C string `xml:"ChecksumCRC32C"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256"`
+}
+
+func (p completedPart) checksum(algorithm string) string {
+	switch algorithm {
+	case "CRC32C":
+		return p.ChecksumCRC32C
+	case "SHA256":
+		return p.ChecksumSHA256
+	}
+	return ""
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName        xml.Name `xml:"CompleteMultipartUploadResult"`
+	Bucket         string   `xml:"Bucket"`
+	Key            string   `xml:"Key"`
+	ETag           string   `xml:"ETag"`
+	ChecksumCRC32C string   `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA256 string   `xml:"ChecksumSHA256,omitempty"`
+}
+
+// The ETag of multipart objects is the MD5 of the concatenated part MD5s, followed by the number of parts
+func compositeETag(parts []*uploadedPart) string {
+	h := md5.New()
+	for _, part := range parts {
+		sum, _ := hex.DecodeString(part.etag)
+		h.Write(sum)
+	}
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(h.Sum(nil)), len(parts))
+}
+
+func compositeChecksum(algorithm string, parts []*uploadedPart) string {
+	h := checksumAlgorithms[algorithm]()
+	for _, part := range parts {
+		h.Write(part.checksum)
+	}
+	return fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(h.Sum(nil)), len(parts))
+}
+
+func (s *Server) completeMultipartUpload(w http.ResponseWriter, b *bucket, upload *multipartUpload, body []byte) {
+	var req completeMultipartUpload
+	if err := readXML(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	if len(req.Parts) == 0 {
+		writeError(w, http.StatusBadRequest, "MalformedXML", "at least one part must be given")
+		return
+	}
+
+	parts := make([]*uploadedPart, 0, len(req.Parts))
+	for i, completed := range req.Parts {
+		if i > 0 && completed.PartNumber <= req.Parts[i-1].PartNumber {
+			writeError(w, http.StatusBadRequest, "InvalidPartOrder", "the parts must be in ascending order")
+			return
+		}
+		part, ok := upload.parts[completed.PartNumber]
+		if !ok || strings.Trim(completed.ETag, `"`) != part.etag {
+			writeError(w, http.StatusBadRequest, "InvalidPart", fmt.Sprintf("part %d was not uploaded or its ETag doesn't match", completed.PartNumber))
+			return
+		}
+		if upload.checksumAlgorithm != "" && completed.checksum(upload.checksumAlgorithm) != base64.StdEncoding.EncodeToString(part.checksum) {
+			writeError(w, http.StatusBadRequest, "InvalidPart", fmt.Sprintf("part %d is missing its %s checksum or it doesn't match", completed.PartNumber, upload.checksumAlgorithm))
+			return
+		}
+		parts = append(parts, part)
+	}
+
+	var data []byte
+	obj := newObject(upload.key, nil, upload.header)
+	for _, part := range parts {
+		data = append(data, part.data...)
+		obj.partSizes = append(obj.partSizes, int64(len(part.data)))
+	}
+	obj.data = data
+	obj.etag = compositeETag(parts)
+	obj.tags = upload.tags
+	obj.sseKeyMD5 = upload.sseKeyMD5
+	if upload.checksumAlgorithm != "" {
+		obj.checksums[upload.checksumAlgorithm] = compositeChecksum(upload.checksumAlgorithm, parts)
+	}
+	s.addVersion(b, obj)
+	delete(b.uploads, upload.id)
+
+	w.Header().Set("X-Amz-Version-Id", obj.versionId)
+	writeXML(w, http.StatusOK, completeMultipartUploadResult{
+		Bucket:         b.name,
+		Key:            upload.key,
+		ETag:           obj.quotedETag(),
+		ChecksumCRC32C: obj.checksums["CRC32C"],
+		ChecksumSHA256: obj.checksums["SHA256"],
+	})
+}
+
+type listedPart struct {
+	PartNumber   int    `xml:"PartNumber"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	LastModified string `xml:"LastModified"`
+}
+
+type listPartsResult struct {
+	XMLName              xml.Name     `xml:"ListPartsResult"`
+	Bucket               string       `xml:"Bucket"`
+	Key                  string       `xml:"Key"`
+	UploadId             string       `xml:"UploadId"`
+	PartNumberMarker     int          `xml:"PartNumberMarker"`
+	NextPartNumberMarker int          `xml:"NextPartNumberMarker"`
+	MaxParts             int          `xml:"MaxParts"`
+	IsTruncated          bool         `xml:"IsTruncated"`
+	Parts                []listedPart `xml:"Part"`
+}
+
+func listParts(w http.ResponseWriter, r *http.Request, b *bucket, upload *multipartUpload) {
+	query := r.URL.Query()
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+	maxParts, err := strconv.Atoi(query.Get("max-parts"))
+	if err != nil || maxParts <= 0 || maxParts > defaultMaxKeys {
+		maxParts = defaultMaxKeys
+	}
+
+	numbers := make([]int, 0, len(upload.parts))
+	for number := range upload.parts {
+		if number > marker {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+
+	result := listPartsResult{Bucket: b.name, Key: upload.key, UploadId: upload.id, PartNumberMarker: marker, MaxParts: maxParts}
+	for _, number := range numbers {
+		if len(result.Parts) == maxParts {
+			result.IsTruncated = true
+			break
+		}
+		part := upload.parts[number]
+		result.Parts = append(result.Parts, listedPart{
+			PartNumber:   number,
+			ETag:         `"` + part.etag + `"`,
+			Size:         int64(len(part.data)),
+			LastModified: formatLastModified(part.modified),
+		})
+		result.NextPartNumberMarker = number
+	}
+
+	writeXML(w, http.StatusOK, result)
+}
+
+type listedUpload struct {
+	Key          string `xml:"Key"`
+	UploadId     string `xml:"UploadId"`
+	Initiated    string `xml:"Initiated"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type listMultipartUploadsResult struct {
+	XMLName            xml.Name       `xml:"ListMultipartUploadsResult"`
+	Bucket             string         `xml:"Bucket"`
+	Prefix             string         `xml:"Prefix"`
+	KeyMarker          string         `xml:"KeyMarker"`
+	UploadIdMarker     string         `xml:"UploadIdMarker"`
+	NextKeyMarker      string         `xml:"NextKeyMarker"`
+	NextUploadIdMarker string         `xml:"NextUploadIdMarker"`
+	MaxUploads         int            `xml:"MaxUploads"`
+	IsTruncated        bool           `xml:"IsTruncated"`
+	Uploads            []listedUpload `xml:"Upload"`
+}
+
+// Uploads are sorted by key and then by ID, which grows with time
+func (s *Server) listMultipartUploads(w http.ResponseWriter, r *http.Request, b *bucket) {
+	query := r.URL.Query()
+	result := listMultipartUploadsResult{
+		Bucket:         b.name,
+		Prefix:         query.Get("prefix"),
+		KeyMarker:      query.Get("key-marker"),
+		UploadIdMarker: query.Get("upload-id-marker"),
+		MaxUploads:     defaultMaxKeys,
+	}
+	if maxUploads, err := strconv.Atoi(query.Get("max-uploads")); err == nil && maxUploads > 0 && maxUploads < defaultMaxKeys {
+		result.MaxUploads = maxUploads
+	}
+
+	uploads := make([]*multipartUpload, 0, len(b.uploads))
+	for _, upload := range b.uploads {
+		if !strings.HasPrefix(upload.key, result.Prefix) {
+			continue
+		}
+		if upload.key < result.KeyMarker || (upload.key == result.KeyMarker && upload.id <= result.UploadIdMarker) {
+			continue
+		}
+		uploads = append(uploads, upload)
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].key != uploads[j].key {
+			return uploads[i].key < uploads[j].key
+		}
+		return uploads[i].id < uploads[j].id
+	})
+
+	for _, upload := range uploads {
+		if len(result.Uploads) == result.MaxUploads {
+			result.IsTruncated = true
+			break
+		}
+		storageClass := upload.header.Get("X-Amz-Storage-Class")
+		if storageClass == "" {
+			storageClass = defaultStorageClass
+		}
+		result.Uploads = append(result.Uploads, listedUpload{
+			Key:          upload.key,
+			UploadId:     upload.id,
+			Initiated:    formatLastModified(upload.initiated),
+			StorageClass: storageClass,
+		})
+		result.NextKeyMarker = upload.key
+		result.NextUploadIdMarker = upload.id
+	}
+
+	writeXML(w, http.StatusOK, result)
+}
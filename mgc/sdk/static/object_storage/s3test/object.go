@@ -0,0 +1,664 @@
+package s3test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStorageClass = "STANDARD"
+
+	sseCustomerAlgorithmHeader = "X-Amz-Server-Side-Encryption-Customer-Algorithm"
+	sseCustomerKeyHeader       = "X-Amz-Server-Side-Encryption-Customer-Key"
+	sseCustomerKeyMD5Header    = "X-Amz-Server-Side-Encryption-Customer-Key-Md5"
+	copySourceSSEPrefix        = "X-Amz-Copy-Source-"
+)
+
+// Headers stored with the object and returned when reading it
+var storedHeaders = []string{"Content-Type", "Cache-Control", "Content-Disposition", "Content-Encoding"}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Flexible checksums verified on arrival and returned in checksum mode
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"CRC32C": func() hash.Hash { return crc32.New(crc32cTable) },
+	"SHA256": sha256.New,
+}
+
+func checksumHeader(algorithm string) string {
+	return http.CanonicalHeaderKey("X-Amz-Checksum-" + algorithm)
+}
+
+func checksum(algorithm string, data []byte) []byte {
+	h := checksumAlgorithms[algorithm]()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+type object struct {
+	key          string
+	versionId    string
+	deleteMarker bool
+	data         []byte
+	etag         string
+	header       http.Header
+	storageClass string
+	modified     time.Time
+	acl          accessControlPolicy
+	tags         []tag
+	retention    *retention
+	// MD5 of the SSE-C key, base64 encoded. Empty if the object isn't encrypted
+	sseKeyMD5 string
+	// Flexible checksums by algorithm, composite for multipart objects
+	checksums map[string]string
+	// Empty for objects not uploaded in parts
+	partSizes []int64
+	// Set by SetReplicationStatus, since the server doesn't replicate
+	replicationStatus string
+}
+
+func (o *object) quotedETag() string {
+	return `"` + o.etag + `"`
+}
+
+// Contents of an object, as stored by the server
+type Object struct {
+	Data         []byte
+	ETag         string
+	VersionId    string
+	StorageClass string
+	Header       http.Header
+	Tags         map[string]string
+	Grants       []string
+	Parts        int
+}
+
+// Puts the object directly, without going through HTTP
+func (s *Server) PutObject(bucketName, key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		b = s.createBucket(bucketName, nil)
+	}
+	obj := newObject(key, data, nil)
+	obj.etag = md5Hex(data)
+	s.addVersion(b, obj)
+}
+
+// Latest version of the object, false if it doesn't exist or is deleted
+func (s *Server) Object(bucketName, key string) (Object, bool) {
+	return s.ObjectVersion(bucketName, key, "")
+}
+
+func (s *Server) ObjectVersion(bucketName, key, versionId string) (Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return Object{}, false
+	}
+	obj := b.version(key, versionId)
+	if obj == nil || obj.deleteMarker {
+		return Object{}, false
+	}
+
+	tags := make(map[string]string, len(obj.tags))
+	for _, t := range obj.tags {
+		tags[t.Key] = t.Value
+	}
+	return Object{
+		Data:         append([]byte(nil), obj.data...),
+		ETag:         obj.etag,
+		VersionId:    obj.versionId,
+		StorageClass: obj.storageClass,
+		Header:       obj.header.Clone(),
+		Tags:         tags,
+		Grants:       obj.acl.grants(),
+		Parts:        len(obj.partSizes),
+	}, true
+}
+
+// Sets the replication status of the latest version of the object, such as COMPLETED
+func (s *Server) SetReplicationStatus(bucketName, key, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[bucketName]; ok {
+		if obj := b.latest(key); obj != nil {
+			obj.replicationStatus = status
+		}
+	}
+}
+
+// Number of versions of the key, including delete markers
+func (s *Server) VersionCount(bucketName, key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[bucketName]; ok {
+		return len(b.objects[key])
+	}
+	return 0
+}
+
+func newObject(key string, data []byte, header http.Header) *object {
+	obj := &object{
+		key:          key,
+		data:         data,
+		header:       http.Header{},
+		storageClass: defaultStorageClass,
+		modified:     time.Now(),
+		acl:          newAccessControlPolicy(header),
+		checksums:    map[string]string{},
+	}
+	if header == nil {
+		return obj
+	}
+	obj.setHeaders(header)
+	if class := header.Get("X-Amz-Storage-Class"); class != "" {
+		obj.storageClass = class
+	}
+	return obj
+}
+
+func (o *object) setHeaders(header http.Header) {
+	o.header = http.Header{}
+	for _, name := range storedHeaders {
+		if value := header.Get(name); value != "" {
+			o.header.Set(name, value)
+		}
+	}
+	for name, values := range header {
+		if strings.HasPrefix(name, "X-Amz-Meta-") {
+			o.header[name] = values
+		}
+	}
+}
+
+// The MD5 of the SSE-C key of the request, empty if not encrypted. Fails if the key doesn't match its MD5
+func sseKeyMD5(header http.Header, prefix string) (string, error) {
+	if header.Get(prefix+sseCustomerAlgorithmHeader) == "" {
+		return "", nil
+	}
+	key, err := base64.StdEncoding.DecodeString(header.Get(prefix + sseCustomerKeyHeader))
+	if err != nil || len(key) != 32 {
+		return "", fmt.Errorf("the SSE-C key must have 32 bytes")
+	}
+	sum := md5.Sum(key)
+	keyMD5 := base64.StdEncoding.EncodeToString(sum[:])
+	if keyMD5 != header.Get(prefix+sseCustomerKeyMD5Header) {
+		return "", fmt.Errorf("the SSE-C key MD5 doesn't match the key")
+	}
+	return keyMD5, nil
+}
+
+// Fails unless the request has the key the object was encrypted with
+func (o *object) checkSSEKey(header http.Header, prefix string) (int, string, string) {
+	keyMD5, err := sseKeyMD5(header, prefix)
+	if err != nil {
+		return http.StatusBadRequest, "InvalidArgument", err.Error()
+	}
+	if keyMD5 != o.sseKeyMD5 {
+		if o.sseKeyMD5 == "" {
+			return http.StatusBadRequest, "InvalidRequest", "the object was not encrypted with a customer-provided key"
+		}
+		return http.StatusBadRequest, "InvalidRequest", "the object was encrypted with a different customer-provided key"
+	}
+	return 0, "", ""
+}
+
+// The ETag of encrypted objects is not the MD5 of their content, like in the real service
+func contentETag(data []byte, keyMD5 string) string {
+	if keyMD5 == "" {
+		return md5Hex(data)
+	}
+	return md5Hex(append([]byte(keyMD5), data...))
+}
+
+// Verifies the flexible checksum headers of the request against the body
+func verifyChecksums(header http.Header, body []byte) (map[string]string, error) {
+	checksums := map[string]string{}
+	for algorithm := range checksumAlgorithms {
+		expected := header.Get(checksumHeader(algorithm))
+		if expected == "" {
+			continue
+		}
+		actual := base64.StdEncoding.EncodeToString(checksum(algorithm, body))
+		if actual != expected {
+			return nil, fmt.Errorf("the %s checksum doesn't match the body", algorithm)
+		}
+		checksums[algorithm] = actual
+	}
+	return checksums, nil
+}
+
+func parseTags(value string) ([]tag, error) {
+	values, err := url.ParseQuery(value)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]tag, 0, len(values))
+	for k := range values {
+		tags = append(tags, tag{Key: k, Value: values.Get(k)})
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, body []byte) {
+	query := r.URL.Query()
+	switch {
+	case hasParam(r, "uploads") && r.Method == http.MethodPost:
+		s.createMultipartUpload(w, r, b, key)
+	case hasParam(r, "uploadId"):
+		s.serveMultipartUpload(w, r, b, key, body)
+	case hasParam(r, "acl"), hasParam(r, "tagging"), hasParam(r, "retention"):
+		obj := b.version(key, query.Get("versionId"))
+		if obj == nil || obj.deleteMarker {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "the object does not exist")
+			return
+		}
+		switch {
+		case hasParam(r, "acl"):
+			serveACL(w, r, &obj.acl)
+		case hasParam(r, "tagging"):
+			serveTagging(w, r, obj, body)
+		default:
+			serveRetention(w, r, b, obj, body)
+		}
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		if checkWriteConditions(w, r, b.latest(key)) {
+			s.copyObject(w, r, b, key)
+		}
+	case r.Method == http.MethodPut:
+		if checkWriteConditions(w, r, b.latest(key)) {
+			s.putObject(w, r, b, key, body)
+		}
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		s.getObject(w, r, b, key)
+	case r.Method == http.MethodDelete:
+		versionId := query.Get("versionId")
+		if !checkWriteConditions(w, r, b.version(key, versionId)) {
+			return
+		}
+		if code, message := s.deleteObject(b, key, versionId); code != "" {
+			writeError(w, http.StatusForbidden, code, message)
+			return
+		}
+		if versionId != "" {
+			w.Header().Set("X-Amz-Version-Id", versionId)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported object operation")
+	}
+}
+
+// Checks the If-Match and If-None-Match headers of writes against the current object, which
+// is nil if it doesn't exist. Writes the error and returns false if they don't hold
+func checkWriteConditions(w http.ResponseWriter, r *http.Request, current *object) bool {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if current == nil {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "the object does not exist")
+			return false
+		}
+		if ifMatch != "*" && strings.Trim(ifMatch, `"`) != current.etag {
+			writeError(w, http.StatusPreconditionFailed, "PreconditionFailed", "the ETag of the object doesn't match If-Match")
+			return false
+		}
+	}
+	if r.Header.Get("If-None-Match") == "*" && current != nil {
+		writeError(w, http.StatusPreconditionFailed, "PreconditionFailed", "the object already exists")
+		return false
+	}
+	return true
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, body []byte) {
+	keyMD5, err := sseKeyMD5(r.Header, "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	checksums, err := verifyChecksums(r.Header, body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BadDigest", err.Error())
+		return
+	}
+	tags, err := parseTags(r.Header.Get("X-Amz-Tagging"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+
+	obj := newObject(key, body, r.Header)
+	obj.sseKeyMD5 = keyMD5
+	obj.etag = contentETag(body, keyMD5)
+	obj.checksums = checksums
+	obj.tags = tags
+	s.addVersion(b, obj)
+
+	w.Header().Set("ETag", obj.quotedETag())
+	w.Header().Set("X-Amz-Version-Id", obj.versionId)
+	for algorithm, value := range checksums {
+		w.Header().Set(checksumHeader(algorithm), value)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// The source of copies, as "bucket/key" with an optional "?versionId=". The version may
+// also be given in the query of the destination
+func (s *Server) copySource(r *http.Request) (*object, int, string, string) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		return nil, http.StatusBadRequest, "InvalidArgument", "invalid copy source"
+	}
+	source, rawQuery, _ := strings.Cut(strings.TrimPrefix(source, "/"), "?")
+	versionId := r.URL.Query().Get("versionId")
+	if q, err := url.ParseQuery(rawQuery); err == nil && q.Get("versionId") != "" {
+		versionId = q.Get("versionId")
+	}
+
+	bucketName, key, _ := strings.Cut(source, "/")
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, http.StatusNotFound, "NoSuchBucket", "the source bucket does not exist"
+	}
+	obj := b.version(key, versionId)
+	if obj == nil || obj.deleteMarker {
+		return nil, http.StatusNotFound, "NoSuchKey", "the source object does not exist"
+	}
+	if status, code, message := obj.checkSSEKey(r.Header, copySourceSSEPrefix); status != 0 {
+		return nil, status, code, message
+	}
+	return obj, 0, "", ""
+}
+
+type copyObjectResult struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	ETag         string   `xml:"ETag"`
+	LastModified string   `xml:"LastModified"`
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	src, status, code, message := s.copySource(r)
+	if status != 0 {
+		writeError(w, status, code, message)
+		return
+	}
+	keyMD5, err := sseKeyMD5(r.Header, "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+
+	data := append([]byte(nil), src.data...)
+	obj := newObject(key, data, r.Header)
+	obj.sseKeyMD5 = keyMD5
+	obj.etag = contentETag(data, keyMD5)
+	for algorithm := range src.checksums {
+		obj.checksums[algorithm] = base64.StdEncoding.EncodeToString(checksum(algorithm, data))
+	}
+
+	if r.Header.Get("X-Amz-Metadata-Directive") != "REPLACE" {
+		obj.header = src.header.Clone()
+	}
+	if r.Header.Get("X-Amz-Storage-Class") == "" {
+		obj.storageClass = src.storageClass
+	}
+	obj.tags = src.tags
+	if r.Header.Get("X-Amz-Tagging-Directive") == "REPLACE" {
+		if obj.tags, err = parseTags(r.Header.Get("X-Amz-Tagging")); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+			return
+		}
+	}
+	s.addVersion(b, obj)
+
+	w.Header().Set("X-Amz-Version-Id", obj.versionId)
+	writeXML(w, http.StatusOK, copyObjectResult{ETag: obj.quotedETag(), LastModified: formatLastModified(obj.modified)})
+}
+
+// Parses "bytes=start-end", "bytes=start-" or "bytes=-suffix". ok is false if the range
+// can't be satisfied
+func parseRange(value string, size int64) (start, end int64, ok bool) {
+	spec, found := strings.CutPrefix(value, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	first, last, _ := strings.Cut(spec, "-")
+	var err error
+	switch {
+	case first == "":
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix <= 0 {
+			return 0, 0, false
+		}
+		start, end = max(size-suffix, 0), size-1
+	case last == "":
+		start, err = strconv.ParseInt(first, 10, 64)
+		end = size - 1
+	default:
+		start, err = strconv.ParseInt(first, 10, 64)
+		if err == nil {
+			end, err = strconv.ParseInt(last, 10, 64)
+		}
+		end = min(end, size-1)
+	}
+	if err != nil || start < 0 || start >= size || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	query := r.URL.Query()
+	versionId := query.Get("versionId")
+	obj := b.version(key, versionId)
+	if obj == nil {
+		if versions := b.objects[key]; versionId == "" && len(versions) > 0 {
+			w.Header().Set("X-Amz-Delete-Marker", "true")
+		}
+		writeError(w, http.StatusNotFound, "NoSuchKey", "the object does not exist")
+		return
+	}
+	if obj.deleteMarker {
+		w.Header().Set("X-Amz-Delete-Marker", "true")
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "the version is a delete marker")
+		return
+	}
+	if status, code, message := obj.checkSSEKey(r.Header, ""); status != 0 {
+		writeError(w, status, code, message)
+		return
+	}
+
+	header := w.Header()
+	for name, values := range obj.header {
+		header[name] = values
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "binary/octet-stream")
+	}
+	header.Set("ETag", obj.quotedETag())
+	header.Set("Last-Modified", obj.modified.UTC().Format(http.TimeFormat))
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("X-Amz-Storage-Class", obj.storageClass)
+	header.Set("X-Amz-Version-Id", obj.versionId)
+	if obj.replicationStatus != "" {
+		header.Set("X-Amz-Replication-Status", obj.replicationStatus)
+	}
+	if len(obj.partSizes) > 0 {
+		header.Set("X-Amz-Mp-Parts-Count", strconv.Itoa(len(obj.partSizes)))
+	}
+	if obj.sseKeyMD5 != "" {
+		header.Set(sseCustomerAlgorithmHeader, "AES256")
+		header.Set(sseCustomerKeyMD5Header, obj.sseKeyMD5)
+	}
+	if r.Header.Get("X-Amz-Checksum-Mode") == "ENABLED" {
+		for algorithm, value := range obj.checksums {
+			header.Set(checksumHeader(algorithm), value)
+		}
+	}
+
+	data := obj.data
+	status := http.StatusOK
+	size := int64(len(data))
+	if partNumber, err := strconv.Atoi(query.Get("partNumber")); err == nil {
+		start, end, ok := obj.partRange(partNumber)
+		if !ok {
+			writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidPartNumber", "the requested part number is not satisfiable")
+			return
+		}
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	} else if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		start, end, ok := parseRange(rangeHeader, size)
+		if !ok {
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "the requested range is not satisfiable")
+			return
+		}
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+// Byte range of the part, objects not uploaded in parts have a single part
+func (o *object) partRange(partNumber int) (start, end int64, ok bool) {
+	sizes := o.partSizes
+	if len(sizes) == 0 {
+		sizes = []int64{int64(len(o.data))}
+	}
+	if partNumber < 1 || partNumber > len(sizes) {
+		return 0, 0, false
+	}
+	for _, size := range sizes[:partNumber-1] {
+		start += size
+	}
+	return start, start + sizes[partNumber-1] - 1, true
+}
+
+// Deletes the version, or adds a delete marker if versionId is empty. Keys that don't
+// exist are ignored. Returns the error code and message if the version is locked
+func (s *Server) deleteObject(b *bucket, key, versionId string) (string, string) {
+	versions := b.objects[key]
+	if versionId == "" {
+		if b.latest(key) == nil {
+			return "", ""
+		}
+		s.addVersion(b, &object{key: key, deleteMarker: true, modified: time.Now()})
+		return "", ""
+	}
+
+	for i, obj := range versions {
+		if obj.versionId != versionId {
+			continue
+		}
+		if obj.retention != nil && obj.retention.until.After(time.Now()) {
+			return "AccessDenied", "the object version is locked until " + obj.retention.RetainUntilDate
+		}
+		versions = append(versions[:i], versions[i+1:]...)
+		break
+	}
+	if len(versions) == 0 {
+		delete(b.objects, key)
+	} else {
+		b.objects[key] = versions
+	}
+	return "", ""
+}
+
+type tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+type tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Tags    []tag    `xml:"TagSet>Tag"`
+}
+
+func sortTags(tags []tag) {
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+}
+
+func serveTagging(w http.ResponseWriter, r *http.Request, obj *object, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, tagging{Tags: obj.tags})
+	case http.MethodPut:
+		var t tagging
+		if err := readXML(body, &t); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		sortTags(t.Tags)
+		obj.tags = t.Tags
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		obj.tags = nil
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported tagging operation")
+	}
+}
+
+type retention struct {
+	XMLName         xml.Name `xml:"Retention"`
+	Mode            string   `xml:"Mode"`
+	RetainUntilDate string   `xml:"RetainUntilDate"`
+	until           time.Time
+}
+
+func serveRetention(w http.ResponseWriter, r *http.Request, b *bucket, obj *object, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		if obj.retention == nil {
+			writeError(w, http.StatusNotFound, "NoSuchObjectLockConfiguration", "the object has no retention")
+			return
+		}
+		writeXML(w, http.StatusOK, obj.retention)
+	case http.MethodPut:
+		if b.objectLock == nil {
+			writeError(w, http.StatusBadRequest, "InvalidRequest", "the bucket has no object lock configuration")
+			return
+		}
+		var ret retention
+		if err := readXML(body, &ret); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		until, err := time.Parse(time.RFC3339, ret.RetainUntilDate)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML", "invalid RetainUntilDate: "+err.Error())
+			return
+		}
+		if obj.retention != nil && obj.retention.until.After(until) {
+			writeError(w, http.StatusForbidden, "AccessDenied", "the retention period can't be shortened")
+			return
+		}
+		ret.until = until
+		obj.retention = &ret
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported retention operation")
+	}
+}
@@ -0,0 +1,156 @@
+package s3test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+// Fields which are not covered by the policy conditions
+var unconditionedPostFields = map[string]bool{
+	common.PostPolicyField:          true,
+	common.PostPolicySignatureField: true,
+	"file":                          true,
+}
+
+func isPostObject(r *http.Request) bool {
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	return r.Method == http.MethodPost && bucketName != "" && key == "" &&
+		strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+}
+
+// Browser-based upload, signed by the policy sent among the form fields instead of
+// the request itself
+func (s *Server) postObject(w http.ResponseWriter, r *http.Request) {
+	bucketName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedPOSTRequest", err.Error())
+		return
+	}
+	fields := map[string]string{}
+	var fileName string
+	var data []byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedPOSTRequest", err.Error())
+			return
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		name := strings.ToLower(part.FormName())
+		if name == "file" {
+			fileName, data = part.FileName(), value
+			// Fields after the file are ignored
+			break
+		}
+		fields[name] = string(value)
+	}
+	if fileName == "" && data == nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "POST requires exactly one file upload per request")
+		return
+	}
+
+	policy, err := common.VerifyPostPolicy(fields, AccessKeyID, SecretAccessKey)
+	if err != nil {
+		writeError(w, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+		return
+	}
+	if expiration, err := time.Parse(time.RFC3339, policy.Expiration); err != nil || time.Now().After(expiration) {
+		writeError(w, http.StatusForbidden, "AccessDenied", "invalid according to policy: policy expired")
+		return
+	}
+
+	fields["key"] = strings.ReplaceAll(fields["key"], "${filename}", fileName)
+	fields["bucket"] = bucketName
+	if err = checkPostConditions(policy.Conditions, fields, len(data)); err != nil {
+		writeError(w, http.StatusForbidden, "AccessDenied", "invalid according to policy: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{Method: r.Method, Bucket: bucketName, Query: r.URL.Query()})
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "the bucket does not exist")
+		return
+	}
+
+	header := http.Header{}
+	if contentType := fields["content-type"]; contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	obj := newObject(fields["key"], data, header)
+	obj.etag = md5Hex(data)
+	s.addVersion(b, obj)
+
+	w.Header().Set("ETag", obj.quotedETag())
+	w.Header().Set("X-Amz-Version-Id", obj.versionId)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Every field must match a condition and every condition must be met
+func checkPostConditions(conditions []any, fields map[string]string, size int) error {
+	covered := map[string]bool{"bucket": true}
+	for _, condition := range conditions {
+		switch c := condition.(type) {
+		case map[string]any:
+			for name, expected := range c {
+				name = strings.ToLower(name)
+				if fields[name] != fmt.Sprint(expected) {
+					return fmt.Errorf("condition failed: [\"eq\", \"$%s\", %q]", name, expected)
+				}
+				covered[name] = true
+			}
+		case []any:
+			if len(c) != 3 {
+				return fmt.Errorf("malformed condition %v", c)
+			}
+			op := strings.ToLower(fmt.Sprint(c[0]))
+			if op == "content-length-range" {
+				min, minOk := c[1].(float64)
+				max, maxOk := c[2].(float64)
+				if !minOk || !maxOk {
+					return fmt.Errorf("malformed condition %v", c)
+				}
+				if float64(size) < min || float64(size) > max {
+					return fmt.Errorf("your proposed upload size %d is outside of the allowed range", size)
+				}
+				continue
+			}
+			name := strings.ToLower(strings.TrimPrefix(fmt.Sprint(c[1]), "$"))
+			value, expected := fields[name], fmt.Sprint(c[2])
+			if (op == "eq" && value != expected) || (op == "starts-with" && !strings.HasPrefix(value, expected)) {
+				return fmt.Errorf("condition failed: %v", c)
+			}
+			if op != "eq" && op != "starts-with" {
+				return fmt.Errorf("unsupported condition %v", c)
+			}
+			covered[name] = true
+		default:
+			return fmt.Errorf("malformed condition %v", c)
+		}
+	}
+
+	for name := range fields {
+		if !covered[name] && !unconditionedPostFields[name] && !strings.HasPrefix(name, "x-ignore-") {
+			return fmt.Errorf("extra input fields: %s", name)
+		}
+	}
+	return nil
+}
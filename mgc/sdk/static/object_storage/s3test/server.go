@@ -0,0 +1,352 @@
+// Package s3test provides an in-memory server compatible with the subset of the S3 API
+// used by the object storage commands, so they can be tested end to end without reaching
+// the real service. It's meant to be used like net/http/httptest:
+//
+//	server := s3test.NewServer(t)
+//	ctx := server.Context(context.Background())
+//	cfg := server.Config()
+//
+// Every request must be signed with AccessKeyID and SecretAccessKey, which Context() sets
+// up, or with a key pair added by AddKeyPair(). Signatures are verified with common.VerifySignature(), except for browser-based
+// uploads, whose form carries a POST policy verified with common.VerifyPostPolicy().
+package s3test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MagaluCloud/magalu/mgc/core"
+	"github.com/MagaluCloud/magalu/mgc/core/auth"
+	"github.com/MagaluCloud/magalu/mgc/core/config"
+	mgcHttpPkg "github.com/MagaluCloud/magalu/mgc/core/http"
+	"github.com/MagaluCloud/magalu/mgc/core/profile_manager"
+	"github.com/MagaluCloud/magalu/mgc/sdk/static/object_storage/common"
+)
+
+const (
+	AccessKeyID     = "s3test-access-key"
+	SecretAccessKey = "s3test-secret-key"
+
+	ownerID           = "s3test-owner"
+	unsignedPayload   = "UNSIGNED-PAYLOAD"
+	lastModifiedXML   = "2006-01-02T15:04:05.000Z"
+	defaultMaxKeys    = 1000
+	nullVersionId     = "null"
+	forceDeleteHeader = "X-Force-Container-Delete"
+)
+
+// A request received by the server, recorded for assertions
+type Request struct {
+	Method string
+	Bucket string
+	Key    string
+	Query  url.Values
+	// Access key and region of the signature
+	AccessKeyID string
+	Region      string
+}
+
+type Server struct {
+	// Base URL of the server, such as http://127.0.0.1:1234
+	URL string
+
+	httpServer *httptest.Server
+	mu         sync.Mutex
+	buckets    map[string]*bucket
+	requests   []Request
+	keyPairs   map[string]string
+	lastId     int
+	// Remaining failures per "METHOD bucket/key"
+	failures map[string]int
+}
+
+// Starts a new server, which is closed when the test finishes
+func NewServer(t testing.TB) *Server {
+	s := &Server{buckets: map[string]*bucket{}, keyPairs: map[string]string{AccessKeyID: SecretAccessKey}}
+	s.httpServer = httptest.NewServer(s)
+	s.URL = s.httpServer.URL
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// Configuration pointing the object storage commands to this server
+func (s *Server) Config() common.Config {
+	return common.Config{
+		Workers:       2,
+		ChunkSize:     8,
+		Region:        "br-se1",
+		NetworkConfig: config.NetworkConfig{ServerUrl: s.URL},
+	}
+}
+
+// Returns a context with the HTTP client and the credentials needed to reach this server. It
+// also has an in-memory profile, so state kept in the profile directory doesn't leak between tests
+func (s *Server) Context(parent context.Context) context.Context {
+	pm, _ := profile_manager.NewInMemoryProfileManager()
+	mgcConfig := config.New(pm)
+	mgcConfig.AddTempKeyPair("apikey", AccessKeyID, SecretAccessKey)
+
+	ctx := mgcHttpPkg.NewClientContext(parent, mgcHttpPkg.NewClient(http.DefaultTransport))
+	ctx = profile_manager.NewContext(ctx, pm)
+	ctx = config.NewContext(ctx, mgcConfig)
+	return auth.NewContext(ctx, auth.New(nil, nil, pm, mgcConfig))
+}
+
+// Accepts requests signed with another key pair, such as the ones of another tenant. The
+// server has a single namespace, every key pair reaches all the buckets
+func (s *Server) AddKeyPair(accessKeyID, secretAccessKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keyPairs[accessKeyID] = secretAccessKey
+}
+
+// Access key and region of the credential scope, from the query of presigned URLs or
+// from the Authorization header
+func signatureCredential(r *http.Request) (accessKeyID, region string) {
+	credential := r.URL.Query().Get("X-Amz-Credential")
+	if credential == "" {
+		_, fields, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		for _, field := range strings.Split(fields, ",") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(field), "Credential="); ok {
+				credential = value
+			}
+		}
+	}
+	// id/date/region/service/aws4_request
+	parts := strings.Split(credential, "/")
+	if len(parts) < 3 {
+		return credential, ""
+	}
+	return parts[0], parts[2]
+}
+
+// Requests received so far, in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Counts the requests received with the given method and query parameter, such as
+// ("POST", "delete") for batch deletions. An empty param matches any request
+func (s *Server) CountRequests(method, param string) int {
+	count := 0
+	for _, req := range s.Requests() {
+		if req.Method != method {
+			continue
+		}
+		if _, ok := req.Query[param]; param == "" || ok {
+			count++
+		}
+	}
+	return count
+}
+
+// Makes the next requests with the given method to the object fail with 500 InternalError,
+// the given number of times. A negative number makes them always fail
+func (s *Server) FailRequests(method, bucketName, key string, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures == nil {
+		s.failures = map[string]int{}
+	}
+	s.failures[method+" "+bucketName+"/"+key] = times
+}
+
+// Same as FailRequests(), but only for requests with the given query parameter, such as
+// ("POST", "uploadId") for multipart upload completions
+func (s *Server) FailRequestsWithParam(method, param, bucketName, key string, times int) {
+	s.FailRequests(method+"?"+param, bucketName, key, times)
+}
+
+func (s *Server) shouldFail(method, bucketName, key string, query url.Values) bool {
+	ids := []string{method + " " + bucketName + "/" + key}
+	for param := range query {
+		ids = append(ids, method+"?"+param+" "+bucketName+"/"+key)
+	}
+	for _, id := range ids {
+		remaining := s.failures[id]
+		if remaining > 0 {
+			s.failures[id] = remaining - 1
+		}
+		if remaining != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) newId() string {
+	s.lastId++
+	return fmt.Sprintf("%016x", s.lastId)
+}
+
+type xmlError struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeXML(w, status, xmlError{Code: code, Message: message})
+}
+
+func writeXML(w http.ResponseWriter, status int, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", fmt.Sprint(len(xml.Header)+len(data)))
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, xml.Header)
+	_, _ = w.Write(data)
+}
+
+func readXML(body []byte, v any) error {
+	if err := xml.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("malformed XML: %w", err)
+	}
+	return nil
+}
+
+func hasParam(r *http.Request, name string) bool {
+	_, ok := r.URL.Query()[name]
+	return ok
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func formatLastModified(t time.Time) string {
+	return t.UTC().Format(lastModifiedXML)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isPostObject(r) {
+		s.postObject(w, r)
+		return
+	}
+
+	accessKeyID, region := signatureCredential(r)
+	s.mu.Lock()
+	secretAccessKey, ok := s.keyPairs[accessKeyID]
+	s.mu.Unlock()
+	if !ok {
+		accessKeyID, secretAccessKey = AccessKeyID, SecretAccessKey
+	}
+	if err := common.VerifySignature(r, accessKeyID, secretAccessKey); err != nil {
+		writeError(w, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+
+	if hash := r.Header.Get("X-Amz-Content-Sha256"); hash != "" && hash != unsignedPayload {
+		actual, _ := core.SHA256Hex(bytes.NewReader(body))
+		if actual != hash {
+			writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch", "the provided X-Amz-Content-Sha256 doesn't match the body")
+			return
+		}
+	}
+
+	if expected := r.Header.Get("Content-Md5"); expected != "" {
+		sum := md5.Sum(body)
+		if base64.StdEncoding.EncodeToString(sum[:]) != expected {
+			writeError(w, http.StatusBadRequest, "BadDigest", "the Content-MD5 doesn't match the body")
+			return
+		}
+	}
+
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{
+		Method:      r.Method,
+		Bucket:      bucketName,
+		Key:         key,
+		Query:       r.URL.Query(),
+		AccessKeyID: accessKeyID,
+		Region:      region,
+	})
+
+	if s.shouldFail(r.Method, bucketName, key, r.URL.Query()) {
+		writeError(w, http.StatusInternalServerError, "InternalError", "failure requested by the test")
+		return
+	}
+
+	if bucketName == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported operation")
+			return
+		}
+		s.listBuckets(w)
+		return
+	}
+
+	if key == "" {
+		s.serveBucket(w, r, bucketName, body)
+		return
+	}
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "the bucket does not exist")
+		return
+	}
+	s.serveObject(w, r, b, key, body)
+}
+
+type xmlOwner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+var owner = xmlOwner{ID: ownerID, DisplayName: ownerID}
+
+type xmlBucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type listBucketsResult struct {
+	XMLName xml.Name    `xml:"ListAllMyBucketsResult"`
+	Owner   xmlOwner    `xml:"Owner"`
+	Buckets []xmlBucket `xml:"Buckets>Bucket"`
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter) {
+	result := listBucketsResult{Owner: owner}
+	for _, b := range s.buckets {
+		result.Buckets = append(result.Buckets, xmlBucket{Name: b.name, CreationDate: formatLastModified(b.created)})
+	}
+	sort.Slice(result.Buckets, func(i, j int) bool { return result.Buckets[i].Name < result.Buckets[j].Name })
+	writeXML(w, http.StatusOK, result)
+}